@@ -0,0 +1,313 @@
+package wspubsub
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ http.Handler = (*AdminHandler)(nil)
+
+// AdminHandler is an HTTP API for inspecting and managing the hub.
+// Paths are relative to the mount point, so the handler can be mounted using http.StripPrefix:
+//
+//	mux.Handle("/admin/", http.StripPrefix("/admin", wspubsub.NewAdminHandler(options, hub)))
+//
+// Endpoints:
+//
+//	GET    /clients?offset=0&limit=100           lists connected clients ordered by the connect time
+//	GET    /clients/{id}                         shows a single client
+//	DELETE /clients/{id}                         disconnects a client
+//	GET    /channels?offset=0&limit=100          lists channels with subscriber counts
+//	DELETE /channels/{channel}                   disconnects all clients of a channel
+//	POST   /messages?channel=X&type=text|binary  publishes the request body to the channels (all clients if omitted)
+type AdminHandler struct {
+	options AdminHandlerOptions
+	hub     *Hub
+	mux     *http.ServeMux
+}
+
+type adminClient struct {
+	ID          UUID      `json:"id"`
+	RemoteAddr  string    `json:"remote_addr"`
+	IP          string    `json:"ip"`
+	Identity    string    `json:"identity,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Channels    []string  `json:"channels"`
+	QueueDepth  int       `json:"queue_depth"`
+	RTTMillis   float64   `json:"rtt_ms"`
+}
+
+type adminChannel struct {
+	Name        string `json:"name"`
+	Subscribers int    `json:"subscribers"`
+}
+
+type adminPage struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// bounds returns the range of the page within the total number of items.
+// The end is computed from the remaining items, so a huge offset can't overflow it.
+func (p adminPage) bounds(total int) (start, end int) {
+	start = min(p.Offset, total)
+
+	return start, start + min(p.Limit, total-start)
+}
+
+type adminClientList struct {
+	adminPage
+	Clients []adminClient `json:"clients"`
+}
+
+type adminChannelList struct {
+	adminPage
+	Channels []adminChannel `json:"channels"`
+}
+
+type adminNumClients struct {
+	NumClients int `json:"num_clients"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *AdminHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if h.options.Authenticator != nil {
+		_, err := h.options.Authenticator.Authenticate(request)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if authErr, ok := IsHubAuthenticationError(err); ok {
+				status = authErr.StatusCode
+			}
+
+			h.writeError(response, status, err)
+
+			return
+		}
+	}
+
+	h.mux.ServeHTTP(response, request)
+}
+
+func (h *AdminHandler) listClients(response http.ResponseWriter, request *http.Request) {
+	page, err := h.page(request)
+	if err != nil {
+		h.writeError(response, http.StatusBadRequest, err)
+
+		return
+	}
+
+	infos, err := h.hub.ListClients()
+	if err != nil {
+		h.writeError(response, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	page.Total = len(infos)
+	start, end := page.bounds(len(infos))
+	infos = infos[start:end]
+
+	result := adminClientList{adminPage: page, Clients: make([]adminClient, 0, len(infos))}
+	for _, info := range infos {
+		result.Clients = append(result.Clients, newAdminClient(info))
+	}
+
+	h.writeJSON(response, http.StatusOK, result)
+}
+
+func (h *AdminHandler) showClient(response http.ResponseWriter, request *http.Request) {
+	clientID, err := ParseUUID(request.PathValue("id"))
+	if err != nil {
+		h.writeError(response, http.StatusBadRequest, err)
+
+		return
+	}
+
+	info, err := h.hub.ClientInfo(clientID)
+	if err != nil {
+		h.writeHubError(response, err)
+
+		return
+	}
+
+	h.writeJSON(response, http.StatusOK, newAdminClient(info))
+}
+
+func (h *AdminHandler) disconnectClient(response http.ResponseWriter, request *http.Request) {
+	clientID, err := ParseUUID(request.PathValue("id"))
+	if err != nil {
+		h.writeError(response, http.StatusBadRequest, err)
+
+		return
+	}
+
+	err = h.hub.Disconnect(clientID)
+	if err != nil {
+		h.writeHubError(response, err)
+
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) listChannels(response http.ResponseWriter, request *http.Request) {
+	page, err := h.page(request)
+	if err != nil {
+		h.writeError(response, http.StatusBadRequest, err)
+
+		return
+	}
+
+	infos := h.hub.ListChannels()
+
+	page.Total = len(infos)
+	start, end := page.bounds(len(infos))
+	infos = infos[start:end]
+
+	result := adminChannelList{adminPage: page, Channels: make([]adminChannel, 0, len(infos))}
+	for _, info := range infos {
+		result.Channels = append(result.Channels, adminChannel{Name: info.Name, Subscribers: info.Subscribers})
+	}
+
+	h.writeJSON(response, http.StatusOK, result)
+}
+
+func (h *AdminHandler) disconnectChannel(response http.ResponseWriter, request *http.Request) {
+	numClients, err := h.hub.DisconnectChannel(request.PathValue("channel"))
+	if err != nil {
+		h.writeHubError(response, err)
+
+		return
+	}
+
+	h.writeJSON(response, http.StatusOK, adminNumClients{NumClients: numClients})
+}
+
+func (h *AdminHandler) publish(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+
+	messageType := MessageTypeText
+	switch query.Get("type") {
+	case "", "text":
+	case "binary":
+		messageType = MessageTypeBinary
+	default:
+		h.writeError(response, http.StatusBadRequest, errors.Errorf("invalid message type: %q", query.Get("type")))
+
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(response, request.Body, h.options.MaxMessageSize))
+	if err != nil {
+		h.writeError(response, http.StatusRequestEntityTooLarge, err)
+
+		return
+	}
+
+	message := Message{Type: messageType, Payload: payload}
+
+	numClients, err := h.hub.PublishContext(request.Context(), message, query["channel"]...)
+	if err != nil {
+		h.writeHubError(response, err)
+
+		return
+	}
+
+	h.writeJSON(response, http.StatusOK, adminNumClients{NumClients: numClients})
+}
+
+func (h *AdminHandler) page(request *http.Request) (adminPage, error) {
+	page := adminPage{Limit: h.options.DefaultPageSize}
+	query := request.URL.Query()
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return page, errors.Errorf("invalid offset: %q", value)
+		}
+
+		page.Offset = offset
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return page, errors.Errorf("invalid limit: %q", value)
+		}
+
+		page.Limit = limit
+	}
+
+	if page.Limit > h.options.MaxPageSize {
+		page.Limit = h.options.MaxPageSize
+	}
+
+	return page, nil
+}
+
+func (h *AdminHandler) writeHubError(response http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if _, ok := IsClientNotFoundError(err); ok {
+		status = http.StatusNotFound
+	}
+
+	h.writeError(response, status, err)
+}
+
+func (h *AdminHandler) writeError(response http.ResponseWriter, status int, err error) {
+	h.writeJSON(response, status, adminError{Error: err.Error()})
+}
+
+func (h *AdminHandler) writeJSON(response http.ResponseWriter, status int, v interface{}) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+
+	_ = json.NewEncoder(response).Encode(v)
+}
+
+func newAdminClient(info ClientInfo) adminClient {
+	channels := info.Channels
+	if channels == nil {
+		channels = []string{}
+	}
+
+	return adminClient{
+		ID:          info.ID,
+		RemoteAddr:  info.RemoteAddr,
+		IP:          info.IP,
+		Identity:    info.Identity.ID,
+		ConnectedAt: info.ConnectedAt,
+		Channels:    channels,
+		QueueDepth:  info.QueueDepth,
+		RTTMillis:   float64(info.RTT) / float64(time.Millisecond),
+	}
+}
+
+// NewAdminHandler initializes a new AdminHandler.
+func NewAdminHandler(options AdminHandlerOptions, hub *Hub) *AdminHandler {
+	handler := &AdminHandler{
+		options: options,
+		hub:     hub,
+		mux:     http.NewServeMux(),
+	}
+
+	handler.mux.HandleFunc("GET /clients", handler.listClients)
+	handler.mux.HandleFunc("GET /clients/{id}", handler.showClient)
+	handler.mux.HandleFunc("DELETE /clients/{id}", handler.disconnectClient)
+	handler.mux.HandleFunc("GET /channels", handler.listChannels)
+	handler.mux.HandleFunc("DELETE /channels/{channel...}", handler.disconnectChannel)
+	handler.mux.HandleFunc("POST /messages", handler.publish)
+
+	return handler
+}
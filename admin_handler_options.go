@@ -0,0 +1,30 @@
+package wspubsub
+
+// AdminHandlerOptions represents configuration of the AdminHandler.
+type AdminHandlerOptions struct {
+	// Authenticates a request to the admin API (nil disables authentication).
+	// The admin API exposes and manages all clients of the hub,
+	// so it must be protected unless it is served on a private network.
+	Authenticator Authenticator
+
+	// Number of items returned by list endpoints if the limit wasn't specified
+	DefaultPageSize int
+
+	// Max number of items returned by list endpoints
+	MaxPageSize int
+
+	// Max size of a test message payload in bytes
+	MaxMessageSize int64
+}
+
+// NewAdminHandlerOptions initializes a new AdminHandlerOptions.
+// nolint: gomnd
+func NewAdminHandlerOptions() AdminHandlerOptions {
+	options := AdminHandlerOptions{
+		DefaultPageSize: 100,
+		MaxPageSize:     1000,
+		MaxMessageSize:  64 * 1024,
+	}
+
+	return options
+}
@@ -0,0 +1,16 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAdminHandlerOptions(t *testing.T) {
+	options := wspubsub.NewAdminHandlerOptions()
+	require.Nil(t, options.Authenticator)
+	require.Equal(t, 100, options.DefaultPageSize)
+	require.Equal(t, 1000, options.MaxPageSize)
+	require.Equal(t, int64(64*1024), options.MaxMessageSize)
+}
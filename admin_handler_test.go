@@ -0,0 +1,267 @@
+package wspubsub_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/mock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	otherClientID := wspubsub.UUID([16]byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	message := wspubsub.NewTextMessageFromString("hello")
+
+	client := newAdminMockClient(ctrl, clientID)
+	otherClient := newAdminMockClient(ctrl, otherClientID)
+
+	client.
+		EXPECT().
+		RTT().
+		AnyTimes().
+		Return(1500 * time.Microsecond)
+
+	otherClient.
+		EXPECT().
+		RTT().
+		AnyTimes().
+		Return(time.Duration(0))
+
+	client.
+		EXPECT().
+		Send(gomock.Any()).
+		Times(1).
+		Do(func(m wspubsub.Message) {
+			require.Equal(t, message.Payload, m.Payload)
+		})
+
+	client.
+		EXPECT().
+		Close().
+		Times(1)
+
+	otherClient.
+		EXPECT().
+		Close().
+		Times(1)
+
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	gomock.InOrder(
+		clientFactory.EXPECT().Create().Return(client),
+		clientFactory.EXPECT().Create().Return(otherClient),
+	)
+
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	for i := 0; i < 2; i++ {
+		hub.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	require.NoError(t, hub.Subscribe(clientID, "X", "Y"))
+	require.NoError(t, hub.Subscribe(otherClientID, "Y"))
+
+	handler := wspubsub.NewAdminHandler(wspubsub.NewAdminHandlerOptions(), hub)
+
+	t.Run("List clients", func(t *testing.T) {
+		result := struct {
+			Total   int                      `json:"total"`
+			Offset  int                      `json:"offset"`
+			Limit   int                      `json:"limit"`
+			Clients []map[string]interface{} `json:"clients"`
+		}{}
+
+		status := serveAdminRequest(t, handler, "GET", "/clients", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, result.Total)
+		require.Equal(t, 100, result.Limit)
+		require.Len(t, result.Clients, 2)
+		require.Equal(t, clientID.String(), result.Clients[0]["id"])
+		require.Equal(t, otherClientID.String(), result.Clients[1]["id"])
+
+		status = serveAdminRequest(t, handler, "GET", "/clients?offset=1&limit=1", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, result.Total)
+		require.Len(t, result.Clients, 1)
+		require.Equal(t, otherClientID.String(), result.Clients[0]["id"])
+
+		status = serveAdminRequest(t, handler, "GET", "/clients?offset=5", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Empty(t, result.Clients)
+
+		status = serveAdminRequest(t, handler, "GET", "/clients?offset=9223372036854775807", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, result.Total)
+		require.Empty(t, result.Clients)
+
+		status = serveAdminRequest(t, handler, "GET", "/clients?limit=-1", "", nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Show client", func(t *testing.T) {
+		result := map[string]interface{}{}
+
+		status := serveAdminRequest(t, handler, "GET", "/clients/"+clientID.String(), "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, clientID.String(), result["id"])
+		require.Equal(t, "192.0.2.1:1234", result["remote_addr"])
+		require.Equal(t, "192.0.2.1", result["ip"])
+		require.ElementsMatch(t, []interface{}{"X", "Y"}, result["channels"])
+		require.Equal(t, float64(3), result["queue_depth"])
+		require.Equal(t, 1.5, result["rtt_ms"])
+		require.NotEmpty(t, result["connected_at"])
+
+		status = serveAdminRequest(t, handler, "GET", "/clients/invalid", "", nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status = serveAdminRequest(t, handler, "GET", "/clients/"+wspubsub.UUID{}.String(), "", nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("List channels", func(t *testing.T) {
+		result := struct {
+			Total    int `json:"total"`
+			Channels []struct {
+				Name        string `json:"name"`
+				Subscribers int    `json:"subscribers"`
+			} `json:"channels"`
+		}{}
+
+		status := serveAdminRequest(t, handler, "GET", "/channels", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, result.Total)
+		require.Len(t, result.Channels, 2)
+		require.Equal(t, "X", result.Channels[0].Name)
+		require.Equal(t, 1, result.Channels[0].Subscribers)
+		require.Equal(t, "Y", result.Channels[1].Name)
+		require.Equal(t, 2, result.Channels[1].Subscribers)
+
+		status = serveAdminRequest(t, handler, "GET", "/channels?offset=9223372036854775807&limit=10", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, result.Total)
+		require.Empty(t, result.Channels)
+	})
+
+	t.Run("Publish message", func(t *testing.T) {
+		result := map[string]int{}
+
+		status := serveAdminRequest(t, handler, "POST", "/messages?channel=X", "hello", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 1, result["num_clients"])
+
+		status = serveAdminRequest(t, handler, "POST", "/messages?type=unknown", "hello", nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Disconnect channel", func(t *testing.T) {
+		result := map[string]int{}
+
+		status := serveAdminRequest(t, handler, "DELETE", "/channels/X", "", &result)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 1, result["num_clients"])
+		require.Equal(t, 1, hub.Count())
+	})
+
+	t.Run("Disconnect client", func(t *testing.T) {
+		status := serveAdminRequest(t, handler, "DELETE", "/clients/"+otherClientID.String(), "", nil)
+		require.Equal(t, http.StatusNoContent, status)
+		require.Equal(t, 0, hub.Count())
+
+		status = serveAdminRequest(t, handler, "DELETE", "/clients/"+otherClientID.String(), "", nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestAdminHandler_Authentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	options := wspubsub.NewAdminHandlerOptions()
+	options.Authenticator = wspubsub.AuthenticatorFunc(func(request *http.Request) (wspubsub.Identity, error) {
+		if request.Header.Get("Authorization") == "" {
+			return wspubsub.Identity{}, errors.New("token is required")
+		}
+
+		return wspubsub.Identity{}, wspubsub.NewHubForbiddenError(errors.New("not an admin"))
+	})
+
+	handler := wspubsub.NewAdminHandler(options, hub)
+
+	status := serveAdminRequest(t, handler, "GET", "/channels", "", nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	request := httptest.NewRequest("GET", "/channels", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	require.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func newAdminMockClient(ctrl *gomock.Controller, id wspubsub.UUID) *mock.MockWebsocketClient {
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(id)
+
+	client.
+		EXPECT().
+		OnReceive(gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		OnError(gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		Connect(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		QueueDepth().
+		AnyTimes().
+		Return(3)
+
+	return client
+}
+
+func serveAdminRequest(t *testing.T, handler http.Handler, method, target, body string, result interface{}) int {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if result != nil {
+		err := json.Unmarshal(response.Body.Bytes(), result)
+		require.NoError(t, err)
+	}
+
+	return response.Code
+}
@@ -0,0 +1,32 @@
+package wspubsub
+
+import "sync"
+
+// channelHistory keeps the last published messages of a channel.
+type channelHistory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Append adds a message evicting the oldest ones which exceed the size.
+func (h *channelHistory) Append(message Message, size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.messages = append(h.messages, message)
+	if len(h.messages) > size {
+		// The evicted messages are released once append reallocates the array
+		h.messages = h.messages[len(h.messages)-size:]
+	}
+}
+
+// Messages returns a copy of the messages ordered from the oldest to the newest.
+func (h *channelHistory) Messages() []Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	messages := make([]Message, len(h.messages))
+	copy(messages, h.messages)
+
+	return messages
+}
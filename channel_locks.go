@@ -0,0 +1,38 @@
+package wspubsub
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const channelLocksCount = 64
+
+// channelLocks serializes operations on the same channels.
+// Channels are spread over a fixed number of mutexes, so the locks never have to be reclaimed.
+type channelLocks struct {
+	mutexes [channelLocksCount]sync.Mutex
+}
+
+// Lock acquires the mutexes of the channels and returns a function releasing them.
+// The mutexes are acquired in the order of their indexes to avoid deadlocks.
+func (l *channelLocks) Lock(channels []string) (unlock func()) {
+	indexes := make([]int, 0, len(channels))
+	for _, channel := range channels {
+		indexes = append(indexes, int(xxhash.Sum64String(channel)%channelLocksCount))
+	}
+
+	slices.Sort(indexes)
+	indexes = slices.Compact(indexes)
+
+	for _, index := range indexes {
+		l.mutexes[index].Lock()
+	}
+
+	return func() {
+		for _, index := range indexes {
+			l.mutexes[index].Unlock()
+		}
+	}
+}
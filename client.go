@@ -1,24 +1,66 @@
 package wspubsub
 
 import (
+	"context"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var (
+	_ conflatingClient       = (*Client)(nil)
+	_ statsAggregatingClient = (*Client)(nil)
+	_ contextClient          = (*Client)(nil)
+)
+
+// conflatingClient is implemented by clients able to replace undelivered messages
+// of conflated channels (see NamespacePolicy.Conflation).
+type conflatingClient interface {
+	sendConflated(ctx context.Context, key string, message Message) error
+}
+
+// contextClient is implemented by clients able to link messages with a trace context.
+type contextClient interface {
+	SendContext(ctx context.Context, message Message) error
+	OnReceiveContext(handler ReceiveContextHandler)
+}
+
+// statsAggregatingClient is implemented by clients able to update the hub-wide statistics.
+type statsAggregatingClient interface {
+	aggregateStats(parent *trafficCounters)
+}
+
+// queuedMessage is a message waiting in the send buffer
+// along with the span context of its sending.
+type queuedMessage struct {
+	message     Message
+	spanContext trace.SpanContext
+}
+
 // Client represents a connection to the WebSocket server.
 type Client struct {
 	options        ClientOptions
 	id             UUID
 	upgrader       WebsocketConnectionUpgrader
 	logger         Logger
+	tracer         trace.Tracer
 	receiveHandler atomic.Value
 	errorHandler   atomic.Value
-	connection     WebsocketConnection
-	messages       chan Message
+	connection     atomic.Value
+	messages       chan queuedMessage
+	counters       trafficCounters
+	conflated      sync.Map
+	conflatedMu    sync.Mutex
+	closeMessage   atomic.Value
+	remoteAddr     string
 	isConnected    bool
+	isClosed       atomic.Bool
+	stop           context.CancelFunc
 	quit           chan struct{}
 }
 
@@ -28,16 +70,12 @@ func (c *Client) ID() UUID {
 }
 
 // Connect upgrades the HTTP server connection to the WebSocket protocol.
-func (c *Client) Connect(response http.ResponseWriter, request *http.Request) error {
-	if c.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > c.options.DebugFuncTimeLimit {
-				c.logger.Warnf("wspubsub.client.connect: took=%s", end)
-			}
-		}()
-	}
+func (c *Client) Connect(response http.ResponseWriter, request *http.Request) (err error) {
+	op := startOperation(
+		c.options.Observer,
+		OperationEvent{Name: "wspubsub.client.connect", ClientID: c.id, RemoteAddr: request.RemoteAddr},
+	)
+	defer op.end(&err)
 
 	if c.isConnected {
 		return NewClientRepeatConnectError(c.id)
@@ -48,17 +86,42 @@ func (c *Client) Connect(response http.ResponseWriter, request *http.Request) er
 		return errors.WithStack(NewClientConnectError(c.id, err))
 	}
 
-	c.connection = connection
+	if binder, ok := connection.(WebsocketConnectionBinder); ok {
+		err = binder.Bind(c.id)
+		if err != nil {
+			_ = connection.Close()
+
+			return errors.WithStack(NewClientConnectError(c.id, err))
+		}
+	}
+
+	c.connection.Store(connection)
+	c.remoteAddr = request.RemoteAddr
 	c.isConnected = true
+	c.counters.Connected(time.Now())
+
+	// The context is done once the connection is closed or broken
+	ctx, stop := context.WithCancel(context.Background())
+	c.stop = stop
+
+	go c.runReader(ctx)
+	go c.runWriter(stop)
 
-	go c.runReader()
-	go c.runWriter()
+	c.logger.Debug("Client connected", LogFieldRemoteAddr, c.remoteAddr)
 
 	return nil
 }
 
 // OnReceive registers a handler for incoming messages.
 func (c *Client) OnReceive(handler ReceiveHandler) {
+	c.receiveHandler.Store(ReceiveContextHandler(func(ctx context.Context, clientID UUID, message Message) {
+		handler(clientID, message)
+	}))
+}
+
+// OnReceiveContext registers a handler for incoming messages
+// which gets a context carrying the span of receiving.
+func (c *Client) OnReceiveContext(handler ReceiveContextHandler) {
 	c.receiveHandler.Store(handler)
 }
 
@@ -69,37 +132,112 @@ func (c *Client) OnError(handler ErrorHandler) {
 
 // Send writes a message to client connection asynchronously.
 func (c *Client) Send(message Message) error {
-	if c.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > c.options.DebugFuncTimeLimit {
-				c.logger.Warnf("wspubsub.client.send: took=%s", end)
-			}
-		}()
+	return c.SendContext(context.Background(), message)
+}
+
+// SendContext writes a message to client connection asynchronously
+// like Send. The span of the context becomes a parent of the sending span.
+// It fails with ClientClosedError once the client is closed.
+func (c *Client) SendContext(ctx context.Context, message Message) (err error) {
+	op := startOperation(
+		c.options.Observer,
+		OperationEvent{
+			Name:       "wspubsub.client.send",
+			ClientID:   c.id,
+			RemoteAddr: c.remoteAddr,
+			Size:       len(message.Payload),
+		},
+	)
+	defer op.end(&err)
+
+	if c.isClosed.Load() {
+		return errors.WithStack(NewClientClosedError(c.id))
 	}
 
+	message = c.withDefaultExpiry(message)
+
+	_, span := c.tracer.Start(
+		ctx,
+		"wspubsub.client.send",
+		trace.WithAttributes(attribute.String("wspubsub.client.id", c.id.String())),
+	)
+
+	// Counted in advance since the writer can take the message immediately
+	size := len(message.Payload)
+	c.counters.Enqueued(size)
+
 	select {
-	case c.messages <- message:
+	case c.messages <- queuedMessage{message: message, spanContext: span.SpanContext()}:
 	default:
-		return errors.WithStack(NewClientSendBufferOverflowError(c.id))
+		c.counters.Enqueued(-size)
+		c.counters.Dropped(size)
+		if c.options.Metrics != nil {
+			c.options.Metrics.MessageDropped(DropReasonOverflow)
+		}
+
+		c.logger.Debug("Client send buffer is full", LogFieldRemoteAddr, c.remoteAddr)
+
+		err = errors.WithStack(NewClientSendBufferOverflowError(c.id))
+		endSpan(span, err)
+
+		return err
 	}
 
+	span.End()
+
 	return nil
 }
 
-// Close closes a client connection.
-func (c *Client) Close() error {
-	if c.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > c.options.DebugFuncTimeLimit {
-				c.logger.Warnf("wspubsub.client.close: took=%s", end)
-			}
-		}()
+// SendConflated writes a message to client connection asynchronously
+// replacing a message with the same key which is still waiting in the send buffer.
+func (c *Client) SendConflated(key string, message Message) error {
+	return c.sendConflated(context.Background(), key, message)
+}
+
+func (c *Client) sendConflated(ctx context.Context, key string, message Message) error {
+	message = c.withDefaultExpiry(message)
+
+	// Senders are serialized, so a message is never left in the map without a marker
+	c.conflatedMu.Lock()
+	defer c.conflatedMu.Unlock()
+
+	latest := &queuedMessage{message: message, spanContext: trace.SpanContextFromContext(ctx)}
+	previous, ok := c.conflated.Swap(key, latest)
+	if ok {
+		// The replaced message is still counted in the queue by its marker
+		size := len(previous.(*queuedMessage).message.Payload)
+		c.counters.Enqueued(len(message.Payload) - size)
+		c.counters.Dropped(size)
+		if c.options.Metrics != nil {
+			c.options.Metrics.MessageDropped(DropReasonConflated)
+		}
+
+		return nil
+	}
+
+	// The writer takes the latest message by the key once it reaches the marker
+	marker := message
+	marker.conflationKey = key
+
+	err := c.SendContext(ctx, marker)
+	if err != nil {
+		// Only the own message is removed, a message of another sender must stay queued
+		c.conflated.CompareAndDelete(key, latest)
+
+		return err
 	}
 
+	return nil
+}
+
+// Close closes a client connection.
+func (c *Client) Close() (err error) {
+	op := startOperation(
+		c.options.Observer,
+		OperationEvent{Name: "wspubsub.client.close", ClientID: c.id, RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
 	if !c.isConnected {
 		return nil
 	}
@@ -108,21 +246,75 @@ func (c *Client) Close() error {
 		c.isConnected = false
 	}()
 
+	c.isClosed.Store(true)
+	c.stop()
 	c.quit <- struct{}{}
 
-	err := c.connection.Close()
+	// The writer is stopped at this point,
+	// so the close message can be written directly
+	connection := c.connection.Load().(WebsocketConnection)
+	if closeMessage, ok := c.closeMessage.Load().(Message); ok {
+		_ = connection.Write(closeMessage)
+	}
+
+	err = connection.Close()
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
+	c.logger.Debug("Client closed", LogFieldRemoteAddr, c.remoteAddr)
+
 	return nil
 }
 
-func (c *Client) runReader() {
-	receiveHandler := c.receiveHandler.Load().(ReceiveHandler)
+// QueueDepth returns the number of messages waiting to be written to the connection.
+func (c *Client) QueueDepth() int {
+	return len(c.messages)
+}
+
+// RTT returns the round-trip time of the last answered ping
+// (zero if the client isn't connected or the ping wasn't answered yet).
+func (c *Client) RTT() time.Duration {
+	meter, ok := c.connection.Load().(WebsocketConnectionRTTMeter)
+	if !ok {
+		return 0
+	}
+
+	return meter.RTT()
+}
+
+// Stats returns queue and traffic statistics of the client.
+func (c *Client) Stats() ClientStats {
+	stats := c.counters.ClientStats()
+	stats.ID = c.id
+	stats.QueueDepth = c.QueueDepth()
+	stats.RTT = c.RTT()
+
+	return stats
+}
+
+// CloseWithCode sends a close message with the status code and reason, then closes a client connection.
+func (c *Client) CloseWithCode(code CloseCode, reason string) error {
+	c.closeMessage.Store(NewCloseMessage(code, reason))
+
+	return c.Close()
+}
+
+func (c *Client) runReader(ctx context.Context) {
+	connection := c.connection.Load().(WebsocketConnection)
+	receiveHandler := c.receiveHandler.Load().(ReceiveContextHandler)
 	errorHandler := c.errorHandler.Load().(ErrorHandler)
+	limit := c.options.ReceiveRateLimit
+	messagesBucket := newTokenBucket(limit.MessagesPerSecond, limit.MessagesBurst)
+	bytesBucket := newTokenBucket(limit.BytesPerSecond, limit.BytesBurst)
+	isWarned := false
 	for {
-		message, err := c.connection.Read()
+		message, err := connection.Read()
+		if ctx.Err() != nil {
+			// Neither messages nor errors of the stopped connection are reported
+			return
+		}
+
 		if err != nil {
 			err := errors.WithStack(NewClientReceiveError(c.id, message, err))
 			errorHandler(c.id, err)
@@ -130,11 +322,68 @@ func (c *Client) runReader() {
 			return
 		}
 
-		receiveHandler(c.id, message)
+		now := time.Now()
+		size := len(message.Payload)
+
+		c.counters.Received(size, now)
+		if c.options.Metrics != nil {
+			c.options.Metrics.MessageReceived(size)
+		}
+
+		if limit.Policy == RateLimitPolicyDelay {
+			delay := messagesBucket.Reserve(now, 1)
+			if bytesDelay := bytesBucket.Reserve(now, size); bytesDelay > delay {
+				delay = bytesDelay
+			}
+
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+
+			c.receive(receiveHandler, message)
+
+			continue
+		}
+
+		if !messagesBucket.Allow(now, 1) || !bytesBucket.Allow(now, size) {
+			if !isWarned {
+				c.logger.Debug("Client receive rate limit exceeded", LogFieldRemoteAddr, c.remoteAddr)
+			}
+
+			switch limit.Policy {
+			case RateLimitPolicyWarn:
+				// A single warning is sent until the limit allows a message again,
+				// so a flood can't push published messages out of the send buffer
+				if !isWarned {
+					_ = c.Send(limit.WarningMessage)
+					isWarned = true
+				}
+			case RateLimitPolicyDisconnect:
+				c.closeMessage.Store(NewCloseMessage(CloseCodePolicyViolation, "rate limit exceeded"))
+				err := errors.WithStack(NewClientRateLimitError(c.id, message))
+				errorHandler(c.id, err)
+
+				return
+			}
+
+			c.counters.Dropped(size)
+			if c.options.Metrics != nil {
+				c.options.Metrics.MessageDropped(DropReasonRateLimit)
+			}
+
+			continue
+		}
+
+		messagesBucket.Take(1)
+		bytesBucket.Take(size)
+		isWarned = false
+
+		c.receive(receiveHandler, message)
 	}
 }
 
-func (c *Client) runWriter() {
+func (c *Client) runWriter(stop context.CancelFunc) {
+	connection := c.connection.Load().(WebsocketConnection)
 	pingMessage := NewPingMessage()
 	pingTicker := time.NewTicker(c.options.PingInterval)
 	defer pingTicker.Stop()
@@ -147,35 +396,134 @@ func (c *Client) runWriter() {
 		case <-c.quit:
 			return
 		case <-pings:
-			err := c.connection.Write(pingMessage)
+			err := connection.Write(pingMessage)
 			if err != nil {
+				c.counters.WriteFailed()
+				stop()
+
+				// The handler may close the client which waits for the writer,
+				// so it's called asynchronously
 				err := errors.WithStack(NewClientPingError(c.id, pingMessage, err))
-				errorHandler(c.id, err)
+				go errorHandler(c.id, err)
 				pings = nil
+
+				continue
+			}
+
+			c.counters.Written(time.Now())
+		case queued := <-messages:
+			if key := queued.message.conflationKey; key != "" {
+				latest, ok := c.conflated.LoadAndDelete(key)
+				if !ok {
+					c.counters.Enqueued(-len(queued.message.Payload))
+
+					continue
+				}
+
+				queued = *latest.(*queuedMessage)
+			}
+
+			message := queued.message
+
+			size := len(message.Payload)
+			c.counters.Enqueued(-size)
+
+			// Outdated messages are worse than missed ones
+			now := time.Now()
+			if !message.expiresAt.IsZero() && now.After(message.expiresAt) {
+				c.counters.Dropped(size)
+				if c.options.Metrics != nil {
+					c.options.Metrics.MessageDropped(DropReasonExpired)
+				}
+
+				continue
 			}
-		case message := <-messages:
-			err := c.connection.Write(message)
+
+			err := c.write(connection, message, queued.spanContext)
 			if err != nil {
+				c.counters.WriteFailed()
+				stop()
 				err := errors.WithStack(NewClientSendError(c.id, message, err))
-				errorHandler(c.id, err)
+				go errorHandler(c.id, err)
 				messages = nil
+
+				continue
+			}
+
+			c.counters.Sent(size, time.Now())
+			if c.options.Metrics != nil {
+				c.options.Metrics.MessageSent(size)
 			}
 		}
 	}
 }
 
+// aggregateStats makes the client update the parent counters as well.
+// It must be called before the client is connected.
+func (c *Client) aggregateStats(parent *trafficCounters) {
+	c.counters.parent = parent
+}
+
+// withDefaultExpiry sets the expiry of the message according to the TTL option
+// unless the message expires on its own.
+func (c *Client) withDefaultExpiry(message Message) Message {
+	if c.options.MessageTTL > 0 && message.expiresAt.IsZero() {
+		message.expiresAt = time.Now().Add(c.options.MessageTTL)
+	}
+
+	return message
+}
+
+func (c *Client) receive(handler ReceiveContextHandler, message Message) {
+	ctx := context.Background()
+	if c.options.MessagePropagator != nil {
+		ctx = c.options.MessagePropagator.Extract(ctx, message)
+	}
+
+	ctx, span := c.tracer.Start(
+		ctx,
+		"wspubsub.client.receive",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("wspubsub.client.id", c.id.String()),
+			attribute.Int("wspubsub.message.size", len(message.Payload)),
+		),
+	)
+	defer span.End()
+
+	handler(ctx, c.id, message)
+}
+
+func (c *Client) write(connection WebsocketConnection, message Message, spanContext trace.SpanContext) error {
+	_, span := c.tracer.Start(
+		trace.ContextWithSpanContext(context.Background(), spanContext),
+		"wspubsub.connection.write",
+		trace.WithAttributes(
+			attribute.String("wspubsub.client.id", c.id.String()),
+			attribute.Int("wspubsub.message.size", len(message.Payload)),
+		),
+	)
+
+	err := connection.Write(message)
+	endSpan(span, err)
+
+	return err
+}
+
 // NewClient initializes a new Client.
+// Messages of the client are logged with its ID (nil logger discards them).
 func NewClient(options ClientOptions, id UUID, upgrader WebsocketConnectionUpgrader, logger Logger) *Client {
 	client := &Client{
 		options:  options,
 		id:       id,
 		upgrader: upgrader,
-		logger:   logger,
-		messages: make(chan Message, options.SendBufferSize),
+		logger:   loggerOrNop(logger).With(LogFieldClientID, id),
+		tracer:   newTracer(options.TracerProvider),
+		messages: make(chan queuedMessage, options.SendBufferSize),
 		quit:     make(chan struct{}),
 	}
 
-	client.receiveHandler.Store(defaultReceiveHandler)
+	client.receiveHandler.Store(defaultReceiveContextHandler)
 	client.errorHandler.Store(defaultErrorHandler)
 
 	return client
@@ -0,0 +1,29 @@
+package wspubsub
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ClientClosedError returned when a message is sent to a closed client.
+type ClientClosedError struct {
+	ID UUID
+}
+
+// ClientClosedError implements an error interface.
+func (e *ClientClosedError) Error() string {
+	return fmt.Sprintf("wspubsub: client is closed: id=%s", e.ID)
+}
+
+// NewClientClosedError initializes a new ClientClosedError.
+func NewClientClosedError(id UUID) *ClientClosedError {
+	return &ClientClosedError{ID: id}
+}
+
+// IsClientClosedError checks if error type is ClientClosedError.
+func IsClientClosedError(err error) (*ClientClosedError, bool) {
+	v, ok := errors.Cause(err).(*ClientClosedError)
+
+	return v, ok
+}
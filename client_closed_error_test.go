@@ -0,0 +1,24 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientClosedError(t *testing.T) {
+	rawErr := errors.New("TEST")
+	err := wspubsub.NewClientClosedError(clientID)
+	require.Equal(t, clientID, err.ID)
+	require.NotEmpty(t, clientID, err.Error())
+
+	e, ok := wspubsub.IsClientClosedError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsClientClosedError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -0,0 +1,31 @@
+package wspubsub
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ClientDecodeError returned when a received message can't be decoded by a typed receive handler.
+type ClientDecodeError struct {
+	ID      UUID
+	Message Message
+	Err     error
+}
+
+// ClientDecodeError implements an error interface.
+func (e *ClientDecodeError) Error() string {
+	return fmt.Sprintf("wspubsub: client failed to decode a message: id=%s, err=%s", e.ID, e.Err)
+}
+
+// NewClientDecodeError initializes a new ClientDecodeError.
+func NewClientDecodeError(id UUID, message Message, err error) *ClientDecodeError {
+	return &ClientDecodeError{ID: id, Message: message, Err: err}
+}
+
+// IsClientDecodeError checks if error type is ClientDecodeError.
+func IsClientDecodeError(err error) (*ClientDecodeError, bool) {
+	v, ok := errors.Cause(err).(*ClientDecodeError)
+
+	return v, ok
+}
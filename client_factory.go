@@ -2,6 +2,7 @@ package wspubsub
 
 import (
 	"net/http"
+	"time"
 )
 
 // WebsocketConnectionUpgrader upgrades HTTP connection to the WebSocket connection.
@@ -16,6 +17,20 @@ type WebsocketConnection interface {
 	Close() error
 }
 
+// WebsocketConnectionBinder is an optional interface of WebsocketConnection
+// implemented by connections which need to know the ID of their client,
+// e.g. to route upstream messages sent by separate HTTP requests.
+type WebsocketConnectionBinder interface {
+	Bind(clientID UUID) error
+}
+
+// WebsocketConnectionRTTMeter is an optional interface of WebsocketConnection
+// implemented by connections which measure the round-trip time of pings.
+type WebsocketConnectionRTTMeter interface {
+	// RTT returns the round-trip time of the last answered ping
+	RTT() time.Duration
+}
+
 // UUIDGenerator generates UUID v4.
 type UUIDGenerator interface {
 	GenerateV4() UUID
@@ -35,6 +50,7 @@ func (f *ClientFactory) Create() WebsocketClient {
 }
 
 // NewClientFactory initializes a new ClientFactory.
+// The logger is passed to the created clients.
 func NewClientFactory(
 	options ClientOptions,
 	uuidGenerator UUIDGenerator,
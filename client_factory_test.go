@@ -21,8 +21,7 @@ func TestClientFactory_Create(t *testing.T) {
 
 	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
 	clientOptions := wspubsub.NewClientOptions()
-	logger := mock.NewMockLogger(ctrl)
-	factory := wspubsub.NewClientFactory(clientOptions, uuidGenerator, upgrader, logger)
+	factory := wspubsub.NewClientFactory(clientOptions, uuidGenerator, upgrader, nil)
 	client := factory.Create()
 
 	require.NotNil(t, client)
@@ -0,0 +1,27 @@
+package wspubsub
+
+import (
+	"time"
+)
+
+// ClientInfo represents details of a connected client.
+type ClientInfo struct {
+	ID          UUID
+	RemoteAddr  string
+	IP          string
+	ConnectedAt time.Time
+	Identity    Identity
+	Channels    []string
+
+	// Number of messages waiting to be written to the connection
+	QueueDepth int
+
+	// Round-trip time of the last answered ping
+	RTT time.Duration
+}
+
+// ChannelInfo represents details of a channel.
+type ChannelInfo struct {
+	Name        string
+	Subscribers int
+}
@@ -1,6 +1,28 @@
 package wspubsub
 
-import "time"
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RateLimitPolicy enumerates possible reactions on exceeding a rate limit.
+type RateLimitPolicy uint32
+
+const (
+	// RateLimitPolicyDrop silently drops messages exceeding the limit.
+	RateLimitPolicyDrop RateLimitPolicy = iota
+
+	// RateLimitPolicyDelay suspends reading from a connection until the limit allows
+	// to receive a message, so a sender is slowed down by the TCP backpressure.
+	RateLimitPolicyDelay
+
+	// RateLimitPolicyWarn drops messages exceeding the limit and sends a warning message to the client.
+	RateLimitPolicyWarn
+
+	// RateLimitPolicyDisconnect closes a connection with the policy violation close code.
+	RateLimitPolicyDisconnect
+)
 
 // ClientOptions represents configuration of the client.
 type ClientOptions struct {
@@ -12,21 +34,56 @@ type ClientOptions struct {
 	// Exceeding this size will cause an error.
 	SendBufferSize int
 
-	// Enable/disable debug mode.
-	IsDebug bool
+	// How long a message can wait in the send buffer before it's dropped as outdated
+	// (zero means forever). Messages having own expiry (see Message.WithExpiry) ignore it.
+	MessageTTL time.Duration
+
+	// Limits of messages received from a WebSocket connection.
+	// They are enforced before a receive handler is called.
+	ReceiveRateLimit struct {
+		// Messages per second (zero means unlimited)
+		MessagesPerSecond float64
+
+		// Max number of messages received at once
+		MessagesBurst int
+
+		// Bytes per second (zero means unlimited)
+		BytesPerSecond float64
 
-	// Function execution time limit in debug mode.
-	// Exceeding this time limit will cause a new warn log message.
-	DebugFuncTimeLimit time.Duration
+		// Max number of bytes received at once
+		BytesBurst int
+
+		// Reaction on exceeding the limits
+		Policy RateLimitPolicy
+
+		// Message sent to the client when RateLimitPolicyWarn is used.
+		// It's sent once until the limits allow to receive a message again.
+		WarningMessage Message
+	}
+
+	// Collects metrics of sent, received and dropped messages (nil disables collecting).
+	Metrics MetricsCollector
+
+	// Provides a tracer of sending and receiving messages (nil means the global provider).
+	TracerProvider trace.TracerProvider
+
+	// Extracts a trace context carried inside received messages (nil disables propagation).
+	MessagePropagator MessagePropagator
+
+	// Observes operations (nil disables observing).
+	Observer Observer
 }
 
 // NewClientOptions initializes a new ClientOptions.
 // nolint: gomnd
 func NewClientOptions() ClientOptions {
-	return ClientOptions{
-		PingInterval:       10 * time.Second,
-		SendBufferSize:     1000,
-		IsDebug:            false,
-		DebugFuncTimeLimit: 1 * time.Millisecond,
+	options := ClientOptions{
+		PingInterval:   10 * time.Second,
+		SendBufferSize: 1000,
 	}
+
+	options.ReceiveRateLimit.Policy = RateLimitPolicyDrop
+	options.ReceiveRateLimit.WarningMessage = NewTextMessageFromString("rate limit exceeded")
+
+	return options
 }
@@ -11,6 +11,10 @@ func TestNewClientOptions(t *testing.T) {
 	options := wspubsub.NewClientOptions()
 	require.NotZero(t, options.PingInterval)
 	require.NotZero(t, options.SendBufferSize)
-	require.False(t, options.IsDebug)
-	require.NotZero(t, options.DebugFuncTimeLimit)
+	require.Zero(t, options.MessageTTL)
+	require.Zero(t, options.ReceiveRateLimit.MessagesPerSecond)
+	require.Zero(t, options.ReceiveRateLimit.BytesPerSecond)
+	require.Equal(t, wspubsub.RateLimitPolicyDrop, options.ReceiveRateLimit.Policy)
+	require.NotEmpty(t, options.ReceiveRateLimit.WarningMessage.Payload)
+	require.Nil(t, options.Observer)
 }
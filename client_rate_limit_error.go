@@ -0,0 +1,30 @@
+package wspubsub
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ClientRateLimitError returned when a client exceeds its inbound rate limit.
+type ClientRateLimitError struct {
+	ID      UUID
+	Message Message
+}
+
+// ClientRateLimitError implements an error interface.
+func (e *ClientRateLimitError) Error() string {
+	return fmt.Sprintf("wspubsub: client exceeded receive rate limit: id=%s", e.ID)
+}
+
+// NewClientRateLimitError initializes a new ClientRateLimitError.
+func NewClientRateLimitError(id UUID, message Message) *ClientRateLimitError {
+	return &ClientRateLimitError{ID: id, Message: message}
+}
+
+// IsClientRateLimitError checks if error type is ClientRateLimitError.
+func IsClientRateLimitError(err error) (*ClientRateLimitError, bool) {
+	v, ok := errors.Cause(err).(*ClientRateLimitError)
+
+	return v, ok
+}
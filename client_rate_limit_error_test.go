@@ -0,0 +1,26 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRateLimitError(t *testing.T) {
+	message := wspubsub.NewTextMessageFromString("TEST")
+	rawErr := errors.New("TEST")
+	err := wspubsub.NewClientRateLimitError(clientID, message)
+	require.Equal(t, clientID, err.ID)
+	require.Equal(t, message, err.Message)
+	require.NotEmpty(t, clientID, err.Error())
+
+	e, ok := wspubsub.IsClientRateLimitError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsClientRateLimitError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -0,0 +1,50 @@
+package wspubsub
+
+import (
+	"time"
+)
+
+// ClientStats represents queue and traffic statistics of a client.
+type ClientStats struct {
+	ID UUID
+
+	// Number and total size of messages waiting to be written to the connection
+	QueueDepth int
+	QueueBytes int64
+
+	// Messages written to the connection (pings and close messages aren't counted)
+	MessagesSent uint64
+	BytesSent    uint64
+
+	// Messages read from the connection
+	MessagesReceived uint64
+	BytesReceived    uint64
+
+	// Messages dropped because of a buffer overflow, the rate limit, conflation or expiry
+	MessagesDropped uint64
+	BytesDropped    uint64
+
+	// Number of failed writes to the connection (including pings)
+	WriteErrors uint64
+
+	ConnectedAt time.Time
+	LastReadAt  time.Time
+	LastWriteAt time.Time
+
+	// Round-trip time of the last answered ping
+	RTT time.Duration
+}
+
+// HubStats represents traffic statistics aggregated over all clients of the hub since it was created.
+type HubStats struct {
+	// Number of connected clients
+	Clients int
+
+	MessagesSent     uint64
+	BytesSent        uint64
+	MessagesReceived uint64
+	BytesReceived    uint64
+	MessagesDropped  uint64
+	BytesDropped     uint64
+	WriteErrors      uint64
+}
@@ -2,7 +2,7 @@ package wspubsub
 
 import (
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/pkg/errors"
@@ -14,6 +14,7 @@ type IterateFunc func(client WebsocketClient) error
 
 type clientsBuffer struct {
 	clients []WebsocketClient
+	seen    map[UUID]struct{}
 }
 
 // ClientStore represents the storage of clients.
@@ -23,19 +24,14 @@ type ClientStore struct {
 	clientsShardList  []*clientStoreClientsShard
 	channelsShardList []*clientStoreChannelsShard
 	clientsPool       sync.Pool
+	createdHandler    atomic.Value
+	emptiedHandler    atomic.Value
 }
 
 // Get returns client by its ID.
-func (s *ClientStore) Get(clientID UUID) (WebsocketClient, error) {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.get: took=%s", end)
-			}
-		}()
-	}
+func (s *ClientStore) Get(clientID UUID) (_ WebsocketClient, err error) {
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.get", ClientID: clientID})
+	defer op.end(&err)
 
 	clientsShard := s.clientsShard(clientID)
 
@@ -49,31 +45,17 @@ func (s *ClientStore) Get(clientID UUID) (WebsocketClient, error) {
 
 // Set puts client to storage.
 func (s *ClientStore) Set(client WebsocketClient) {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.set: took=%s", end)
-			}
-		}()
-	}
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.set", ClientID: client.ID()})
+	defer op.end(nil)
 
 	clientsShard := s.clientsShard(client.ID())
 	clientsShard.Set(client)
 }
 
 // Unset removes client from storage by its ID
-func (s *ClientStore) Unset(clientID UUID) error {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.unset: took=%s", end)
-			}
-		}()
-	}
+func (s *ClientStore) Unset(clientID UUID) (err error) {
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.unset", ClientID: clientID})
+	defer op.end(&err)
 
 	clientsShard := s.clientsShard(clientID)
 	clientsShard.Unset(clientID)
@@ -86,16 +68,10 @@ func (s *ClientStore) Unset(clientID UUID) error {
 }
 
 // Count returns the total number of clients in specified channel(-s).
+// A client subscribed on several of the channels is counted once (see ClientStoreOptions.DuplicateDelivery).
 func (s *ClientStore) Count(channels ...string) int {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.count: took=%s", end)
-			}
-		}()
-	}
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.count", Channels: channels})
+	defer op.end(nil)
 
 	count := 0
 	if len(channels) == 0 {
@@ -106,26 +82,51 @@ func (s *ClientStore) Count(channels ...string) int {
 		return count
 	}
 
+	if len(channels) == 1 || s.options.DuplicateDelivery {
+		for _, channel := range channels {
+			channelsShard := s.channelsShard(channel)
+			count += channelsShard.Count(channel)
+		}
+
+		return count
+	}
+
+	// A client subscribed on several of the channels is counted once like in Find
+	buff := s.clientsPool.Get().(*clientsBuffer)
 	for _, channel := range channels {
 		channelsShard := s.channelsShard(channel)
-		count += channelsShard.Count(channel)
+		channelsShard.Iterate(channel, func(client WebsocketClient) {
+			buff.seen[client.ID()] = struct{}{}
+		})
 	}
 
+	count = len(buff.seen)
+	clear(buff.seen)
+	s.clientsPool.Put(buff)
+
 	return count
 }
 
-// Find iterates over clients who subscribed on specified channel(-s).
-func (s *ClientStore) Find(fn IterateFunc, channels ...string) error {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.find: took=%s", end)
-			}
-		}()
+// CountByChannel returns the number of clients in each channel.
+// Channels are deleted once their last client is unlinked, so every returned channel has clients.
+func (s *ClientStore) CountByChannel() map[string]int {
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.count_by_channel"})
+	defer op.end(nil)
+
+	counts := make(map[string]int)
+	for _, channelsShard := range s.channelsShardList {
+		channelsShard.CountByChannel(counts)
 	}
 
+	return counts
+}
+
+// Find iterates over clients who subscribed on specified channel(-s).
+// A client subscribed on several of the channels is visited once (see ClientStoreOptions.DuplicateDelivery).
+func (s *ClientStore) Find(fn IterateFunc, channels ...string) (err error) {
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.find", Channels: channels})
+	defer op.end(&err)
+
 	buff := s.clientsPool.Get().(*clientsBuffer)
 
 	if len(channels) == 0 {
@@ -134,15 +135,32 @@ func (s *ClientStore) Find(fn IterateFunc, channels ...string) error {
 				buff.clients = append(buff.clients, client)
 			})
 		}
+	} else if len(channels) == 1 || s.options.DuplicateDelivery {
+		for _, channel := range channels {
+			channelsShard := s.channelsShard(channel)
+			channelsShard.Iterate(channel, func(client WebsocketClient) {
+				buff.clients = append(buff.clients, client)
+			})
+		}
 	} else {
+		// A client subscribed on several of the channels is visited once
 		for _, channel := range channels {
 			channelsShard := s.channelsShard(channel)
 			channelsShard.Iterate(channel, func(client WebsocketClient) {
+				if _, ok := buff.seen[client.ID()]; ok {
+					return
+				}
+
+				buff.seen[client.ID()] = struct{}{}
 				buff.clients = append(buff.clients, client)
 			})
 		}
+
+		clear(buff.seen)
 	}
 
+	op.setCount(len(buff.clients))
+
 	for _, client := range buff.clients {
 		err := fn(client)
 		if err != nil {
@@ -157,16 +175,9 @@ func (s *ClientStore) Find(fn IterateFunc, channels ...string) error {
 }
 
 // CountChannels return a list of channels linked with the client.
-func (s *ClientStore) Channels(clientID UUID) ([]string, error) {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.channels: took=%s", end)
-			}
-		}()
-	}
+func (s *ClientStore) Channels(clientID UUID) (_ []string, err error) {
+	op := startOperation(s.options.Observer, OperationEvent{Name: "wspubsub.client_store.channels", ClientID: clientID})
+	defer op.end(&err)
 
 	clientsShard := s.clientsShard(clientID)
 
@@ -179,16 +190,12 @@ func (s *ClientStore) Channels(clientID UUID) ([]string, error) {
 }
 
 // CountChannels return the total number of channels linked with the client.
-func (s *ClientStore) CountChannels(clientID UUID) (int, error) {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.count_channels: took=%s", end)
-			}
-		}()
-	}
+func (s *ClientStore) CountChannels(clientID UUID) (_ int, err error) {
+	op := startOperation(
+		s.options.Observer,
+		OperationEvent{Name: "wspubsub.client_store.count_channels", ClientID: clientID},
+	)
+	defer op.end(&err)
 
 	clientsShard := s.clientsShard(clientID)
 
@@ -201,16 +208,12 @@ func (s *ClientStore) CountChannels(clientID UUID) (int, error) {
 }
 
 // SetChannels links the client with specified channel(-s).
-func (s *ClientStore) SetChannels(clientID UUID, channels ...string) error {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.set_channels: took=%s", end)
-			}
-		}()
-	}
+func (s *ClientStore) SetChannels(clientID UUID, channels ...string) (err error) {
+	op := startOperation(
+		s.options.Observer,
+		OperationEvent{Name: "wspubsub.client_store.set_channels", ClientID: clientID, Channels: channels},
+	)
+	defer op.end(&err)
 
 	if len(channels) == 0 {
 		return nil
@@ -234,16 +237,12 @@ func (s *ClientStore) SetChannels(clientID UUID, channels ...string) error {
 // SetChannels unlinks the client from specified channel(-s).
 // If channels were not specified then the client will be
 // unlinked from all channels.
-func (s *ClientStore) UnsetChannels(clientID UUID, channels ...string) error {
-	if s.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > s.options.DebugFuncTimeLimit {
-				s.logger.Warnf("wspubsub.client_store.unset_channels: took=%s", end)
-			}
-		}()
-	}
+func (s *ClientStore) UnsetChannels(clientID UUID, channels ...string) (err error) {
+	op := startOperation(
+		s.options.Observer,
+		OperationEvent{Name: "wspubsub.client_store.unset_channels", ClientID: clientID, Channels: channels},
+	)
+	defer op.end(&err)
 
 	clientsShard := s.clientsShard(clientID)
 
@@ -268,6 +267,34 @@ func (s *ClientStore) UnsetChannels(clientID UUID, channels ...string) error {
 	return nil
 }
 
+// OnChannelCreated registers a handler called when a channel gets its first client.
+// Handlers of a channel are called synchronously in the order of its changes,
+// so they must not block and must not link or unlink clients.
+func (s *ClientStore) OnChannelCreated(handler ChannelHandler) {
+	s.createdHandler.Store(handler)
+}
+
+// OnChannelEmptied registers a handler called when the last client is unlinked from a channel.
+// Handlers of a channel are called synchronously in the order of its changes,
+// so they must not block and must not link or unlink clients.
+func (s *ClientStore) OnChannelEmptied(handler ChannelHandler) {
+	s.emptiedHandler.Store(handler)
+}
+
+func (s *ClientStore) channelCreated(channel string) {
+	s.logger.Debug("Channel created", LogFieldChannel, channel)
+
+	handler := s.createdHandler.Load().(ChannelHandler)
+	handler(channel)
+}
+
+func (s *ClientStore) channelEmptied(channel string) {
+	s.logger.Debug("Channel emptied", LogFieldChannel, channel)
+
+	handler := s.emptiedHandler.Load().(ChannelHandler)
+	handler(channel)
+}
+
 func (s *ClientStore) clientsShard(clientID UUID) *clientStoreClientsShard {
 	index := xxhash.Sum64(clientID.Bytes()) % uint64(s.options.ClientShards.Count)
 
@@ -281,15 +308,19 @@ func (s *ClientStore) channelsShard(channel string) *clientStoreChannelsShard {
 }
 
 // NewClientStore initializes a new ClientStore.
+// Lifecycle of channels is logged to the logger (nil logger discards messages).
 func NewClientStore(options ClientStoreOptions, logger Logger) *ClientStore {
 	clientList := &ClientStore{
 		options:           options,
-		logger:            logger,
+		logger:            loggerOrNop(logger),
 		clientsShardList:  make([]*clientStoreClientsShard, options.ClientShards.Count),
 		channelsShardList: make([]*clientStoreChannelsShard, options.ChannelShards.Count),
 		clientsPool: sync.Pool{
 			New: func() interface{} {
-				return &clientsBuffer{clients: make([]WebsocketClient, 0, options.ClientShards.Size)}
+				return &clientsBuffer{
+					clients: make([]WebsocketClient, 0, options.ClientShards.Size),
+					seen:    make(map[UUID]struct{}),
+				}
 			},
 		},
 	}
@@ -305,8 +336,13 @@ func NewClientStore(options ClientStoreOptions, logger Logger) *ClientStore {
 		clientList.channelsShardList[i] = newClientStoreChannelsShard(
 			options.ChannelShards.Size,
 			options.ChannelShards.BucketSize,
+			clientList.channelCreated,
+			clientList.channelEmptied,
 		)
 	}
 
+	clientList.createdHandler.Store(defaultChannelHandler)
+	clientList.emptiedHandler.Store(defaultChannelHandler)
+
 	return clientList
 }
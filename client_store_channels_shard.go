@@ -10,33 +10,66 @@ type clientStoreChannelsShard struct {
 	bucketSize int
 	mu         sync.RWMutex
 	clients    map[string]clientStoreChannelsShardBucket
+
+	// eventsMu is acquired before releasing mu, so lifecycle events
+	// of a channel are delivered in the order of its changes
+	eventsMu       sync.Mutex
+	channelCreated func(channel string)
+	channelEmptied func(channel string)
 }
 
 func (s *clientStoreChannelsShard) Link(client WebsocketClient, channel string) {
 	s.mu.Lock()
-	if _, ok := s.clients[channel]; !ok {
-		s.clients[channel] = make(clientStoreChannelsShardBucket, s.bucketSize)
+	bucket, ok := s.clients[channel]
+	if !ok {
+		bucket = make(clientStoreChannelsShardBucket, s.bucketSize)
+		s.clients[channel] = bucket
+	}
+	bucket[client.ID()] = client
+
+	if ok {
+		s.mu.Unlock()
+
+		return
 	}
-	s.clients[channel][client.ID()] = client
+
+	s.eventsMu.Lock()
 	s.mu.Unlock()
+	s.channelCreated(channel)
+	s.eventsMu.Unlock()
 }
 
+// Unlink removes the client from the channels and deletes channels left without clients.
 func (s *clientStoreChannelsShard) Unlink(clientID UUID, channels ...string) {
+	var emptied []string
+
+	s.mu.Lock()
 	if len(channels) == 0 {
-		s.mu.Lock()
 		for channel := range s.clients {
-			delete(s.clients[channel], clientID)
+			if s.unlink(clientID, channel) {
+				emptied = append(emptied, channel)
+			}
+		}
+	} else {
+		for _, channel := range channels {
+			if s.unlink(clientID, channel) {
+				emptied = append(emptied, channel)
+			}
 		}
+	}
+
+	if len(emptied) == 0 {
 		s.mu.Unlock()
 
 		return
 	}
 
-	s.mu.Lock()
-	for _, channel := range channels {
-		delete(s.clients[channel], clientID)
-	}
+	s.eventsMu.Lock()
 	s.mu.Unlock()
+	for _, channel := range emptied {
+		s.channelEmptied(channel)
+	}
+	s.eventsMu.Unlock()
 }
 
 func (s *clientStoreChannelsShard) Count(channels ...string) int {
@@ -61,6 +94,14 @@ func (s *clientStoreChannelsShard) Count(channels ...string) int {
 	return count
 }
 
+func (s *clientStoreChannelsShard) CountByChannel(counts map[string]int) {
+	s.mu.RLock()
+	for channel, clients := range s.clients {
+		counts[channel] = len(clients)
+	}
+	s.mu.RUnlock()
+}
+
 func (s *clientStoreChannelsShard) Iterate(channel string, iterateFunc func(client WebsocketClient)) {
 	s.mu.RLock()
 	if clients, ok := s.clients[channel]; ok {
@@ -71,10 +112,39 @@ func (s *clientStoreChannelsShard) Iterate(channel string, iterateFunc func(clie
 	s.mu.RUnlock()
 }
 
-func newClientStoreChannelsShard(size int, bucketSize int) *clientStoreChannelsShard {
+// unlink reports whether the channel became empty and was deleted.
+// The caller must hold mu.
+func (s *clientStoreChannelsShard) unlink(clientID UUID, channel string) bool {
+	bucket, ok := s.clients[channel]
+	if !ok {
+		return false
+	}
+
+	if _, ok := bucket[clientID]; !ok {
+		return false
+	}
+
+	delete(bucket, clientID)
+	if len(bucket) > 0 {
+		return false
+	}
+
+	delete(s.clients, channel)
+
+	return true
+}
+
+func newClientStoreChannelsShard(
+	size int,
+	bucketSize int,
+	channelCreated func(channel string),
+	channelEmptied func(channel string),
+) *clientStoreChannelsShard {
 	shard := &clientStoreChannelsShard{
-		bucketSize: bucketSize,
-		clients:    make(map[string]clientStoreChannelsShardBucket, size),
+		bucketSize:     bucketSize,
+		clients:        make(map[string]clientStoreChannelsShardBucket, size),
+		channelCreated: channelCreated,
+		channelEmptied: channelEmptied,
 	}
 
 	return shard
@@ -1,7 +1,5 @@
 package wspubsub
 
-import "time"
-
 // ClientStoreOptions represents configuration of the storage.
 type ClientStoreOptions struct {
 	ClientShards struct {
@@ -21,33 +19,31 @@ type ClientStoreOptions struct {
 		// Size of shard
 		Size int
 
-		// Size of a bucket in shard
+		// Initial capacity of a channel bucket (buckets grow on demand
+		// and are dropped once their channel has no clients left)
 		BucketSize int
 	}
 
-	// Enable/disable debug mode.
-	IsDebug bool
+	// Makes Find visit a client once per each of the channels it's subscribed on
+	// instead of once in total (a client gets a message published to several channels multiple times).
+	DuplicateDelivery bool
 
-	// Function execution time limit in debug mode.
-	// Exceeding this time limit will cause a new warn log message.
-	DebugFuncTimeLimit time.Duration
+	// Observes operations (nil disables observing).
+	Observer Observer
 }
 
 // NewClientStoreOptions initializes a new ClientStoreOptions.
 // nolint: gomnd
 func NewClientStoreOptions() ClientStoreOptions {
-	options := ClientStoreOptions{
-		IsDebug:            false,
-		DebugFuncTimeLimit: 1 * time.Millisecond,
-	}
+	options := ClientStoreOptions{}
 
 	options.ClientShards.Count = 128
 	options.ClientShards.Size = 10000
-	options.ChannelShards.BucketSize = 100
+	options.ClientShards.BucketSize = 100
 
 	options.ChannelShards.Count = 16
 	options.ChannelShards.Size = 100
-	options.ChannelShards.BucketSize = 10000
+	options.ChannelShards.BucketSize = 8
 
 	return options
 }
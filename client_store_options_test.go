@@ -11,9 +11,10 @@ func TestNewClientStoreOptions(t *testing.T) {
 	options := wspubsub.NewClientStoreOptions()
 	require.NotZero(t, options.ClientShards.Count)
 	require.NotZero(t, options.ClientShards.Size)
+	require.Equal(t, 100, options.ClientShards.BucketSize)
 	require.NotZero(t, options.ChannelShards.Count)
 	require.NotZero(t, options.ChannelShards.Size)
-	require.NotZero(t, options.ChannelShards.BucketSize)
-	require.False(t, options.IsDebug)
-	require.NotZero(t, options.DebugFuncTimeLimit)
+	require.Equal(t, 8, options.ChannelShards.BucketSize)
+	require.False(t, options.DuplicateDelivery)
+	require.Nil(t, options.Observer)
 }
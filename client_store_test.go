@@ -3,12 +3,14 @@ package wspubsub_test
 import (
 	"math/rand"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kpeu3i/wspubsub"
 	"github.com/kpeu3i/wspubsub/mock"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -21,7 +23,6 @@ func TestClientStore_Clients(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	logger := mock.NewMockLogger(ctrl)
 	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
 
 	clientsNum := 10
@@ -30,13 +31,13 @@ func TestClientStore_Clients(t *testing.T) {
 	for i := 0; i < clientsNum; i++ {
 		cid := wspubsub.UUID([16]byte{byte(i), 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
 		clientOptions := wspubsub.NewClientOptions()
-		client := wspubsub.NewClient(clientOptions, cid, upgrader, logger)
+		client := wspubsub.NewClient(clientOptions, cid, upgrader, nil)
 		clients[cid] = client
 		clientList = append(clientList, client)
 	}
 
 	clientStoreOptions := wspubsub.NewClientStoreOptions()
-	clientStore := wspubsub.NewClientStore(clientStoreOptions, logger)
+	clientStore := wspubsub.NewClientStore(clientStoreOptions, nil)
 
 	t.Run("Check default state of a storage", func(t *testing.T) {
 		client, err := clientStore.Get(clientID)
@@ -87,21 +88,19 @@ func TestClientStore_Find(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	logger := mock.NewMockLogger(ctrl)
 	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
 
 	clientStoreOptions := wspubsub.NewClientStoreOptions()
-	clientStore := wspubsub.NewClientStore(clientStoreOptions, logger)
+	clientStore := wspubsub.NewClientStore(clientStoreOptions, nil)
 
 	numClients := 100
-	numClientsInChannels := 0
 	availableChannels := []string{"X", "Y", "Z"}
 	clients := map[string]map[wspubsub.UUID]*wspubsub.Client{}
 
 	for i := 0; i < numClients; i++ {
 		cid := wspubsub.UUID([16]byte{byte(i), 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
 		clientOptions := wspubsub.NewClientOptions()
-		client := wspubsub.NewClient(clientOptions, cid, upgrader, logger)
+		client := wspubsub.NewClient(clientOptions, cid, upgrader, nil)
 		clientStore.Set(client)
 
 		numChannels := rand.Intn(len(availableChannels)-1) + 1
@@ -118,10 +117,6 @@ func TestClientStore_Find(t *testing.T) {
 				clients[channel] = make(map[wspubsub.UUID]*wspubsub.Client)
 			}
 
-			if _, ok := clients[channel][client.ID()]; !ok {
-				numClientsInChannels++
-			}
-
 			clients[channel][client.ID()] = client
 		}
 	}
@@ -139,11 +134,20 @@ func TestClientStore_Find(t *testing.T) {
 	t.Run("Count clients in a few random channels", func(t *testing.T) {
 		channel1 := availableChannels[rand.Intn(len(availableChannels))]
 		channel2 := availableChannels[rand.Intn(len(availableChannels))]
-		require.Equal(t, len(clients[channel1])+len(clients[channel2]), clientStore.Count(channel1, channel2))
+
+		union := map[wspubsub.UUID]struct{}{}
+		for _, channel := range []string{channel1, channel2} {
+			for id := range clients[channel] {
+				union[id] = struct{}{}
+			}
+		}
+
+		require.Equal(t, len(union), clientStore.Count(channel1, channel2))
 	})
 
 	t.Run("Count clients in all channels", func(t *testing.T) {
-		require.Equal(t, numClientsInChannels, clientStore.Count(availableChannels...))
+		// Every client is subscribed on at least one of the channels
+		require.Equal(t, numClients, clientStore.Count(availableChannels...))
 	})
 
 	t.Run("Count clients without channels", func(t *testing.T) {
@@ -189,6 +193,14 @@ func TestClientStore_Find(t *testing.T) {
 		channel1 := availableChannels[rand.Intn(len(availableChannels))]
 		channel2 := availableChannels[rand.Intn(len(availableChannels))]
 
+		foundClients := map[wspubsub.UUID]struct{}{}
+		expectedClients := map[wspubsub.UUID]struct{}{}
+		for _, channel := range []string{channel1, channel2} {
+			for id := range clients[channel] {
+				expectedClients[id] = struct{}{}
+			}
+		}
+
 		fn := func(client wspubsub.WebsocketClient) error {
 			numFoundClients++
 			_, ok1 := clients[channel1][client.ID()]
@@ -196,13 +208,16 @@ func TestClientStore_Find(t *testing.T) {
 
 			require.True(t, ok1 || ok2)
 
+			foundClients[client.ID()] = struct{}{}
+
 			return nil
 		}
 
 		err := clientStore.Find(fn, channel1, channel2)
 		require.NoError(t, err)
 
-		require.Equal(t, len(clients[channel1])+len(clients[channel2]), numFoundClients)
+		require.Equal(t, len(expectedClients), numFoundClients)
+		require.Equal(t, expectedClients, foundClients)
 	})
 
 	t.Run("Find clients in all channels", func(t *testing.T) {
@@ -225,7 +240,8 @@ func TestClientStore_Find(t *testing.T) {
 		err := clientStore.Find(fn, availableChannels...)
 		require.NoError(t, err)
 
-		require.Equal(t, numClientsInChannels, numFoundClients)
+		// Every client is subscribed on at least one channel
+		require.Equal(t, numClients, numFoundClients)
 	})
 
 	t.Run("Find clients without channels", func(t *testing.T) {
@@ -262,18 +278,92 @@ func TestClientStore_Find(t *testing.T) {
 	})
 }
 
+func TestClientStore_FindDuplicateDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	client1 := wspubsub.NewClient(wspubsub.NewClientOptions(), wspubsubtest.SequentialUUID(1), upgrader, nil)
+	client2 := wspubsub.NewClient(wspubsub.NewClientOptions(), wspubsubtest.SequentialUUID(2), upgrader, nil)
+
+	find := func(options wspubsub.ClientStoreOptions, channels ...string) []wspubsub.UUID {
+		clientStore := wspubsub.NewClientStore(options, nil)
+		clientStore.Set(client1)
+		clientStore.Set(client2)
+
+		err := clientStore.SetChannels(client1.ID(), "X", "Y")
+		require.NoError(t, err)
+
+		err = clientStore.SetChannels(client2.ID(), "Y")
+		require.NoError(t, err)
+
+		var ids []wspubsub.UUID
+		err = clientStore.Find(func(client wspubsub.WebsocketClient) error {
+			ids = append(ids, client.ID())
+
+			return nil
+		}, channels...)
+		require.NoError(t, err)
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i].String() < ids[j].String()
+		})
+
+		return ids
+	}
+
+	t.Run("Find clients once", func(t *testing.T) {
+		options := wspubsub.NewClientStoreOptions()
+		require.Equal(t, []wspubsub.UUID{client1.ID(), client2.ID()}, find(options, "X", "Y"))
+		require.Equal(t, []wspubsub.UUID{client1.ID(), client2.ID()}, find(options, "Y", "Y"))
+	})
+
+	t.Run("Find clients once per channel", func(t *testing.T) {
+		options := wspubsub.NewClientStoreOptions()
+		options.DuplicateDelivery = true
+		require.Equal(t, []wspubsub.UUID{client1.ID(), client1.ID(), client2.ID()}, find(options, "X", "Y"))
+	})
+
+	count := func(options wspubsub.ClientStoreOptions, channels ...string) int {
+		clientStore := wspubsub.NewClientStore(options, nil)
+		clientStore.Set(client1)
+		clientStore.Set(client2)
+
+		err := clientStore.SetChannels(client1.ID(), "X", "Y")
+		require.NoError(t, err)
+
+		err = clientStore.SetChannels(client2.ID(), "Y")
+		require.NoError(t, err)
+
+		return clientStore.Count(channels...)
+	}
+
+	t.Run("Count clients once", func(t *testing.T) {
+		options := wspubsub.NewClientStoreOptions()
+		require.Equal(t, 2, count(options, "X", "Y"))
+		require.Equal(t, 2, count(options, "Y", "Y"))
+		require.Equal(t, 1, count(options, "X", "UNKNOWN"))
+	})
+
+	t.Run("Count clients once per channel", func(t *testing.T) {
+		options := wspubsub.NewClientStoreOptions()
+		options.DuplicateDelivery = true
+		require.Equal(t, 3, count(options, "X", "Y"))
+	})
+}
+
 func TestClientStore_Channels(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	logger := mock.NewMockLogger(ctrl)
 	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
 
 	clientStoreOptions := wspubsub.NewClientStoreOptions()
-	clientStore := wspubsub.NewClientStore(clientStoreOptions, logger)
+	clientStore := wspubsub.NewClientStore(clientStoreOptions, nil)
 
 	clientOptions := wspubsub.NewClientOptions()
-	client := wspubsub.NewClient(clientOptions, clientID, upgrader, logger)
+	client := wspubsub.NewClient(clientOptions, clientID, upgrader, nil)
 	clientStore.Set(client)
 
 	unknownClientID := wspubsub.UUID{}
@@ -336,6 +426,7 @@ func TestClientStore_Channels(t *testing.T) {
 		numChannels, err := clientStore.CountChannels(clientID)
 		require.NoError(t, err)
 		require.Equal(t, len(channels), numChannels)
+		require.Equal(t, map[string]int{"X": 1, "Z": 1}, clientStore.CountByChannel())
 
 		err = clientStore.UnsetChannels(clientID)
 		require.NoError(t, err)
@@ -343,5 +434,147 @@ func TestClientStore_Channels(t *testing.T) {
 		sort.Strings(newChannels)
 		require.NoError(t, err)
 		require.Empty(t, newChannels)
+		require.Empty(t, clientStore.CountByChannel())
+	})
+}
+
+func TestClientStore_ChannelEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	clientStoreOptions := wspubsub.NewClientStoreOptions()
+	clientStore := wspubsub.NewClientStore(clientStoreOptions, nil)
+
+	var events []string
+	clientStore.OnChannelCreated(func(channel string) {
+		events = append(events, "created:"+channel)
+	})
+	clientStore.OnChannelEmptied(func(channel string) {
+		events = append(events, "emptied:"+channel)
+	})
+
+	otherClientID := wspubsub.UUID([16]byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+
+	clientOptions := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(clientOptions, clientID, upgrader, nil)
+	otherClient := wspubsub.NewClient(clientOptions, otherClientID, upgrader, nil)
+	clientStore.Set(client)
+	clientStore.Set(otherClient)
+
+	t.Run("Create channels", func(t *testing.T) {
+		err := clientStore.SetChannels(clientID, "X", "Y")
+		require.NoError(t, err)
+		err = clientStore.SetChannels(otherClientID, "X")
+		require.NoError(t, err)
+		err = clientStore.SetChannels(clientID, "X")
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"created:X", "created:Y"}, events)
+		require.Equal(t, map[string]int{"X": 2, "Y": 1}, clientStore.CountByChannel())
+	})
+
+	t.Run("Empty channels", func(t *testing.T) {
+		events = nil
+
+		err := clientStore.UnsetChannels(clientID, "X", "Z")
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		err = clientStore.Unset(clientID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"emptied:Y"}, events)
+
+		err = clientStore.UnsetChannels(otherClientID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"emptied:Y", "emptied:X"}, events)
+
+		require.Empty(t, clientStore.CountByChannel())
+		require.Equal(t, 0, clientStore.Count("X", "Y"))
 	})
+
+	t.Run("Recreate channel", func(t *testing.T) {
+		events = nil
+
+		err := clientStore.SetChannels(otherClientID, "X")
+		require.NoError(t, err)
+		err = clientStore.UnsetChannels(otherClientID, "X")
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"created:X", "emptied:X"}, events)
+	})
+}
+
+func TestClientStore_Logger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	logger := mock.NewMockLogger(ctrl)
+
+	logger.
+		EXPECT().
+		Debug(gomock.Eq("Channel created"), gomock.Eq(wspubsub.LogFieldChannel), gomock.Eq("X")).
+		Times(1)
+
+	logger.
+		EXPECT().
+		Debug(gomock.Eq("Channel emptied"), gomock.Eq(wspubsub.LogFieldChannel), gomock.Eq("X")).
+		Times(1)
+
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), logger)
+
+	client := wspubsub.NewClient(wspubsub.NewClientOptions(), clientID, upgrader, nil)
+	clientStore.Set(client)
+
+	err := clientStore.SetChannels(clientID, "X")
+	require.NoError(t, err)
+
+	err = clientStore.Unset(clientID)
+	require.NoError(t, err)
+}
+
+func TestClientStore_ChannelEventsOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	clientStoreOptions := wspubsub.NewClientStoreOptions()
+	clientStore := wspubsub.NewClientStore(clientStoreOptions, nil)
+
+	// Handlers are called sequentially for a channel,
+	// so the counter is not accessed concurrently
+	numSubscribed := 0
+	clientStore.OnChannelCreated(func(channel string) {
+		require.Equal(t, 0, numSubscribed)
+		numSubscribed++
+	})
+	clientStore.OnChannelEmptied(func(channel string) {
+		require.Equal(t, 1, numSubscribed)
+		numSubscribed--
+	})
+
+	clientsNum := 10
+	wg := sync.WaitGroup{}
+	for i := 0; i < clientsNum; i++ {
+		cid := wspubsub.UUID([16]byte{byte(i), 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+		clientStore.Set(wspubsub.NewClient(wspubsub.NewClientOptions(), cid, upgrader, nil))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				_ = clientStore.SetChannels(cid, "X")
+				_ = clientStore.UnsetChannels(cid, "X")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 0, numSubscribed)
+	require.Empty(t, clientStore.CountByChannel())
 }
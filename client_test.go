@@ -1,9 +1,11 @@
 package wspubsub_test
 
 import (
+	"context"
 	"fmt"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +14,9 @@ import (
 	"github.com/kpeu3i/wspubsub/mock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var clientID = wspubsub.UUID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
@@ -22,8 +27,7 @@ func TestClient_ID(t *testing.T) {
 
 	options := wspubsub.NewClientOptions()
 	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
-	logger := mock.NewMockLogger(ctrl)
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 
 	require.Equal(t, client.ID(), clientID)
 }
@@ -46,8 +50,6 @@ func TestClient_ReuseConnectAndClose(t *testing.T) {
 	request3 := httptest.NewRequest("GET", "/", nil)
 	response3 := httptest.NewRecorder()
 
-	logger := mock.NewMockLogger(ctrl)
-
 	connection := mock.NewMockWebsocketConnection(ctrl)
 
 	connection.
@@ -85,7 +87,7 @@ func TestClient_ReuseConnectAndClose(t *testing.T) {
 		Times(1)
 
 	options := wspubsub.NewClientOptions()
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 
 	for i := 1; i <= 2; i++ {
 		t.Run(fmt.Sprintf("Connection attempt #%d", i), func(t *testing.T) {
@@ -124,8 +126,6 @@ func TestClient_CloseError(t *testing.T) {
 	request := httptest.NewRequest("GET", "/", nil)
 	response := httptest.NewRecorder()
 
-	logger := mock.NewMockLogger(ctrl)
-
 	connection := mock.NewMockWebsocketConnection(ctrl)
 
 	connection.
@@ -151,7 +151,7 @@ func TestClient_CloseError(t *testing.T) {
 		Times(1)
 
 	options := wspubsub.NewClientOptions()
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 
 	err := client.Connect(response, request)
 	require.NoError(t, err)
@@ -159,6 +159,10 @@ func TestClient_CloseError(t *testing.T) {
 	err = client.Close()
 	require.Error(t, err)
 	require.True(t, strings.Contains(err.Error(), closeErrText))
+
+	err = client.Send(wspubsub.NewTextMessageFromString("TEST"))
+	_, ok := wspubsub.IsClientClosedError(err)
+	require.True(t, ok)
 }
 
 func TestClient_Write(t *testing.T) {
@@ -174,8 +178,6 @@ func TestClient_Write(t *testing.T) {
 	request := httptest.NewRequest("GET", "/", nil)
 	response := httptest.NewRecorder()
 
-	logger := mock.NewMockLogger(ctrl)
-
 	connection := mock.NewMockWebsocketConnection(ctrl)
 	connection.
 		EXPECT().
@@ -204,7 +206,7 @@ func TestClient_Write(t *testing.T) {
 		Times(1)
 
 	options := wspubsub.NewClientOptions()
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 	client.OnError(func(id wspubsub.UUID, err error) {
 		require.Equal(t, clientID, id)
 		require.Equal(t, wspubsub.NewClientSendError(clientID, message, closedErr), errors.Cause(err).(*wspubsub.ClientSendError))
@@ -230,12 +232,11 @@ func TestClient_WriteBufferOverflow(t *testing.T) {
 	closedErr := wspubsub.NewConnectionClosedError(errors.New("i/o timeout"))
 	message := wspubsub.NewTextMessageFromString("TEST")
 
-	logger := mock.NewMockLogger(ctrl)
 	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
 
 	options := wspubsub.NewClientOptions()
 	options.SendBufferSize = 1
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 	client.OnError(func(id wspubsub.UUID, err error) {
 		require.Equal(t, clientID, id)
 		require.Equal(t, wspubsub.NewClientSendError(clientID, message, closedErr), errors.Cause(err).(*wspubsub.ClientSendError))
@@ -248,6 +249,204 @@ func TestClient_WriteBufferOverflow(t *testing.T) {
 	require.Equal(t, wspubsub.NewClientSendBufferOverflowError(clientID), errors.Cause(err).(*wspubsub.ClientSendBufferOverflowError))
 }
 
+func TestClient_SendConflated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	blockingMessage := wspubsub.NewTextMessageFromString("BLOCK")
+	writing := make(chan struct{})
+	unblock := make(chan struct{})
+	written := make(chan wspubsub.Message, 10)
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Eq(blockingMessage)).
+		Times(1).
+		Do(func(message wspubsub.Message) {
+			close(writing)
+			<-unblock
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Any()).
+		Times(2).
+		Do(func(message wspubsub.Message) {
+			written <- message
+		})
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	metrics := mock.NewMockMetricsCollector(ctrl)
+	metrics.
+		EXPECT().
+		MessageSent(gomock.Any()).
+		Times(3)
+
+	metrics.
+		EXPECT().
+		MessageDropped(gomock.Eq(wspubsub.DropReasonConflated)).
+		Times(2)
+
+	options := wspubsub.NewClientOptions()
+	options.Metrics = metrics
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	// Keep the writer busy, so conflated messages wait in the buffer
+	err = client.Send(blockingMessage)
+	require.NoError(t, err)
+	<-writing
+
+	for _, payload := range []string{"X1", "X2", "X3"} {
+		err = client.SendConflated("X", wspubsub.NewTextMessageFromString(payload))
+		require.NoError(t, err)
+	}
+
+	err = client.Send(wspubsub.NewTextMessageFromString("Y"))
+	require.NoError(t, err)
+	require.Equal(t, 2, client.QueueDepth())
+
+	close(unblock)
+	require.Equal(t, wspubsub.NewTextMessageFromString("X3"), receiveMessage(t, written))
+	require.Equal(t, wspubsub.NewTextMessageFromString("Y"), receiveMessage(t, written))
+}
+
+func TestClient_SendConflatedBufferOverflow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	options := wspubsub.NewClientOptions()
+	options.SendBufferSize = 1
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	err := client.SendConflated("X", wspubsub.NewTextMessageFromString("X1"))
+	require.NoError(t, err)
+
+	err = client.SendConflated("X", wspubsub.NewTextMessageFromString("X2"))
+	require.NoError(t, err)
+
+	// A message which didn't fit the buffer doesn't block the following ones
+	for i := 0; i < 2; i++ {
+		err = client.SendConflated("Y", wspubsub.NewTextMessageFromString("Y"))
+		require.Equal(t, wspubsub.NewClientSendBufferOverflowError(clientID), errors.Cause(err).(*wspubsub.ClientSendBufferOverflowError))
+	}
+}
+
+func TestClient_SendExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	blockingMessage := wspubsub.NewTextMessageFromString("BLOCK")
+	writing := make(chan struct{})
+	unblock := make(chan struct{})
+	written := make(chan wspubsub.Message, 10)
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Any()).
+		Times(1).
+		Do(func(message wspubsub.Message) {
+			close(writing)
+			<-unblock
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Any()).
+		Times(1).
+		Do(func(message wspubsub.Message) {
+			written <- message
+		})
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	metrics := mock.NewMockMetricsCollector(ctrl)
+	metrics.
+		EXPECT().
+		MessageSent(gomock.Any()).
+		Times(2)
+
+	metrics.
+		EXPECT().
+		MessageDropped(gomock.Eq(wspubsub.DropReasonExpired)).
+		Times(3)
+
+	options := wspubsub.NewClientOptions()
+	options.MessageTTL = 50 * time.Millisecond
+	options.Metrics = metrics
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	// Keep the writer busy, so messages wait in the buffer
+	err = client.Send(blockingMessage)
+	require.NoError(t, err)
+	<-writing
+
+	err = client.Send(wspubsub.NewTextMessageFromString("DEFAULT_TTL"))
+	require.NoError(t, err)
+
+	err = client.SendConflated("X", wspubsub.NewTextMessageFromString("CONFLATED"))
+	require.NoError(t, err)
+
+	err = client.Send(wspubsub.NewTextMessageFromString("EXPIRED").WithExpiry(time.Now().Add(-time.Second)))
+	require.NoError(t, err)
+
+	fresh := wspubsub.NewTextMessageFromString("FRESH").WithTTL(time.Hour)
+	err = client.Send(fresh)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	close(unblock)
+	require.Equal(t, fresh, receiveMessage(t, written))
+}
+
 func TestClient_Ping(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -264,8 +463,6 @@ func TestClient_Ping(t *testing.T) {
 	options := wspubsub.NewClientOptions()
 	options.PingInterval = 1 * time.Millisecond
 
-	logger := mock.NewMockLogger(ctrl)
-
 	connection := mock.NewMockWebsocketConnection(ctrl)
 
 	connection.
@@ -296,7 +493,7 @@ func TestClient_Ping(t *testing.T) {
 		Return(connection, nil).
 		Times(1)
 
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 	client.OnError(func(id wspubsub.UUID, err error) {
 		require.Equal(t, clientID, id)
 		require.Equal(t, wspubsub.NewClientPingError(clientID, message, closedErr), errors.Cause(err).(*wspubsub.ClientPingError))
@@ -306,7 +503,7 @@ func TestClient_Ping(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestClient_Read(t *testing.T) {
+func TestClient_CloseFromErrorHandler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
 		time.Sleep(100 * time.Millisecond)
@@ -319,7 +516,115 @@ func TestClient_Read(t *testing.T) {
 	request := httptest.NewRequest("GET", "/", nil)
 	response := httptest.NewRecorder()
 
-	logger := mock.NewMockLogger(ctrl)
+	connection := mock.NewMockWebsocketConnection(ctrl)
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Eq(message)).
+		Times(1).
+		Return(closedErr)
+
+	connection.
+		EXPECT().
+		Close().
+		Times(1)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	closed := make(chan error, 1)
+	options := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+	client.OnError(func(id wspubsub.UUID, err error) {
+		// The hub disconnects a client this way on a write error
+		closed <- client.Close()
+	})
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	err = client.Send(message)
+	require.NoError(t, err)
+
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "closing the client from the error handler is blocked")
+	}
+}
+
+func TestClient_StopReadingAfterClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		DoAndReturn(func() (wspubsub.Message, error) {
+			time.Sleep(50 * time.Millisecond)
+
+			return wspubsub.NewTextMessageFromString("TEST"), nil
+		})
+
+	connection.
+		EXPECT().
+		Close().
+		Times(1)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+	client.OnReceive(func(id wspubsub.UUID, message wspubsub.Message) {
+		require.Fail(t, "a message of the closed client is received")
+	})
+	client.OnError(func(id wspubsub.UUID, err error) {
+		require.Fail(t, "an error of the closed client is reported")
+	})
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	err = client.Close()
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestClient_Read(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	message := wspubsub.NewTextMessageFromString("TEST")
+	closedErr := wspubsub.NewConnectionClosedError(errors.New("i/o timeout"))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
 
 	connection := mock.NewMockWebsocketConnection(ctrl)
 
@@ -344,7 +649,7 @@ func TestClient_Read(t *testing.T) {
 		Times(1)
 
 	options := wspubsub.NewClientOptions()
-	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
 
 	client.OnReceive(func(id wspubsub.UUID, message wspubsub.Message) {
 		require.Equal(t, clientID, id)
@@ -359,3 +664,689 @@ func TestClient_Read(t *testing.T) {
 	err := client.Connect(response, request)
 	require.NoError(t, err)
 }
+
+func TestClient_ReadRateLimit(t *testing.T) {
+	message := wspubsub.NewTextMessageFromString("TEST")
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	newClient := func(ctrl *gomock.Controller, options wspubsub.ClientOptions, connection *mock.MockWebsocketConnection) *wspubsub.Client {
+		connection.
+			EXPECT().
+			Read().
+			MaxTimes(3).
+			Return(message, nil)
+
+		connection.
+			EXPECT().
+			Read().
+			AnyTimes().
+			Do(func() {
+				time.Sleep(time.Hour)
+			})
+
+		upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+		upgrader.
+			EXPECT().
+			Upgrade(gomock.Eq(response), gomock.Eq(request)).
+			Return(connection, nil).
+			Times(1)
+
+		client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+		return client
+	}
+
+	options := wspubsub.NewClientOptions()
+	options.ReceiveRateLimit.MessagesPerSecond = 0.001
+	options.ReceiveRateLimit.MessagesBurst = 1
+
+	t.Run("Drop policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer func() {
+			time.Sleep(100 * time.Millisecond)
+			ctrl.Finish()
+		}()
+
+		received := make(chan wspubsub.Message, 3)
+		client := newClient(ctrl, options, mock.NewMockWebsocketConnection(ctrl))
+		client.OnReceive(func(id wspubsub.UUID, message wspubsub.Message) {
+			received <- message
+		})
+
+		err := client.Connect(response, request)
+		require.NoError(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, received, 1)
+	})
+
+	t.Run("Warn policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer func() {
+			time.Sleep(100 * time.Millisecond)
+			ctrl.Finish()
+		}()
+
+		options := options
+		options.ReceiveRateLimit.Policy = wspubsub.RateLimitPolicyWarn
+
+		// A flood is answered with a single warning
+		connection := mock.NewMockWebsocketConnection(ctrl)
+		connection.
+			EXPECT().
+			Read().
+			Times(100).
+			Return(message, nil)
+
+		connection.
+			EXPECT().
+			Write(gomock.Eq(options.ReceiveRateLimit.WarningMessage)).
+			Times(1)
+
+		received := make(chan wspubsub.Message, 3)
+		client := newClient(ctrl, options, connection)
+		client.OnReceive(func(id wspubsub.UUID, message wspubsub.Message) {
+			received <- message
+		})
+
+		err := client.Connect(response, request)
+		require.NoError(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, received, 1)
+	})
+
+	t.Run("Disconnect policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer func() {
+			time.Sleep(100 * time.Millisecond)
+			ctrl.Finish()
+		}()
+
+		options := options
+		options.ReceiveRateLimit.Policy = wspubsub.RateLimitPolicyDisconnect
+
+		connection := mock.NewMockWebsocketConnection(ctrl)
+		connection.
+			EXPECT().
+			Write(gomock.Eq(wspubsub.NewCloseMessage(wspubsub.CloseCodePolicyViolation, "rate limit exceeded"))).
+			Times(1)
+
+		connection.
+			EXPECT().
+			Close().
+			Times(1)
+
+		errs := make(chan error, 1)
+		client := newClient(ctrl, options, connection)
+		client.OnError(func(id wspubsub.UUID, err error) {
+			require.Equal(t, clientID, id)
+			errs <- err
+		})
+
+		err := client.Connect(response, request)
+		require.NoError(t, err)
+
+		select {
+		case err := <-errs:
+			require.Equal(t, wspubsub.NewClientRateLimitError(clientID, message), errors.Cause(err).(*wspubsub.ClientRateLimitError))
+		case <-time.After(time.Second):
+			t.Fatal("Rate limit error is not reported")
+		}
+
+		err = client.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("Delay policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer func() {
+			time.Sleep(100 * time.Millisecond)
+			ctrl.Finish()
+		}()
+
+		options := wspubsub.NewClientOptions()
+		options.ReceiveRateLimit.MessagesPerSecond = 50
+		options.ReceiveRateLimit.MessagesBurst = 1
+		options.ReceiveRateLimit.Policy = wspubsub.RateLimitPolicyDelay
+
+		received := make(chan wspubsub.Message, 3)
+		client := newClient(ctrl, options, mock.NewMockWebsocketConnection(ctrl))
+		client.OnReceive(func(id wspubsub.UUID, message wspubsub.Message) {
+			received <- message
+		})
+
+		now := time.Now()
+		err := client.Connect(response, request)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			<-received
+		}
+
+		require.True(t, time.Since(now) >= 35*time.Millisecond)
+	})
+}
+
+func TestClient_CloseWithCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	closeMessage := wspubsub.NewCloseMessage(wspubsub.CloseCodeCredentialsExpired, "TEST")
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		Do(func() {
+			time.Sleep(5 * time.Second)
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Eq(closeMessage)).
+		Times(1)
+
+	connection.
+		EXPECT().
+		Close().
+		Times(1)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	err = client.CloseWithCode(wspubsub.CloseCodeCredentialsExpired, "TEST")
+	require.NoError(t, err)
+}
+
+func TestClient_ReadTracing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	message := wspubsub.NewTextMessageFromString(`{"traceparent":"` + traceparent + `","command":"PUBLISH"}`)
+	received := make(chan context.Context, 1)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		Return(message, nil)
+
+	connection.
+		EXPECT().
+		Read().
+		AnyTimes().
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	options.TracerProvider = tracerProvider
+	options.MessagePropagator = wspubsub.NewJSONMessagePropagator(nil)
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+	client.OnReceiveContext(func(ctx context.Context, id wspubsub.UUID, message wspubsub.Message) {
+		received <- ctx
+	})
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	select {
+	case ctx := <-received:
+		spanContext := trace.SpanContextFromContext(ctx)
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.TraceID().String())
+	case <-time.After(time.Second):
+		require.Fail(t, "message was not received")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "wspubsub.client.receive", spans[0].Name)
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	require.True(t, spans[0].Parent.IsRemote())
+}
+
+func TestClient_QueueDepthAndRTT(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	connection := struct {
+		*mock.MockWebsocketConnection
+		*mock.MockWebsocketConnectionRTTMeter
+	}{
+		MockWebsocketConnection:         mock.NewMockWebsocketConnection(ctrl),
+		MockWebsocketConnectionRTTMeter: mock.NewMockWebsocketConnectionRTTMeter(ctrl),
+	}
+
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Read().
+		AnyTimes().
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Write(gomock.Any()).
+		AnyTimes().
+		Return(nil)
+
+	connection.MockWebsocketConnectionRTTMeter.
+		EXPECT().
+		RTT().
+		Times(1).
+		Return(5 * time.Millisecond)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	require.Zero(t, client.RTT())
+	require.Zero(t, client.QueueDepth())
+
+	err := client.Send(wspubsub.NewTextMessageFromString("X"))
+	require.NoError(t, err)
+	err = client.Send(wspubsub.NewTextMessageFromString("Y"))
+	require.NoError(t, err)
+	require.Equal(t, 2, client.QueueDepth())
+
+	err = client.Connect(response, request)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return client.QueueDepth() == 0
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 5*time.Millisecond, client.RTT())
+}
+
+func TestClient_Stats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	writeErr := errors.New("write_error")
+	received := make(chan wspubsub.Message, 1)
+	read := make(chan struct{})
+	failed := make(chan error, 1)
+
+	connection := struct {
+		*mock.MockWebsocketConnection
+		*mock.MockWebsocketConnectionRTTMeter
+	}{
+		MockWebsocketConnection:         mock.NewMockWebsocketConnection(ctrl),
+		MockWebsocketConnectionRTTMeter: mock.NewMockWebsocketConnectionRTTMeter(ctrl),
+	}
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Read().
+		Times(1).
+		Return(wspubsub.NewTextMessageFromString("HELLO"), nil)
+
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Read().
+		AnyTimes().
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	// The failed write stops reading, so writing waits until the message is received
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Write(gomock.Eq(wspubsub.NewTextMessageFromString("XX"))).
+		Times(1).
+		Do(func(message wspubsub.Message) {
+			<-read
+		})
+
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Write(gomock.Eq(wspubsub.NewTextMessageFromString("YYY"))).
+		Times(1).
+		Return(writeErr)
+
+	connection.MockWebsocketConnectionRTTMeter.
+		EXPECT().
+		RTT().
+		AnyTimes().
+		Return(5 * time.Millisecond)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	options.SendBufferSize = 2
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+	client.OnReceive(func(id wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+		close(read)
+	})
+	client.OnError(func(id wspubsub.UUID, err error) {
+		failed <- err
+	})
+
+	stats := client.Stats()
+	require.Equal(t, clientID, stats.ID)
+	require.True(t, stats.ConnectedAt.IsZero())
+
+	err := client.Send(wspubsub.NewTextMessageFromString("XX"))
+	require.NoError(t, err)
+	err = client.Send(wspubsub.NewTextMessageFromString("YYY"))
+	require.NoError(t, err)
+	err = client.Send(wspubsub.NewTextMessageFromString("ZZZZ"))
+	require.Error(t, err)
+
+	stats = client.Stats()
+	require.Equal(t, 2, stats.QueueDepth)
+	require.Equal(t, int64(5), stats.QueueBytes)
+	require.Equal(t, uint64(1), stats.MessagesDropped)
+	require.Equal(t, uint64(4), stats.BytesDropped)
+
+	err = client.Connect(response, request)
+	require.NoError(t, err)
+
+	receiveMessage(t, received)
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		require.Fail(t, "write error wasn't reported")
+	}
+
+	stats = client.Stats()
+	require.Zero(t, stats.QueueDepth)
+	require.Zero(t, stats.QueueBytes)
+	require.Equal(t, uint64(1), stats.MessagesSent)
+	require.Equal(t, uint64(2), stats.BytesSent)
+	require.Equal(t, uint64(1), stats.MessagesReceived)
+	require.Equal(t, uint64(5), stats.BytesReceived)
+	require.Equal(t, uint64(1), stats.WriteErrors)
+	require.False(t, stats.ConnectedAt.IsZero())
+	require.False(t, stats.LastReadAt.Before(stats.ConnectedAt))
+	require.False(t, stats.LastWriteAt.Before(stats.ConnectedAt))
+	require.Equal(t, 5*time.Millisecond, stats.RTT)
+}
+
+func TestClient_Bind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	bindErrText := "bind_error"
+
+	request1 := httptest.NewRequest("GET", "/", nil)
+	response1 := httptest.NewRecorder()
+
+	request2 := httptest.NewRequest("GET", "/", nil)
+	response2 := httptest.NewRecorder()
+
+	connection := struct {
+		*mock.MockWebsocketConnection
+		*mock.MockWebsocketConnectionBinder
+	}{
+		MockWebsocketConnection:       mock.NewMockWebsocketConnection(ctrl),
+		MockWebsocketConnectionBinder: mock.NewMockWebsocketConnectionBinder(ctrl),
+	}
+
+	connection.MockWebsocketConnectionBinder.
+		EXPECT().
+		Bind(gomock.Eq(clientID)).
+		Times(1).
+		Return(errors.New(bindErrText))
+
+	connection.MockWebsocketConnectionBinder.
+		EXPECT().
+		Bind(gomock.Eq(clientID)).
+		Times(1).
+		Return(nil)
+
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Read().
+		Times(1).
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	connection.MockWebsocketConnection.
+		EXPECT().
+		Close().
+		Times(2).
+		Return(nil)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response1), gomock.Eq(request1)).
+		Return(connection, nil).
+		Times(1)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response2), gomock.Eq(request2)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	err := client.Connect(response1, request1)
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), bindErrText))
+
+	err = client.Connect(response2, request2)
+	require.NoError(t, err)
+
+	err = client.Close()
+	require.NoError(t, err)
+}
+
+func TestClient_Logger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		time.Sleep(100 * time.Millisecond)
+		ctrl.Finish()
+	}()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "203.0.113.7:4321"
+	response := httptest.NewRecorder()
+
+	logger := mock.NewMockLogger(ctrl)
+
+	logger.
+		EXPECT().
+		With(gomock.Eq(wspubsub.LogFieldClientID), gomock.Eq(clientID)).
+		Times(1).
+		Return(logger)
+
+	logger.
+		EXPECT().
+		Debug(gomock.Eq("Client connected"), gomock.Eq(wspubsub.LogFieldRemoteAddr), gomock.Eq(request.RemoteAddr)).
+		Times(1)
+
+	logger.
+		EXPECT().
+		Debug(gomock.Eq("Client closed"), gomock.Eq(wspubsub.LogFieldRemoteAddr), gomock.Eq(request.RemoteAddr)).
+		Times(1)
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+
+	connection.
+		EXPECT().
+		Read().
+		Times(1).
+		Do(func() {
+			time.Sleep(5 * time.Second)
+		})
+
+	connection.
+		EXPECT().
+		Close().
+		Times(1).
+		Return(nil)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	client := wspubsub.NewClient(options, clientID, upgrader, logger)
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	err = client.Close()
+	require.NoError(t, err)
+}
+
+func TestClient_Observer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "203.0.113.7:4321"
+	response := httptest.NewRecorder()
+
+	var (
+		mu     sync.Mutex
+		events = map[string]wspubsub.OperationEvent{}
+	)
+
+	observer := mock.NewMockObserver(ctrl)
+
+	observer.
+		EXPECT().
+		OperationStarted(gomock.Any()).
+		AnyTimes()
+
+	observer.
+		EXPECT().
+		OperationFinished(gomock.Any()).
+		AnyTimes().
+		Do(func(event wspubsub.OperationEvent) {
+			mu.Lock()
+			events[event.Name] = event
+			mu.Unlock()
+		})
+
+	connection := mock.NewMockWebsocketConnection(ctrl)
+
+	connection.
+		EXPECT().
+		Read().
+		AnyTimes().
+		Do(func() {
+			time.Sleep(5 * time.Second)
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Any()).
+		AnyTimes().
+		Return(nil)
+
+	connection.
+		EXPECT().
+		Close().
+		Times(1).
+		Return(nil)
+
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	options := wspubsub.NewClientOptions()
+	options.Observer = observer
+	client := wspubsub.NewClient(options, clientID, upgrader, nil)
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	err = client.Send(wspubsub.NewTextMessageFromString("TEST"))
+	require.NoError(t, err)
+
+	err = client.Close()
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, name := range []string{"wspubsub.client.connect", "wspubsub.client.send", "wspubsub.client.close"} {
+		require.Contains(t, events, name)
+		require.Equal(t, clientID, events[name].ClientID)
+		require.Equal(t, request.RemoteAddr, events[name].RemoteAddr)
+	}
+}
@@ -0,0 +1,11 @@
+package wspubsub
+
+// Codec is an interface responsible for encoding values into messages
+// and decoding received messages back into values.
+type Codec interface {
+	// Encode creates a message of the codec type (text or binary) from the value
+	Encode(v interface{}) (Message, error)
+
+	// Decode decodes a message payload into the value pointed to by v
+	Decode(message Message, v interface{}) error
+}
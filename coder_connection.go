@@ -0,0 +1,178 @@
+package wspubsub
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ WebsocketConnection         = (*CoderConnection)(nil)
+	_ WebsocketConnectionRTTMeter = (*CoderConnection)(nil)
+)
+
+// CoderConnection is an implementation of WebsocketConnection.
+//
+// The connection lives until its context is cancelled: either on Close or
+// when nothing (neither a message nor a pong) is read within the read timeout.
+type CoderConnection struct {
+	conn        *websocket.Conn
+	ctx         context.Context
+	cancel      context.CancelFunc
+	readTimer   *time.Timer
+	metrics     MetricsCollector
+	readTimeout time.Duration
+	writeTimout time.Duration
+	observer    Observer
+	remoteAddr  string
+	isPinging   atomic.Bool
+	rtt         rttMeter
+}
+
+// Read reads a message from WebSocket connection.
+func (c *CoderConnection) Read() (Message, error) {
+	c.readTimer.Reset(c.readTimeout)
+
+	messageType, bytes, err := c.conn.Read(c.ctx)
+	if err != nil {
+		return Message{}, errors.WithStack(c.handleError(err))
+	}
+
+	message := Message{
+		Type:    MessageType(messageType),
+		Payload: bytes,
+	}
+
+	return message, nil
+}
+
+// Write writes a message to WebSocket connection.
+func (c *CoderConnection) Write(message Message) (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.coder_connection.write", RemoteAddr: c.remoteAddr, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	if c.metrics != nil {
+		now := time.Now()
+		defer func() {
+			c.metrics.ConnectionWritten("coder", time.Since(now))
+		}()
+	}
+
+	switch message.Type {
+	case MessageTypePing:
+		// The library waits for a pong before returning from Ping,
+		// so it's sent in background not to block the writer
+		if c.isPinging.CompareAndSwap(false, true) {
+			c.rtt.PingSent(time.Now())
+			go c.ping()
+		}
+
+		return nil
+	case MessageTypeClose:
+		code, reason := parseClosePayload(message.Payload)
+		err = c.conn.Close(websocket.StatusCode(code), reason)
+		if err != nil {
+			return errors.WithStack(c.handleError(err))
+		}
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.writeTimout)
+	defer cancel()
+
+	err = c.conn.Write(ctx, websocket.MessageType(message.Type), message.Payload)
+	if err != nil {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// Close closes a WebSocket connection.
+func (c *CoderConnection) Close() (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.coder_connection.close", RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
+	c.readTimer.Stop()
+	c.cancel()
+
+	err = c.conn.CloseNow()
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// RTT returns the round-trip time of the last answered ping.
+func (c *CoderConnection) RTT() time.Duration {
+	return c.rtt.RTT()
+}
+
+func (c *CoderConnection) ping() {
+	defer c.isPinging.Store(false)
+
+	// A missing pong is detected by the read timer, so the error is ignored
+	ctx, cancel := context.WithTimeout(c.ctx, c.readTimeout)
+	defer cancel()
+
+	_ = c.conn.Ping(ctx)
+}
+
+func (c *CoderConnection) handlePong(context.Context, []byte) {
+	c.rtt.PongReceived(time.Now())
+	c.readTimer.Reset(c.readTimeout)
+}
+
+func (c *CoderConnection) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if websocket.CloseStatus(err) != -1 {
+		closeErr := NewConnectionClosedError(err)
+
+		return errors.WithStack(closeErr)
+	}
+
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		closeErr := NewConnectionClosedError(err)
+
+		return errors.WithStack(closeErr)
+	}
+
+	// The library closes the connection once a context is done
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		closeErr := NewConnectionClosedError(err)
+
+		return errors.WithStack(closeErr)
+	}
+
+	if strings.Contains(err.Error(), "use of closed network connection") {
+		closeErr := NewConnectionClosedError(err)
+
+		return errors.WithStack(closeErr)
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		closeErr := NewConnectionClosedError(err)
+
+		return errors.WithStack(closeErr)
+	}
+
+	return errors.WithStack(err)
+}
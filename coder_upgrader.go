@@ -0,0 +1,75 @@
+package wspubsub
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/pkg/errors"
+)
+
+var _ WebsocketConnectionUpgrader = (*CoderConnectionUpgrader)(nil)
+
+// CoderConnectionUpgrader is an implementation of WebsocketConnectionUpgrader
+// built on top of github.com/coder/websocket.
+type CoderConnectionUpgrader struct {
+	options CoderConnectionUpgraderOptions
+}
+
+// Upgrade upgrades HTTP connection to the WebSocket connection.
+func (u *CoderConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.coder_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
+
+	if u.options.CheckOrigin != nil && !u.options.CheckOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+		return nil, errors.New("request origin not allowed")
+	}
+
+	compressionMode := websocket.CompressionDisabled
+	if u.options.EnableCompression {
+		compressionMode = websocket.CompressionContextTakeover
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	coderConnection := &CoderConnection{
+		ctx:         ctx,
+		cancel:      cancel,
+		metrics:     u.options.Metrics,
+		readTimeout: u.options.ReadTimout,
+		writeTimout: u.options.WriteTimout,
+		observer:    u.options.Observer,
+		remoteAddr:  r.RemoteAddr,
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:         u.options.Subprotocols,
+		InsecureSkipVerify:   u.options.CheckOrigin != nil,
+		OriginPatterns:       u.options.OriginPatterns,
+		CompressionMode:      compressionMode,
+		CompressionThreshold: u.options.CompressionThreshold,
+		OnPongReceived:       coderConnection.handlePong,
+	})
+	if err != nil {
+		cancel()
+
+		return nil, errors.WithStack(err)
+	}
+
+	conn.SetReadLimit(u.options.MaxMessageSize)
+
+	coderConnection.conn = conn
+	coderConnection.readTimer = time.AfterFunc(u.options.ReadTimout, cancel)
+
+	return coderConnection, nil
+}
+
+// NewCoderConnectionUpgrader initializes a new CoderConnectionUpgrader.
+func NewCoderConnectionUpgrader(options CoderConnectionUpgraderOptions) *CoderConnectionUpgrader {
+	return &CoderConnectionUpgrader{options: options}
+}
@@ -0,0 +1,38 @@
+package wspubsub
+
+import (
+	"net/http"
+	"time"
+)
+
+// CoderConnectionUpgraderOptions represents configuration of the CoderConnectionUpgrader.
+//
+// CheckOrigin takes precedence over OriginPatterns, set it to nil to make
+// the library verify the origin against OriginPatterns and the request host.
+type CoderConnectionUpgraderOptions struct {
+	MaxMessageSize       int64
+	ReadTimout           time.Duration
+	WriteTimout          time.Duration
+	Subprotocols         []string
+	CheckOrigin          func(r *http.Request) bool
+	OriginPatterns       []string
+	EnableCompression    bool
+	CompressionThreshold int
+	Metrics              MetricsCollector
+	Observer             Observer
+}
+
+// NewCoderConnectionUpgraderOptions initializes a new CoderConnectionUpgraderOptions.
+// nolint: gomnd
+func NewCoderConnectionUpgraderOptions() CoderConnectionUpgraderOptions {
+	options := CoderConnectionUpgraderOptions{
+		MaxMessageSize: 1 * 1024 * 1024,
+		ReadTimout:     60 * time.Second,
+		WriteTimout:    10 * time.Second,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	return options
+}
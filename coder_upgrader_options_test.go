@@ -0,0 +1,18 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCoderUpgraderOptions(t *testing.T) {
+	options := wspubsub.NewCoderConnectionUpgraderOptions()
+	require.NotZero(t, options.MaxMessageSize)
+	require.NotZero(t, options.ReadTimout)
+	require.NotZero(t, options.WriteTimout)
+	require.NotNil(t, options.CheckOrigin)
+	require.False(t, options.EnableCompression)
+	require.Nil(t, options.Observer)
+}
@@ -0,0 +1,219 @@
+package wspubsub_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoderConnectionUpgrader(t *testing.T) {
+	upgraderOptions := wspubsub.NewCoderConnectionUpgraderOptions()
+	upgraderOptions.EnableCompression = true
+	upgrader := wspubsub.NewCoderConnectionUpgrader(upgraderOptions)
+
+	clientOptions := wspubsub.NewClientOptions()
+	clientOptions.PingInterval = 10 * time.Millisecond
+	hub, connected := newCoderTestHub(t, upgrader, clientOptions)
+
+	received := make(chan wspubsub.Message, 1)
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+	})
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	dialOptions := &websocket.DialOptions{CompressionMode: websocket.CompressionContextTakeover}
+	conn, response, err := websocket.Dial(context.Background(), coderURL(server), dialOptions)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+	require.Contains(t, response.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	clientID := receiveClientID(t, connected)
+	require.Equal(t, 1, hub.Count())
+
+	err = conn.Write(context.Background(), websocket.MessageText, []byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.NewTextMessageFromString("hello"), receiveMessage(t, received))
+
+	err = hub.Subscribe(clientID, "X")
+	require.NoError(t, err)
+
+	// Pongs are only sent while the connection is read
+	messages := readCoderMessages(conn)
+
+	numClients, err := hub.Publish(wspubsub.NewBinaryMessageFromString("news"), "X")
+	require.NoError(t, err)
+	require.Equal(t, 1, numClients)
+	require.Equal(t, wspubsub.NewBinaryMessageFromString("news"), receiveMessage(t, messages))
+
+	require.Eventually(t, func() bool {
+		info, err := hub.ClientInfo(clientID)
+
+		return err == nil && info.RTT > 0
+	}, time.Second, time.Millisecond)
+
+	err = conn.Close(websocket.StatusNormalClosure, "")
+	require.NoError(t, err)
+	require.Equal(t, clientID, receiveClientID(t, disconnected))
+	require.Equal(t, 0, hub.Count())
+}
+
+func TestCoderConnectionUpgrader_Disconnect(t *testing.T) {
+	upgraderOptions := wspubsub.NewCoderConnectionUpgraderOptions()
+	upgraderOptions.MaxMessageSize = 4
+	upgraderOptions.ReadTimout = 100 * time.Millisecond
+	upgrader := wspubsub.NewCoderConnectionUpgrader(upgraderOptions)
+	hub, connected := newCoderTestHub(t, upgrader, wspubsub.NewClientOptions())
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	t.Run("Disconnect with code", func(t *testing.T) {
+		conn, _, err := websocket.Dial(context.Background(), coderURL(server), nil)
+		require.NoError(t, err)
+		defer conn.CloseNow()
+
+		clientID := receiveClientID(t, connected)
+		messages := readCoderMessages(conn)
+
+		err = hub.DisconnectWithCode(clientID, wspubsub.CloseCodeGoingAway, "bye")
+		require.NoError(t, err)
+		require.Equal(t, clientID, receiveClientID(t, disconnected))
+		require.Equal(t, wspubsub.NewCloseMessage(wspubsub.CloseCodeGoingAway, "bye"), receiveMessage(t, messages))
+	})
+
+	t.Run("Too large message", func(t *testing.T) {
+		conn, _, err := websocket.Dial(context.Background(), coderURL(server), nil)
+		require.NoError(t, err)
+		defer conn.CloseNow()
+
+		clientID := receiveClientID(t, connected)
+		messages := readCoderMessages(conn)
+
+		err = conn.Write(context.Background(), websocket.MessageText, []byte("hello"))
+		require.NoError(t, err)
+		require.Equal(t, clientID, receiveClientID(t, disconnected))
+		require.Equal(t, wspubsub.CloseCode(websocket.StatusMessageTooBig), receiveCloseCode(t, messages))
+	})
+
+	t.Run("Read timeout", func(t *testing.T) {
+		conn, _, err := websocket.Dial(context.Background(), coderURL(server), nil)
+		require.NoError(t, err)
+		defer conn.CloseNow()
+
+		clientID := receiveClientID(t, connected)
+		require.Equal(t, clientID, receiveClientID(t, disconnected))
+	})
+}
+
+func TestCoderConnectionUpgrader_Upgrade(t *testing.T) {
+	t.Run("Origin not allowed", func(t *testing.T) {
+		options := wspubsub.NewCoderConnectionUpgraderOptions()
+		options.CheckOrigin = func(r *http.Request) bool {
+			return false
+		}
+		upgrader := wspubsub.NewCoderConnectionUpgrader(options)
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		response := httptest.NewRecorder()
+
+		_, err := upgrader.Upgrade(response, request)
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Not a websocket request", func(t *testing.T) {
+		upgrader := wspubsub.NewCoderConnectionUpgrader(wspubsub.NewCoderConnectionUpgraderOptions())
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		response := httptest.NewRecorder()
+
+		_, err := upgrader.Upgrade(response, request)
+		require.Error(t, err)
+		require.Equal(t, http.StatusUpgradeRequired, response.Code)
+	})
+}
+
+// newCoderTestHub initializes a hub with the upgrader.
+// IDs of connected clients are sent to the returned channel.
+func newCoderTestHub(
+	t *testing.T,
+	upgrader *wspubsub.CoderConnectionUpgrader,
+	clientOptions wspubsub.ClientOptions,
+) (*wspubsub.Hub, chan wspubsub.UUID) {
+	t.Helper()
+
+	logger := wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := wspubsub.NewClientFactory(clientOptions, wspubsub.SatoriUUIDGenerator{}, upgrader, nil)
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	connected := make(chan wspubsub.UUID, 1)
+	hub.OnConnect(func(clientID wspubsub.UUID) {
+		connected <- clientID
+	})
+
+	t.Cleanup(func() {
+		_ = hub.Close()
+	})
+
+	return hub, connected
+}
+
+func coderURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// readCoderMessages reads the connection in background until it's closed.
+// A close frame is sent to the returned channel as a close message.
+func readCoderMessages(conn *websocket.Conn) chan wspubsub.Message {
+	messages := make(chan wspubsub.Message, 1)
+	go func() {
+		for {
+			messageType, payload, err := conn.Read(context.Background())
+			if err != nil {
+				var closeErr websocket.CloseError
+				if errors.As(err, &closeErr) {
+					messages <- wspubsub.NewCloseMessage(wspubsub.CloseCode(closeErr.Code), closeErr.Reason)
+				}
+
+				return
+			}
+
+			messages <- wspubsub.Message{Type: wspubsub.MessageType(messageType), Payload: payload}
+		}
+	}()
+
+	return messages
+}
+
+func receiveCloseCode(t *testing.T, messages chan wspubsub.Message) wspubsub.CloseCode {
+	t.Helper()
+
+	message := receiveMessage(t, messages)
+	require.Equal(t, wspubsub.MessageTypeClose, message.Type)
+	require.GreaterOrEqual(t, len(message.Payload), 2)
+
+	return wspubsub.CloseCode(uint16(message.Payload[0])<<8 | uint16(message.Payload[1]))
+}
@@ -0,0 +1,94 @@
+package wspubsub
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// connectionLimiter counts connections and checks
+// them against the global, per-IP and per-identity limits.
+type connectionLimiter struct {
+	maxTotal       int
+	maxPerIP       int
+	maxPerIdentity int
+	mu             sync.Mutex
+	total          int
+	perIP          map[string]int
+	perIdentity    map[string]int
+}
+
+// Acquire reserves a connection slot for the IP address and identity.
+// An empty identity is not limited.
+func (l *connectionLimiter) Acquire(ip, identity string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxTotal > 0 && l.total >= l.maxTotal {
+		return errors.WithStack(NewHubConnectionLimitError(ConnectionLimitScopeTotal, l.maxTotal))
+	}
+
+	if l.maxPerIP > 0 && l.perIP[ip] >= l.maxPerIP {
+		return errors.WithStack(NewHubConnectionLimitError(ConnectionLimitScopeIP, l.maxPerIP))
+	}
+
+	if l.maxPerIdentity > 0 && identity != "" && l.perIdentity[identity] >= l.maxPerIdentity {
+		return errors.WithStack(NewHubConnectionLimitError(ConnectionLimitScopeIdentity, l.maxPerIdentity))
+	}
+
+	l.total++
+	l.perIP[ip]++
+	if identity != "" {
+		l.perIdentity[identity]++
+	}
+
+	return nil
+}
+
+// Release frees a connection slot previously reserved by Acquire.
+func (l *connectionLimiter) Release(ip, identity string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+
+	l.perIP[ip]--
+	if l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+
+	if identity != "" {
+		l.perIdentity[identity]--
+		if l.perIdentity[identity] <= 0 {
+			delete(l.perIdentity, identity)
+		}
+	}
+}
+
+// CountIP returns the number of connections from the IP address.
+func (l *connectionLimiter) CountIP(ip string) int {
+	l.mu.Lock()
+	count := l.perIP[ip]
+	l.mu.Unlock()
+
+	return count
+}
+
+// CountIdentity returns the number of connections of the identity.
+func (l *connectionLimiter) CountIdentity(identity string) int {
+	l.mu.Lock()
+	count := l.perIdentity[identity]
+	l.mu.Unlock()
+
+	return count
+}
+
+func newConnectionLimiter(maxTotal, maxPerIP, maxPerIdentity int) *connectionLimiter {
+	return &connectionLimiter{
+		maxTotal:       maxTotal,
+		maxPerIP:       maxPerIP,
+		maxPerIdentity: maxPerIdentity,
+		perIP:          make(map[string]int),
+		perIdentity:    make(map[string]int),
+	}
+}
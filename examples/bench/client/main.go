@@ -53,7 +53,7 @@ func main() {
 	connections := make([]*websocket.Conn, 0, *connectionCount)
 	tasks := make(chan func() error)
 
-	logger.Println("Starting...")
+	logger.Info("Starting")
 
 	for i := 1; i <= *workerCount; i++ {
 		go runWorker(tasks)
@@ -76,7 +76,7 @@ func main() {
 			connectionsMutex.Unlock()
 
 			if i%100 == 0 {
-				logger.Printf("Connected: %d\n", i)
+				logger.Info("Connected", "connections", i)
 			}
 
 			return nil
@@ -87,12 +87,12 @@ func main() {
 
 	took := time.Since(now)
 
-	logger.Println("Done!")
-	logger.Printf("Waiting %s...", waitDuration)
+	logger.Info("Done")
+	logger.Info("Waiting", "duration", waitDuration)
 
 	time.Sleep(waitDuration)
 
-	logger.Println("Stopping...")
+	logger.Info("Stopping")
 
 	close(tasks)
 
@@ -100,9 +100,12 @@ func main() {
 		_ = connection.Close()
 	}
 
-	logger.Println("Connections:", len(connections))
-	logger.Println("Took:", took)
-	logger.Println("RPS:", math.Round(float64(len(connections))/took.Seconds()))
+	logger.Info(
+		"Stopped",
+		"connections", len(connections),
+		"took", took,
+		"rps", math.Round(float64(len(connections))/took.Seconds()),
+	)
 }
 
 func subscribe(url string, channels []string, readTimeout, writeTimeout time.Duration) (*websocket.Conn, error) {
@@ -136,7 +139,7 @@ func subscribe(url string, channels []string, readTimeout, writeTimeout time.Dur
 			err = connection.SetReadDeadline(time.Now().Add(60 * time.Second))
 			if err != nil {
 				if !strings.Contains(err.Error(), "use of closed network connection") {
-					logger.Errorf("Read error: %s\n", err)
+					logger.Error("Read error", wspubsub.LogFieldError, err)
 					return
 				}
 
@@ -146,7 +149,7 @@ func subscribe(url string, channels []string, readTimeout, writeTimeout time.Dur
 			_, _, err := connection.ReadMessage()
 			if err != nil {
 				if !strings.Contains(err.Error(), "use of closed network connection") {
-					logger.Errorf("Read error: %s\n", err)
+					logger.Error("Read error", wspubsub.LogFieldError, err)
 					return
 				}
 
@@ -162,7 +165,7 @@ func runWorker(tasks <-chan func() error) {
 	for task := range tasks {
 		err := task()
 		if err != nil {
-			logger.Errorf("Failed to process a task: %s\n", err)
+			logger.Error("Failed to process a task", wspubsub.LogFieldError, err)
 		}
 	}
 }
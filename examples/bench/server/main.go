@@ -15,7 +15,6 @@ import (
 	"time"
 
 	"github.com/kpeu3i/wspubsub"
-	"github.com/pkg/errors"
 )
 
 var (
@@ -61,7 +60,7 @@ func main() {
 		m := Message{}
 		err := json.Unmarshal(message.Payload, &m)
 		if err != nil {
-			hub.LogError(errors.Wrap(err, "hub.on_receive.unmarshal"))
+			hub.LogError("hub.on_receive.unmarshal", wspubsub.LogFieldError, err)
 			return
 		}
 
@@ -69,12 +68,12 @@ func main() {
 		case "SUBSCRIBE":
 			err := hub.Subscribe(clientID, m.Channels...)
 			if err != nil {
-				hub.LogError(errors.Wrap(err, "hub.on_receive.subscribe"))
+				hub.LogError("hub.on_receive.subscribe", wspubsub.LogFieldError, err)
 			}
 		case "UNSUBSCRIBE":
 			err := hub.Unsubscribe(clientID, m.Channels...)
 			if err != nil {
-				hub.LogError(errors.Wrap(err, "hub.on_receive.unsubscribe"))
+				hub.LogError("hub.on_receive.unsubscribe", wspubsub.LogFieldError, err)
 			}
 		}
 	})
@@ -84,7 +83,7 @@ func main() {
 	go func() {
 		err := http.ListenAndServe(*addr, nil)
 		if err != nil {
-			hub.LogPanic(err)
+			panic(err)
 		}
 	}()
 
@@ -96,26 +95,29 @@ func main() {
 
 	err = hub.Close()
 	if err != nil {
-		hub.LogPanic(err)
+		panic(err)
 	}
 
 	if publishTime > 0 && publishCount > 0 {
-		hub.LogPrintf("Total publish time: %s\n", time.Duration(publishTime))
-		hub.LogPrintf("Total messages published: %d\n", publishMessageCount)
-		hub.LogPrintf("Single message publish time: %s\n", time.Duration(publishTime/publishCount))
-		hub.LogPrintf("Publish RPS: %d\n", int(float64(publishMessageCount)/(float64(publishTime)/1e9)))
+		hub.LogInfo(
+			"Publishing finished",
+			"total_publish_time", time.Duration(publishTime),
+			"total_messages_published", publishMessageCount,
+			"single_message_publish_time", time.Duration(publishTime/publishCount),
+			"publish_rps", int(float64(publishMessageCount)/(float64(publishTime)/1e9)),
+		)
 	}
 }
 
 func publish(hub *wspubsub.Hub, ticker *time.Ticker, channels []string, payload []byte) {
-	hub.LogInfoln("Starting publishing...")
+	hub.LogInfo("Starting publishing")
 	message := wspubsub.NewBinaryMessage(payload)
 	for range ticker.C {
 		channel := channels[rand.Intn(len(channels))]
 		now := time.Now()
 		clientCount, err := hub.Publish(message, channel)
 		if err != nil {
-			hub.LogPanic(err)
+			panic(err)
 		}
 
 		if clientCount > 0 {
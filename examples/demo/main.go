@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/kpeu3i/wspubsub"
-	"github.com/pkg/errors"
 )
 
 type Message struct {
@@ -46,7 +45,7 @@ func main() {
 		m := Message{}
 		err := json.Unmarshal(message.Payload, &m)
 		if err != nil {
-			hub.LogError(errors.Wrap(err, "hub.on_receive.unmarshal"))
+			hub.LogError("hub.on_receive.unmarshal", wspubsub.LogFieldError, err)
 			return
 		}
 
@@ -54,22 +53,22 @@ func main() {
 		case "SUBSCRIBE":
 			err := hub.Subscribe(clientID, m.Channels...)
 			if err != nil {
-				hub.LogError(errors.Wrap(err, "hub.on_receive.subscribe"))
+				hub.LogError("hub.on_receive.subscribe", wspubsub.LogFieldError, err)
 			}
-			hub.LogInfof("Subscribed: client_id=%s, channels=%s", clientID, strings.Join(m.Channels, ","))
+			hub.LogInfo("Subscribed", wspubsub.LogFieldClientID, clientID, wspubsub.LogFieldChannels, m.Channels)
 		case "UNSUBSCRIBE":
 			err := hub.Unsubscribe(clientID, m.Channels...)
 			if err != nil {
-				hub.LogError(errors.Wrap(err, "hub.on_receive.unsubscribe"))
+				hub.LogError("hub.on_receive.unsubscribe", wspubsub.LogFieldError, err)
 			}
-			hub.LogInfof("Unsubscribed: client_id=%s, channels=%s", clientID, strings.Join(m.Channels, ","))
+			hub.LogInfo("Unsubscribed", wspubsub.LogFieldClientID, clientID, wspubsub.LogFieldChannels, m.Channels)
 		}
 	})
 
 	go func() {
 		err := hub.ListenAndServe(*addr, *path)
 		if err != nil {
-			hub.LogPanic(err)
+			panic(err)
 		}
 	}()
 
@@ -80,9 +79,9 @@ func main() {
 			message := wspubsub.NewTextMessageFromString(fmt.Sprintf(`{"now": %d}`, time.Now().Unix()))
 			_, err := hub.Publish(message, channel)
 			if err != nil {
-				hub.LogPanic(err)
+				panic(err)
 			}
-			hub.LogInfof("Published: channel=%s, message=%s", channel, string(message.Payload))
+			hub.LogInfo("Published", wspubsub.LogFieldChannel, channel, "message", string(message.Payload))
 		}
 	}()
 
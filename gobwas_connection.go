@@ -11,16 +11,22 @@ import (
 	"github.com/pkg/errors"
 )
 
-var _ WebsocketConnection = (*GobwasConnection)(nil)
+var (
+	_ WebsocketConnection         = (*GobwasConnection)(nil)
+	_ WebsocketConnectionRTTMeter = (*GobwasConnection)(nil)
+)
 
 // GobwasConnection is an implementation of WebsocketConnection.
 type GobwasConnection struct {
-	conn               net.Conn
-	logger             Logger
-	readTimeout        time.Duration
-	wrightTimout       time.Duration
-	IsDebug            bool
-	DebugFuncTimeLimit time.Duration
+	conn         net.Conn
+	backend      string
+	metrics      MetricsCollector
+	readTimeout  time.Duration
+	wrightTimout time.Duration
+	observer     Observer
+	logger       Logger
+	remoteAddr   string
+	rtt          rttMeter
 }
 
 // Read reads a message from WebSocket connection.
@@ -39,22 +45,29 @@ func (c *GobwasConnection) Read() (Message, error) {
 }
 
 // Write writes a message to WebSocket connection.
-func (c *GobwasConnection) Write(message Message) error {
-	if c.IsDebug {
+func (c *GobwasConnection) Write(message Message) (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.gobwas_connection.write", RemoteAddr: c.remoteAddr, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	if c.metrics != nil {
 		now := time.Now()
 		defer func() {
-			end := time.Since(now)
-			if end > c.DebugFuncTimeLimit {
-				c.logger.Warnf("gobwas.connection.write: took=%s", end)
-			}
+			c.metrics.ConnectionWritten(c.backend, time.Since(now))
 		}()
 	}
 
-	err := c.conn.SetWriteDeadline(time.Now().Add(c.wrightTimout))
+	err = c.conn.SetWriteDeadline(time.Now().Add(c.wrightTimout))
 	if err != nil {
 		return errors.WithStack(c.handleError(err))
 	}
 
+	if message.Type == MessageTypePing {
+		c.rtt.PingSent(time.Now())
+	}
+
 	err = wsutil.WriteServerMessage(c.conn, ws.OpCode(message.Type), message.Payload)
 	if err != nil {
 		return errors.WithStack(c.handleError(err))
@@ -64,25 +77,28 @@ func (c *GobwasConnection) Write(message Message) error {
 }
 
 // Close closes a WebSocket connection.
-func (c *GobwasConnection) Close() error {
-	if c.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > c.DebugFuncTimeLimit {
-				c.logger.Warnf("gobwas.connection.close: took=%s", end)
-			}
-		}()
-	}
-
-	err := c.conn.Close()
+func (c *GobwasConnection) Close() (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.gobwas_connection.close", RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
+	err = c.conn.Close()
 	if err != nil {
 		return errors.WithStack(c.handleError(err))
 	}
 
+	c.logger.Debug("Connection closed")
+
 	return nil
 }
 
+// RTT returns the round-trip time of the last answered ping.
+func (c *GobwasConnection) RTT() time.Duration {
+	return c.rtt.RTT()
+}
+
 func (c *GobwasConnection) doRead() (ws.OpCode, []byte, error) {
 	controlHandler := wsutil.ControlFrameHandler(c.conn, ws.StateServerSide)
 	reader := wsutil.Reader{
@@ -106,7 +122,10 @@ func (c *GobwasConnection) doRead() (ws.OpCode, []byte, error) {
 
 		if header.OpCode.IsControl() {
 			if header.OpCode == ws.OpPong {
-				err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+				now := time.Now()
+				c.rtt.PongReceived(now)
+
+				err := c.conn.SetReadDeadline(now.Add(c.readTimeout))
 				if err != nil {
 					return 0, nil, err
 				}
@@ -2,7 +2,6 @@ package wspubsub
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/gobwas/ws"
 )
@@ -11,40 +10,41 @@ var _ WebsocketConnectionUpgrader = (*GobwasConnectionUpgrader)(nil)
 
 // GobwasConnectionUpgrader is an implementation of WebsocketConnectionUpgrader.
 type GobwasConnectionUpgrader struct {
-	logger  Logger
 	options GobwasConnectionUpgraderOptions
+	logger  Logger
 }
 
 // GobwasConnectionUpgrader upgrades HTTP connection to the WebSocket connection.
-func (u *GobwasConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (WebsocketConnection, error) {
-	if u.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > u.options.DebugFuncTimeLimit {
-				u.logger.Warnf("gobwas.connection_upgrader.upgrader: took=%s", end)
-			}
-		}()
-	}
+func (u *GobwasConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.gobwas_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
 
 	connection, _, _, err := ws.UpgradeHTTP(r, w)
 	if err != nil {
+		u.logger.Debug("Connection upgrade failed", LogFieldRemoteAddr, r.RemoteAddr, LogFieldError, err)
+
 		return nil, err
 	}
 
 	gobwasConnection := &GobwasConnection{
-		conn:               connection,
-		logger:             u.logger,
-		readTimeout:        u.options.ReadTimout,
-		wrightTimout:       u.options.WriteTimout,
-		IsDebug:            u.options.IsDebug,
-		DebugFuncTimeLimit: u.options.DebugFuncTimeLimit,
+		conn:         connection,
+		backend:      "gobwas",
+		metrics:      u.options.Metrics,
+		readTimeout:  u.options.ReadTimout,
+		wrightTimout: u.options.WriteTimout,
+		observer:     u.options.Observer,
+		logger:       u.logger.With(LogFieldRemoteAddr, r.RemoteAddr),
+		remoteAddr:   r.RemoteAddr,
 	}
 
 	return gobwasConnection, nil
 }
 
 // NewGobwasConnectionUpgrader initializes a new GobwasConnectionUpgrader.
+// The logger is shared with upgraded connections (nil logger discards messages).
 func NewGobwasConnectionUpgrader(options GobwasConnectionUpgraderOptions, logger Logger) *GobwasConnectionUpgrader {
-	return &GobwasConnectionUpgrader{options: options, logger: logger}
+	return &GobwasConnectionUpgrader{options: options, logger: loggerOrNop(logger)}
 }
@@ -6,20 +6,18 @@ import (
 
 // GobwasConnectionUpgraderOptions represents configuration of the GobwasConnectionUpgrader.
 type GobwasConnectionUpgraderOptions struct {
-	ReadTimout         time.Duration
-	WriteTimout        time.Duration
-	IsDebug            bool
-	DebugFuncTimeLimit time.Duration
+	ReadTimout  time.Duration
+	WriteTimout time.Duration
+	Metrics     MetricsCollector
+	Observer    Observer
 }
 
 // NewGobwasUpgraderOptions initializes a new GobwasConnectionUpgraderOptions.
 // nolint: gomnd
 func NewGobwasConnectionUpgraderOptions() GobwasConnectionUpgraderOptions {
 	options := GobwasConnectionUpgraderOptions{
-		ReadTimout:         60 * time.Second,
-		WriteTimout:        10 * time.Second,
-		IsDebug:            false,
-		DebugFuncTimeLimit: 1 * time.Millisecond,
+		ReadTimout:  60 * time.Second,
+		WriteTimout: 10 * time.Second,
 	}
 
 	return options
@@ -11,6 +11,5 @@ func TestNewGobwasUpgraderOptions(t *testing.T) {
 	options := wspubsub.NewGobwasConnectionUpgraderOptions()
 	require.NotZero(t, options.ReadTimout)
 	require.NotZero(t, options.WriteTimout)
-	require.False(t, options.IsDebug)
-	require.NotZero(t, options.DebugFuncTimeLimit)
+	require.Nil(t, options.Observer)
 }
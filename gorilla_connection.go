@@ -9,17 +9,22 @@ import (
 	"github.com/pkg/errors"
 )
 
-var _ WebsocketConnection = (*GorillaConnection)(nil)
+var (
+	_ WebsocketConnection         = (*GorillaConnection)(nil)
+	_ WebsocketConnectionRTTMeter = (*GorillaConnection)(nil)
+)
 
 // GorillaConnection is an implementation of WebsocketConnection.
 type GorillaConnection struct {
-	conn               *websocket.Conn
-	logger             Logger
-	maxMessageSize     int64
-	readTimeout        time.Duration
-	writeTimout        time.Duration
-	IsDebug            bool
-	DebugFuncTimeLimit time.Duration
+	conn           *websocket.Conn
+	metrics        MetricsCollector
+	maxMessageSize int64
+	readTimeout    time.Duration
+	writeTimout    time.Duration
+	observer       Observer
+	logger         Logger
+	remoteAddr     string
+	rtt            rttMeter
 }
 
 // Read reads a message from WebSocket connection.
@@ -43,22 +48,29 @@ func (c *GorillaConnection) Read() (Message, error) {
 }
 
 // Write writes a message to WebSocket connection.
-func (c *GorillaConnection) Write(message Message) error {
-	if c.IsDebug {
+func (c *GorillaConnection) Write(message Message) (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.gorilla_connection.write", RemoteAddr: c.remoteAddr, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	if c.metrics != nil {
 		now := time.Now()
 		defer func() {
-			end := time.Since(now)
-			if end > c.DebugFuncTimeLimit {
-				c.logger.Warnf("wspubsub.gorilla_connection.write: took=%s", end)
-			}
+			c.metrics.ConnectionWritten("gorilla", time.Since(now))
 		}()
 	}
 
-	err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimout))
+	err = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimout))
 	if err != nil {
 		return errors.WithStack(c.handleError(err))
 	}
 
+	if message.Type == MessageTypePing {
+		c.rtt.PingSent(time.Now())
+	}
+
 	err = c.conn.WriteMessage(int(message.Type), message.Payload)
 	if err != nil {
 		return errors.WithStack(c.handleError(err))
@@ -68,25 +80,35 @@ func (c *GorillaConnection) Write(message Message) error {
 }
 
 // Close closes a WebSocket connection.
-func (c *GorillaConnection) Close() error {
-	if c.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > c.DebugFuncTimeLimit {
-				c.logger.Warnf("wspubsub.gorilla_connection.close: took=%s", end)
-			}
-		}()
-	}
-
-	err := c.conn.Close()
+func (c *GorillaConnection) Close() (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.gorilla_connection.close", RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
+	err = c.conn.Close()
 	if err != nil {
 		return errors.WithStack(c.handleError(err))
 	}
 
+	c.logger.Debug("Connection closed")
+
 	return nil
 }
 
+// RTT returns the round-trip time of the last answered ping.
+func (c *GorillaConnection) RTT() time.Duration {
+	return c.rtt.RTT()
+}
+
+func (c *GorillaConnection) handlePong(string) error {
+	now := time.Now()
+	c.rtt.PongReceived(now)
+
+	return c.conn.SetReadDeadline(now.Add(c.readTimeout))
+}
+
 func (c *GorillaConnection) handleError(err error) error {
 	if err == nil {
 		return nil
@@ -13,24 +13,22 @@ var _ WebsocketConnectionUpgrader = (*GorillaConnectionUpgrader)(nil)
 // GorillaConnectionUpgrader is an implementation of WebsocketConnectionUpgrader.
 type GorillaConnectionUpgrader struct {
 	options  GorillaConnectionUpgraderOptions
-	logger   Logger
 	upgrader *websocket.Upgrader
+	logger   Logger
 }
 
 // GorillaConnectionUpgrader upgrades HTTP connection to the WebSocket connection.
-func (u *GorillaConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (WebsocketConnection, error) {
-	if u.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > u.options.DebugFuncTimeLimit {
-				u.logger.Warnf("gorilla.connection_upgrader.upgrader: took=%s", end)
-			}
-		}()
-	}
+func (u *GorillaConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.gorilla_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
 
 	connection, err := u.upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		u.logger.Debug("Connection upgrade failed", LogFieldRemoteAddr, r.RemoteAddr, LogFieldError, err)
+
 		return nil, errors.WithStack(err)
 	}
 
@@ -41,24 +39,24 @@ func (u *GorillaConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Reque
 		return nil, errors.WithStack(err)
 	}
 
-	connection.SetPongHandler(func(string) error {
-		return connection.SetReadDeadline(time.Now().Add(u.options.ReadTimout))
-	})
-
 	gorillaConnection := &GorillaConnection{
-		conn:               connection,
-		logger:             u.logger,
-		maxMessageSize:     u.options.MaxMessageSize,
-		readTimeout:        u.options.ReadTimout,
-		writeTimout:        u.options.WriteTimout,
-		IsDebug:            u.options.IsDebug,
-		DebugFuncTimeLimit: u.options.DebugFuncTimeLimit,
+		conn:           connection,
+		metrics:        u.options.Metrics,
+		maxMessageSize: u.options.MaxMessageSize,
+		readTimeout:    u.options.ReadTimout,
+		writeTimout:    u.options.WriteTimout,
+		observer:       u.options.Observer,
+		logger:         u.logger.With(LogFieldRemoteAddr, r.RemoteAddr),
+		remoteAddr:     r.RemoteAddr,
 	}
 
+	connection.SetPongHandler(gorillaConnection.handlePong)
+
 	return gorillaConnection, nil
 }
 
 // NewGorillaConnectionUpgrader initializes a new GorillaConnectionUpgrader.
+// The logger is shared with upgraded connections (nil logger discards messages).
 func NewGorillaConnectionUpgrader(options GorillaConnectionUpgraderOptions, logger Logger) *GorillaConnectionUpgrader {
 	upgrader := &websocket.Upgrader{
 		HandshakeTimeout:  options.HandshakeTimeout,
@@ -69,5 +67,5 @@ func NewGorillaConnectionUpgrader(options GorillaConnectionUpgraderOptions, logg
 		EnableCompression: options.EnableCompression,
 	}
 
-	return &GorillaConnectionUpgrader{options: options, logger: logger, upgrader: upgrader}
+	return &GorillaConnectionUpgrader{options: options, upgrader: upgrader, logger: loggerOrNop(logger)}
 }
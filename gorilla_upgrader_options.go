@@ -7,18 +7,18 @@ import (
 
 // GorillaConnectionUpgraderOptions represents configuration of the GorillaConnectionUpgrader.
 type GorillaConnectionUpgraderOptions struct {
-	MaxMessageSize     int64
-	ReadTimout         time.Duration
-	WriteTimout        time.Duration
-	HandshakeTimeout   time.Duration
-	ReadBufferSize     int
-	WriteBufferSize    int
-	Subprotocols       []string
-	Error              func(w http.ResponseWriter, r *http.Request, status int, reason error)
-	CheckOrigin        func(r *http.Request) bool
-	EnableCompression  bool
-	IsDebug            bool
-	DebugFuncTimeLimit time.Duration
+	MaxMessageSize    int64
+	ReadTimout        time.Duration
+	WriteTimout       time.Duration
+	HandshakeTimeout  time.Duration
+	ReadBufferSize    int
+	WriteBufferSize   int
+	Subprotocols      []string
+	Error             func(w http.ResponseWriter, r *http.Request, status int, reason error)
+	CheckOrigin       func(r *http.Request) bool
+	EnableCompression bool
+	Metrics           MetricsCollector
+	Observer          Observer
 }
 
 // NewGorillaConnectionUpgraderOptions initializes a new GorillaConnectionUpgraderOptions.
@@ -33,8 +33,6 @@ func NewGorillaConnectionUpgraderOptions() GorillaConnectionUpgraderOptions {
 		CheckOrigin: func(r *http.Request) bool {
 			return true
 		},
-		IsDebug:            false,
-		DebugFuncTimeLimit: 1 * time.Millisecond,
 	}
 
 	return options
@@ -12,6 +12,5 @@ func TestNewGorillaUpgraderOptions(t *testing.T) {
 	require.NotZero(t, options.MaxMessageSize)
 	require.NotZero(t, options.ReadTimout)
 	require.NotZero(t, options.WriteTimout)
-	require.False(t, options.IsDebug)
-	require.NotZero(t, options.DebugFuncTimeLimit)
+	require.Nil(t, options.Observer)
 }
@@ -0,0 +1,135 @@
+package wspubsub
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ net.Conn = (*h2StreamConn)(nil)
+
+// h2StreamConn adapts an HTTP/2 stream of an extended CONNECT request to net.Conn.
+// The request body is read and the response is written.
+// The stream stays open until the connection is closed (see H2ConnectionUpgrader.Handler).
+type h2StreamConn struct {
+	body       io.ReadCloser
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	mu         sync.Mutex
+	closed     bool
+	done       chan struct{}
+}
+
+func (c *h2StreamConn) Read(b []byte) (int, error) {
+	n, err := c.body.Read(b)
+	if err != nil && c.isClosed() {
+		return n, net.ErrClosed
+	}
+
+	return n, err
+}
+
+// Write writes and flushes b, since WebSocket frames are written by several goroutines
+// (e.g. pongs are written by the reader).
+func (c *h2StreamConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The response can't be written after the handler returns
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	n, err := c.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+
+	return n, c.controller.Flush()
+}
+
+// Close releases the handler which keeps the stream open.
+// It can be called by both the handler and the WebSocket connection, so it's idempotent.
+func (c *h2StreamConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	close(c.done)
+
+	return c.body.Close()
+}
+
+func (c *h2StreamConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *h2StreamConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *h2StreamConn) SetDeadline(t time.Time) error {
+	err := c.SetReadDeadline(t)
+	if err != nil {
+		return err
+	}
+
+	return c.SetWriteDeadline(t)
+}
+
+func (c *h2StreamConn) SetReadDeadline(t time.Time) error {
+	return c.controller.SetReadDeadline(t)
+}
+
+func (c *h2StreamConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	return c.controller.SetWriteDeadline(t)
+}
+
+func (c *h2StreamConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
+// h2Addr is an address of the stream taken from the request.
+type h2Addr string
+
+func (a h2Addr) Network() string {
+	return "tcp"
+}
+
+func (a h2Addr) String() string {
+	return string(a)
+}
+
+func newH2StreamConn(w http.ResponseWriter, r *http.Request) *h2StreamConn {
+	var localAddr net.Addr = h2Addr("")
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		localAddr = addr
+	}
+
+	return &h2StreamConn{
+		body:       r.Body,
+		w:          w,
+		controller: http.NewResponseController(w),
+		localAddr:  localAddr,
+		remoteAddr: h2Addr(r.RemoteAddr),
+		done:       make(chan struct{}),
+	}
+}
@@ -0,0 +1,163 @@
+package wspubsub
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ WebsocketConnectionUpgrader = (*H2ConnectionUpgrader)(nil)
+
+type h2StreamKey struct{}
+
+// h2Stream passes the upgraded stream from the upgrader to the handler, which keeps it open.
+type h2Stream struct {
+	mu   sync.Mutex
+	conn *h2StreamConn
+}
+
+// H2ConnectionUpgrader is an implementation of WebsocketConnectionUpgrader
+// which accepts WebSockets over HTTP/2 streams (RFC 8441 extended CONNECT),
+// so many connections of a browser share one TCP connection.
+//
+// An HTTP/2 stream is closed when its handler returns, so the hub must be wrapped by Handler:
+//
+//	upgrader := wspubsub.NewMultiConnectionUpgrader(
+//		wspubsub.UpgraderRule{Match: wspubsub.IsH2WebsocketRequest, Upgrader: h2Upgrader},
+//		wspubsub.UpgraderRule{Match: wspubsub.IsWebsocketRequest, Upgrader: gorillaUpgrader},
+//	)
+//	...
+//	server.Handler = h2Upgrader.Handler(hub)
+//
+// The Go HTTP/2 server advertises extended CONNECT only if GODEBUG contains http2xconnect=1.
+type H2ConnectionUpgrader struct {
+	options H2ConnectionUpgraderOptions
+}
+
+// Upgrade accepts an extended CONNECT request.
+func (u *H2ConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.h2_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
+
+	stream, ok := r.Context().Value(h2StreamKey{}).(*h2Stream)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return nil, errors.New("wspubsub: HTTP/2 upgrader requires its handler")
+	}
+
+	if !IsH2WebsocketRequest(r) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return nil, errors.New("wspubsub: request isn't an extended CONNECT of WebSocket")
+	}
+
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		w.Header().Set("Sec-Websocket-Version", "13")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return nil, errors.New("wspubsub: unsupported WebSocket version")
+	}
+
+	if u.options.CheckOrigin != nil && !u.options.CheckOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+		return nil, errors.New("wspubsub: request origin isn't allowed")
+	}
+
+	if subprotocol := u.selectSubprotocol(r); subprotocol != "" {
+		w.Header().Set("Sec-Websocket-Protocol", subprotocol)
+	}
+
+	conn := newH2StreamConn(w, r)
+
+	err = conn.SetWriteDeadline(time.Now().Add(u.options.WriteTimeout))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	err = conn.controller.Flush()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	stream.mu.Lock()
+	stream.conn = conn
+	stream.mu.Unlock()
+
+	connection := &GobwasConnection{
+		conn:         conn,
+		backend:      "h2",
+		metrics:      u.options.Metrics,
+		readTimeout:  u.options.ReadTimeout,
+		wrightTimout: u.options.WriteTimeout,
+		observer:     u.options.Observer,
+		logger:       nopLogger{},
+		remoteAddr:   r.RemoteAddr,
+	}
+
+	return connection, nil
+}
+
+// Handler wraps the handler of connections (usually the hub)
+// to keep HTTP/2 streams open until the connections are closed.
+func (u *H2ConnectionUpgrader) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsH2WebsocketRequest(r) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		stream := &h2Stream{}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), h2StreamKey{}, stream)))
+
+		stream.mu.Lock()
+		conn := stream.conn
+		stream.mu.Unlock()
+
+		if conn == nil {
+			return
+		}
+
+		select {
+		case <-conn.done:
+		case <-r.Context().Done():
+			// The stream is reset by the client
+			_ = conn.Close()
+		}
+	})
+}
+
+func (u *H2ConnectionUpgrader) selectSubprotocol(r *http.Request) string {
+	for _, supported := range u.options.Subprotocols {
+		for _, requested := range strings.Split(r.Header.Get("Sec-Websocket-Protocol"), ",") {
+			if strings.TrimSpace(requested) == supported {
+				return supported
+			}
+		}
+	}
+
+	return ""
+}
+
+// IsH2WebsocketRequest reports whether the request is an HTTP/2 extended CONNECT of WebSocket.
+func IsH2WebsocketRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 &&
+		r.Method == http.MethodConnect &&
+		strings.EqualFold(r.Header.Get(":protocol"), "websocket")
+}
+
+// NewH2ConnectionUpgrader initializes a new H2ConnectionUpgrader.
+func NewH2ConnectionUpgrader(options H2ConnectionUpgraderOptions) *H2ConnectionUpgrader {
+	return &H2ConnectionUpgrader{options: options}
+}
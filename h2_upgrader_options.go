@@ -0,0 +1,33 @@
+package wspubsub
+
+import (
+	"net/http"
+	"time"
+)
+
+// H2ConnectionUpgraderOptions represents configuration of the H2ConnectionUpgrader.
+type H2ConnectionUpgraderOptions struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// Subprotocols supported by the server in order of preference
+	Subprotocols []string
+
+	CheckOrigin func(r *http.Request) bool
+	Metrics     MetricsCollector
+	Observer    Observer
+}
+
+// NewH2ConnectionUpgraderOptions initializes a new H2ConnectionUpgraderOptions.
+// nolint: gomnd
+func NewH2ConnectionUpgraderOptions() H2ConnectionUpgraderOptions {
+	options := H2ConnectionUpgraderOptions{
+		ReadTimeout:  60 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	return options
+}
@@ -0,0 +1,19 @@
+package wspubsub_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewH2ConnectionUpgraderOptions(t *testing.T) {
+	options := wspubsub.NewH2ConnectionUpgraderOptions()
+	require.NotZero(t, options.ReadTimeout)
+	require.NotZero(t, options.WriteTimeout)
+	require.Nil(t, options.Subprotocols)
+	require.True(t, options.CheckOrigin(httptest.NewRequest("CONNECT", "/", nil)))
+	require.Nil(t, options.Metrics)
+	require.Nil(t, options.Observer)
+}
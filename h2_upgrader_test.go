@@ -0,0 +1,225 @@
+package wspubsub_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+type h2TestStream struct {
+	response *http.Response
+	upstream *io.PipeWriter
+}
+
+func (s *h2TestStream) Read(p []byte) (int, error) {
+	return s.response.Body.Read(p)
+}
+
+func (s *h2TestStream) Write(p []byte) (int, error) {
+	return s.upstream.Write(p)
+}
+
+func TestH2ConnectionUpgrader(t *testing.T) {
+	// The HTTP/2 server enables extended CONNECT only if GODEBUG contains http2xconnect=1
+	// and reads it once on start, so the test is run by a subprocess
+	if !strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1") {
+		command := exec.Command(os.Args[0], "-test.run=^TestH2ConnectionUpgrader$", "-test.count=1")
+		command.Env = append(os.Environ(), "GODEBUG=http2xconnect=1")
+		output, err := command.CombinedOutput()
+		require.NoError(t, err, string(output))
+
+		return
+	}
+
+	upgrader := wspubsub.NewH2ConnectionUpgrader(wspubsub.NewH2ConnectionUpgraderOptions())
+
+	logger := wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := wspubsub.NewClientFactory(wspubsub.NewClientOptions(), wspubsub.SatoriUUIDGenerator{}, upgrader, nil)
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	connected := make(chan wspubsub.UUID, 2)
+	hub.OnConnect(func(clientID wspubsub.UUID) {
+		connected <- clientID
+	})
+
+	disconnected := make(chan wspubsub.UUID, 2)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	received := make(chan wspubsub.Message, 1)
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+	})
+
+	numConns := int32(0)
+	server := httptest.NewUnstartedServer(upgrader.Handler(hub))
+	server.EnableHTTP2 = true
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&numConns, 1)
+		}
+	}
+
+	server.StartTLS()
+	t.Cleanup(func() {
+		_ = hub.Close()
+		server.Close()
+	})
+
+	// The HTTP/1 transport rejects the :protocol pseudo-header
+	transport := &http2.Transport{TLSClientConfig: server.Client().Transport.(*http.Transport).TLSClientConfig}
+	t.Cleanup(transport.CloseIdleConnections)
+
+	stream1 := openH2Stream(t, transport, server.URL)
+	clientID1 := receiveClientID(t, connected)
+
+	stream2 := openH2Stream(t, transport, server.URL)
+	clientID2 := receiveClientID(t, connected)
+
+	require.Equal(t, 2, hub.Count())
+	require.Equal(t, int32(1), atomic.LoadInt32(&numConns))
+	require.Equal(t, 2, stream1.response.ProtoMajor)
+
+	t.Run("Receive message", func(t *testing.T) {
+		err := wsutil.WriteClientText(stream1, []byte("hello"))
+		require.NoError(t, err)
+		require.Equal(t, wspubsub.NewTextMessageFromString("hello"), receiveMessage(t, received))
+	})
+
+	t.Run("Publish message", func(t *testing.T) {
+		err := hub.Subscribe(clientID1, "X")
+		require.NoError(t, err)
+		err = hub.Subscribe(clientID2, "X")
+		require.NoError(t, err)
+
+		numClients, err := hub.Publish(wspubsub.NewTextMessageFromString("news"), "X")
+		require.NoError(t, err)
+		require.Equal(t, 2, numClients)
+
+		for _, stream := range []*h2TestStream{stream1, stream2} {
+			payload, opCode, err := wsutil.ReadServerData(stream)
+			require.NoError(t, err)
+			require.Equal(t, ws.OpText, opCode)
+			require.Equal(t, []byte("news"), payload)
+		}
+	})
+
+	t.Run("Disconnect with code", func(t *testing.T) {
+		err := hub.DisconnectWithCode(clientID1, wspubsub.CloseCodeGoingAway, "bye")
+		require.NoError(t, err)
+		require.Equal(t, clientID1, receiveClientID(t, disconnected))
+
+		// The stream is closed, so the close frame can't be answered
+		frame, err := ws.ReadFrame(stream1)
+		require.NoError(t, err)
+		require.Equal(t, ws.OpClose, frame.Header.OpCode)
+
+		code, reason := ws.ParseCloseFrameData(frame.Payload)
+		require.Equal(t, ws.StatusGoingAway, code)
+		require.Equal(t, "bye", reason)
+	})
+
+	t.Run("Close by client", func(t *testing.T) {
+		// Ends the request body
+		err := stream2.upstream.Close()
+		require.NoError(t, err)
+		require.Equal(t, clientID2, receiveClientID(t, disconnected))
+		require.Equal(t, 0, hub.Count())
+	})
+}
+
+func TestH2ConnectionUpgrader_InvalidRequest(t *testing.T) {
+	newRequest := func() *http.Request {
+		request := httptest.NewRequest(http.MethodConnect, "/", nil)
+		request.ProtoMajor = 2
+		request.Header.Set(":protocol", "websocket")
+		request.Header.Set("Sec-WebSocket-Version", "13")
+
+		return request
+	}
+
+	options := wspubsub.NewH2ConnectionUpgraderOptions()
+	options.CheckOrigin = func(r *http.Request) bool {
+		return r.Header.Get("Origin") != "https://evil.example"
+	}
+
+	upgrader := wspubsub.NewH2ConnectionUpgrader(options)
+	handler := upgrader.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := upgrader.Upgrade(w, r)
+		require.Error(t, err)
+	}))
+
+	t.Run("Without handler", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		_, err := upgrader.Upgrade(response, newRequest())
+		require.Error(t, err)
+		require.Equal(t, http.StatusInternalServerError, response.Code)
+	})
+
+	t.Run("Invalid version", func(t *testing.T) {
+		request := newRequest()
+		request.Header.Set("Sec-WebSocket-Version", "8")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		require.Equal(t, http.StatusBadRequest, response.Code)
+		require.Equal(t, "13", response.Header().Get("Sec-WebSocket-Version"))
+	})
+
+	t.Run("Invalid origin", func(t *testing.T) {
+		request := newRequest()
+		request.Header.Set("Origin", "https://evil.example")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		require.Equal(t, http.StatusForbidden, response.Code)
+	})
+}
+
+func TestIsH2WebsocketRequest(t *testing.T) {
+	request := httptest.NewRequest(http.MethodConnect, "/", nil)
+	request.Header.Set(":protocol", "websocket")
+	require.False(t, wspubsub.IsH2WebsocketRequest(request))
+
+	request.ProtoMajor = 2
+	require.True(t, wspubsub.IsH2WebsocketRequest(request))
+
+	request.Method = http.MethodGet
+	require.False(t, wspubsub.IsH2WebsocketRequest(request))
+}
+
+func openH2Stream(t *testing.T, transport *http2.Transport, url string) *h2TestStream {
+	t.Helper()
+
+	upstreamReader, upstream := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodConnect, url, upstreamReader)
+	require.NoError(t, err)
+	request.Header.Set(":protocol", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+
+	response, err := transport.RoundTrip(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	t.Cleanup(func() {
+		_ = response.Body.Close()
+	})
+
+	return &h2TestStream{response: response, upstream: upstream}
+}
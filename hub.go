@@ -2,12 +2,20 @@ package wspubsub
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 	"golang.org/x/sync/errgroup"
 )
@@ -20,6 +28,10 @@ type WebsocketClient interface {
 	OnError(handler ErrorHandler)
 	Send(message Message) error
 	Close() error
+	CloseWithCode(code CloseCode, reason string) error
+	QueueDepth() int
+	RTT() time.Duration
+	Stats() ClientStats
 }
 
 // WebsocketClientStore is an interface responsible for storing and finding the users.
@@ -28,11 +40,14 @@ type WebsocketClientStore interface {
 	Set(client WebsocketClient)
 	Unset(clientID UUID) error
 	Count(channels ...string) int
+	CountByChannel() map[string]int
 	Find(fn IterateFunc, channels ...string) error
 	Channels(clientID UUID) ([]string, error)
 	CountChannels(clientID UUID) (int, error)
 	SetChannels(clientID UUID, channels ...string) error
 	UnsetChannels(clientID UUID, channels ...string) error
+	OnChannelCreated(handler ChannelHandler)
+	OnChannelEmptied(handler ChannelHandler)
 }
 
 // WebsocketClientStore is an interface responsible for creating a client.
@@ -40,53 +55,182 @@ type WebsocketClientFactory interface {
 	Create() WebsocketClient
 }
 
-// Logger is an interface representing the ability to log messages.
+// Authenticator is an interface responsible for authenticating a client
+// before its connection is upgraded.
+// Returning HubAuthenticationError allows to choose a status code of the rejected upgrade,
+// any other error causes 401 status code.
+type Authenticator interface {
+	Authenticate(request *http.Request) (Identity, error)
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as Authenticator.
+type AuthenticatorFunc func(request *http.Request) (Identity, error)
+
+// Authenticate calls fn(request).
+func (fn AuthenticatorFunc) Authenticate(request *http.Request) (Identity, error) {
+	return fn(request)
+}
+
+// Authorizer is an interface responsible for granting access to channels.
+type Authorizer interface {
+	Authorize(identity Identity, channels ...string) error
+}
+
+// AuthorizerFunc is an adapter to allow the use of ordinary functions as Authorizer.
+type AuthorizerFunc func(identity Identity, channels ...string) error
+
+// Authorize calls fn(identity, channels...).
+func (fn AuthorizerFunc) Authorize(identity Identity, channels ...string) error {
+	return fn(identity, channels...)
+}
+
+// MetricsCollector is an interface responsible for collecting metrics of the hub,
+// clients and connections.
+type MetricsCollector interface {
+	ClientConnected()
+	ClientDisconnected(reason DisconnectReason)
+	ChannelSubscribers(channel string, count int)
+	MessagePublished(numClients int, size int, duration time.Duration)
+	MessageSent(size int)
+	MessageReceived(size int)
+	MessageDropped(reason DropReason)
+	ConnectionWritten(backend string, duration time.Duration)
+}
+
+// Observer is an interface representing the ability to observe operations
+// of the hub, clients, the client store and connections.
+// It can be used to attach metrics, tracing or profiling.
+type Observer interface {
+	// OperationStarted is called before an operation starts
+	OperationStarted(event OperationEvent)
+
+	// OperationFinished is called after an operation finished,
+	// the event contains the duration and the outcome of the operation
+	OperationFinished(event OperationEvent)
+}
+
+// MessagePropagator is an interface representing the ability to carry
+// a trace context inside messages.
+type MessagePropagator interface {
+	// Inject returns a copy of the message carrying the trace context of ctx
+	Inject(ctx context.Context, message Message) Message
+
+	// Extract returns a copy of ctx with the trace context carried by the message
+	Extract(ctx context.Context, message Message) context.Context
+}
+
+// Clock is an interface providing the current time and timers to the scheduler of the hub.
+// It allows to control time in tests.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer is an interface representing a timer created by Clock.
+type ClockTimer interface {
+	// Stop prevents the timer from firing,
+	// it returns false if the timer has already fired or been stopped
+	Stop() bool
+}
+
+// MessageProducer creates a message published periodically (see Hub.PublishEvery).
+// The time is the moment the message was scheduled to.
+type MessageProducer func(now time.Time) (Message, error)
+
+// DisconnectReason enumerates possible reasons of a client disconnection.
+type DisconnectReason string
+
+const (
+	// DisconnectReasonClosed is used when a client is disconnected explicitly.
+	DisconnectReasonClosed DisconnectReason = "closed"
+
+	// DisconnectReasonError is used when reading or writing a connection failed.
+	DisconnectReasonError DisconnectReason = "error"
+
+	// DisconnectReasonOverflow is used when a client send buffer is full.
+	DisconnectReasonOverflow DisconnectReason = "overflow"
+
+	// DisconnectReasonRateLimit is used when a client exceeded its receive rate limit.
+	DisconnectReasonRateLimit DisconnectReason = "rate_limit"
+
+	// DisconnectReasonExpired is used when client credentials are expired.
+	DisconnectReasonExpired DisconnectReason = "expired"
+
+	// DisconnectReasonShutdown is used when the hub is closed.
+	DisconnectReasonShutdown DisconnectReason = "shutdown"
+)
+
+// DropReason enumerates possible reasons of dropping a message.
+type DropReason string
+
+const (
+	// DropReasonOverflow is used when a client send buffer is full.
+	DropReasonOverflow DropReason = "overflow"
+
+	// DropReasonRateLimit is used when a received message exceeds the rate limit.
+	DropReasonRateLimit DropReason = "rate_limit"
+
+	// DropReasonConflated is used when an undelivered message is replaced by a newer one.
+	DropReasonConflated DropReason = "conflated"
+
+	// DropReasonExpired is used when a message expired while waiting in a client send buffer.
+	DropReasonExpired DropReason = "expired"
+)
+
+// Logger is an interface representing the ability to log structured messages.
+// Fields are passed as alternating keys and values, e.g.:
+// logger.Info("Client connected", "client_id", clientID, "remote_addr", "127.0.0.1:53414").
 type Logger interface {
-	Debug(args ...interface{})
-	Info(args ...interface{})
-	Print(args ...interface{})
-	Warn(args ...interface{})
-	Error(args ...interface{})
-	Fatal(args ...interface{})
-	Panic(args ...interface{})
-
-	Debugln(args ...interface{})
-	Infoln(args ...interface{})
-	Println(args ...interface{})
-	Warnln(args ...interface{})
-	Errorln(args ...interface{})
-	Fatalln(args ...interface{})
-	Panicln(args ...interface{})
-
-	Debugf(format string, args ...interface{})
-	Infof(format string, args ...interface{})
-	Printf(format string, args ...interface{})
-	Warnf(format string, args ...interface{})
-	Errorf(format string, args ...interface{})
-	Fatalf(format string, args ...interface{})
-	Panicf(format string, args ...interface{})
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+
+	// With returns a logger which adds the fields to every message
+	With(keysAndValues ...interface{}) Logger
 }
 
+// Field keys shared by all log messages.
+const (
+	LogFieldClientID   = "client_id"
+	LogFieldChannel    = "channel"
+	LogFieldChannels   = "channels"
+	LogFieldRemoteAddr = "remote_addr"
+	LogFieldError      = "error"
+)
+
 type (
 	// ConnectHandler called when a new client is connected to hub.
 	ConnectHandler func(clientID UUID)
 
+	// ConnectContextHandler called when a new client is connected to hub.
+	// The context carries the identity of the client (see IdentityFromContext).
+	ConnectContextHandler func(ctx context.Context, clientID UUID)
+
 	// DisconnectHandler called when a client is disconnected from the hub.
 	DisconnectHandler func(clientID UUID)
 
 	// ReceiveHandler called when a client reads a new message.
 	ReceiveHandler func(clientID UUID, message Message)
 
+	// ReceiveContextHandler called when a client reads a new message.
+	// The context carries the identity of the client (see IdentityFromContext).
+	ReceiveContextHandler func(ctx context.Context, clientID UUID, message Message)
+
 	// ErrorHandler called when an error occurred when reading or writing messages.
 	ErrorHandler func(clientID UUID, err error)
+
+	// ChannelHandler called when a channel gets its first subscriber or loses the last one.
+	ChannelHandler func(channel string)
 )
 
 // nolint: gochecknoglobals
 var (
-	defaultConnectHandler    = ConnectHandler(func(clientID UUID) {})
-	defaultDisconnectHandler = DisconnectHandler(func(clientID UUID) {})
-	defaultReceiveHandler    = ReceiveHandler(func(clientID UUID, message Message) {})
-	defaultErrorHandler      = ErrorHandler(func(clientID UUID, err error) {})
+	defaultConnectHandler        = ConnectContextHandler(func(ctx context.Context, clientID UUID) {})
+	defaultDisconnectHandler     = DisconnectHandler(func(clientID UUID) {})
+	defaultReceiveContextHandler = ReceiveContextHandler(func(ctx context.Context, clientID UUID, message Message) {})
+	defaultErrorHandler          = ErrorHandler(func(clientID UUID, err error) {})
+	defaultChannelHandler        = ChannelHandler(func(channel string) {})
 )
 
 // Hub manages client connections.
@@ -97,78 +241,85 @@ type Hub struct {
 	logger            Logger
 	httpServer        *http.Server
 	httpServerTLS     *http.Server
+	ipExtractor       *remoteIPExtractor
+	connectionLimiter *connectionLimiter
+	tracer            trace.Tracer
+	connections       sync.Map
+	recipientsPool    sync.Pool
+	namespaces        namespaceRegistry
+	subscriberLocks   channelLocks
+	history           sync.Map
+	historyReclaim    sync.Once
+	scheduler         *scheduler
+	stats             trafficCounters
 	connectHandler    atomic.Value
 	disconnectHandler atomic.Value
 	receiveHandler    atomic.Value
 	errorHandler      atomic.Value
+	emptiedHandler    atomic.Value
 }
 
 // Subscribe allows to subscribe a client to specific channels.
 // At least one channel is required.
-func (h *Hub) Subscribe(clientID UUID, channels ...string) error {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.subscribe: took=%s", end)
-			}
-		}()
-	}
+func (h *Hub) Subscribe(clientID UUID, channels ...string) (err error) {
+	op := startOperation(
+		h.options.Observer,
+		OperationEvent{Name: "wspubsub.hub.subscribe", ClientID: clientID, Channels: channels},
+	)
+	defer op.end(&err)
 
 	if len(channels) == 0 {
 		return NewHubSubscriptionChannelRequiredError()
 	}
 
-	err := h.clients.SetChannels(clientID, channels...)
+	err = h.authorize(clientID, channels)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if h.options.IsDebug {
-		h.logger.Debugf("Client subscribed: id=%s, channels=[%s]", clientID, strings.Join(channels, ","))
+	err = h.subscribe(clientID, channels)
+	if err != nil {
+		return errors.WithStack(err)
 	}
 
+	h.collectChannelSubscribers(channels)
+
+	h.logger.Debug("Client subscribed", LogFieldClientID, clientID, LogFieldChannels, channels)
+
 	return nil
 }
 
 // Unsubscribe allows to unsubscribe a client from specific channels.
 // If channels were not specified then the client will be
 // unsubscribed from all channels.
-func (h *Hub) Unsubscribe(clientID UUID, channels ...string) error {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.unsubscribe: took=%s", end)
-			}
-		}()
+func (h *Hub) Unsubscribe(clientID UUID, channels ...string) (err error) {
+	op := startOperation(
+		h.options.Observer,
+		OperationEvent{Name: "wspubsub.hub.unsubscribe", ClientID: clientID, Channels: channels},
+	)
+	defer op.end(&err)
+
+	affectedChannels := channels
+	if h.options.Metrics != nil && len(channels) == 0 {
+		affectedChannels, _ = h.clients.Channels(clientID)
 	}
 
-	err := h.clients.UnsetChannels(clientID, channels...)
+	err = h.clients.UnsetChannels(clientID, channels...)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if h.options.IsDebug {
-		h.logger.Debugf("Client unsubscribed: id=%s, channels=[%s]", clientID, strings.Join(channels, ","))
-	}
+	h.collectChannelSubscribers(affectedChannels)
+
+	h.logger.Debug("Client unsubscribed", LogFieldClientID, clientID, LogFieldChannels, channels)
 
 	return nil
 }
 
 // IsSubscribed checks does the client is subscribed to at least one channel.
 func (h *Hub) IsSubscribed(clientID UUID) bool {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.is_subscribed: took=%s", end)
-			}
-		}()
-	}
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.is_subscribed", ClientID: clientID})
+	defer op.end(nil)
 
 	count, _ := h.clients.CountChannels(clientID)
 
@@ -176,16 +327,9 @@ func (h *Hub) IsSubscribed(clientID UUID) bool {
 }
 
 // Channels return a list of channels the client currently subscribed to.
-func (h *Hub) Channels(clientID UUID) ([]string, error) {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.channels: took=%s", end)
-			}
-		}()
-	}
+func (h *Hub) Channels(clientID UUID) (_ []string, err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.channels", ClientID: clientID})
+	defer op.end(&err)
 
 	channels, err := h.clients.Channels(clientID)
 	if err != nil {
@@ -197,74 +341,506 @@ func (h *Hub) Channels(clientID UUID) ([]string, error) {
 
 // Count returns total number of connected clients.
 func (h *Hub) Count(channels ...string) int {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.count: took=%s", end)
-			}
-		}()
-	}
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.count", Channels: channels})
+	defer op.end(nil)
 
 	return h.clients.Count(channels...)
 }
 
+// CountIP returns the number of clients connected from the IP address.
+func (h *Hub) CountIP(ip string) int {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.count_ip"})
+	defer op.end(nil)
+
+	return h.connectionLimiter.CountIP(ip)
+}
+
+// CountIdentity returns the number of clients connected with the identity.
+func (h *Hub) CountIdentity(identity string) int {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.count_identity"})
+	defer op.end(nil)
+
+	return h.connectionLimiter.CountIdentity(identity)
+}
+
+// Identity returns the identity the client was authenticated with.
+// Handlers get the identity from the context (see OnConnectContext and OnReceiveContext)
+// which doesn't fail if the client is disconnected meanwhile.
+// Anonymous identity is returned if the authentication is disabled.
+func (h *Hub) Identity(clientID UUID) (Identity, error) {
+	connection, ok := h.connections.Load(clientID)
+	if !ok {
+		return Identity{}, errors.WithStack(NewClientNotFoundError(clientID))
+	}
+
+	return connection.(*hubConnection).Identity(), nil
+}
+
+// ClientInfo returns details of the connected client.
+func (h *Hub) ClientInfo(clientID UUID) (_ ClientInfo, err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.client_info", ClientID: clientID})
+	defer op.end(&err)
+
+	client, err := h.clients.Get(clientID)
+	if err != nil {
+		return ClientInfo{}, errors.WithStack(err)
+	}
+
+	return h.clientInfo(client), nil
+}
+
+// ClientStats returns queue and traffic statistics of the connected client.
+func (h *Hub) ClientStats(clientID UUID) (_ ClientStats, err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.client_stats", ClientID: clientID})
+	defer op.end(&err)
+
+	client, err := h.clients.Get(clientID)
+	if err != nil {
+		return ClientStats{}, errors.WithStack(err)
+	}
+
+	return client.Stats(), nil
+}
+
+// Stats returns traffic statistics aggregated over all clients connected since the hub was created.
+// Unlike collecting statistics of each client, it doesn't iterate clients.
+func (h *Hub) Stats() HubStats {
+	stats := h.stats.HubStats()
+	stats.Clients = h.clients.Count()
+
+	return stats
+}
+
+// ListClients returns details of all connected clients ordered by the connect time.
+func (h *Hub) ListClients() (_ []ClientInfo, err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.list_clients"})
+	defer op.end(&err)
+
+	infos := make([]ClientInfo, 0, h.clients.Count())
+	iterateFunc := func(client WebsocketClient) error {
+		infos = append(infos, h.clientInfo(client))
+
+		return nil
+	}
+
+	err = h.clients.Find(iterateFunc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].ConnectedAt.Equal(infos[j].ConnectedAt) {
+			return infos[i].ID.String() < infos[j].ID.String()
+		}
+
+		return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+	})
+
+	op.setCount(len(infos))
+
+	return infos, nil
+}
+
+// ListChannels returns channels having at least one subscriber ordered by the name.
+func (h *Hub) ListChannels() []ChannelInfo {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.list_channels"})
+	defer op.end(nil)
+
+	counts := h.clients.CountByChannel()
+
+	infos := make([]ChannelInfo, 0, len(counts))
+	for channel, count := range counts {
+		infos = append(infos, ChannelInfo{Name: channel, Subscribers: count})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	op.setCount(len(infos))
+
+	return infos
+}
+
+// RegisterNamespace sets the policy of channels whose names start with the prefix (like "chat:").
+// The longest matching prefix wins. An empty prefix changes the policy of channels
+// which don't belong to any other namespace (see NewNamespacePolicy).
+func (h *Hub) RegisterNamespace(prefix string, policy NamespacePolicy) {
+	h.logger.Info("Registering namespace", "prefix", prefix)
+	h.namespaces.Register(prefix, policy)
+
+	if policy.HistorySize > 0 {
+		h.reclaimHistory()
+	}
+}
+
+// Presence returns IDs of clients subscribed to the channel.
+// It fails with HubNamespacePolicyError if the presence is disabled by the namespace policy.
+func (h *Hub) Presence(channel string) (_ []UUID, err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.presence", Channels: []string{channel}})
+	defer op.end(&err)
+
+	_, policy := h.namespaces.Lookup(channel)
+	if !policy.Presence {
+		return nil, errors.WithStack(NewHubNamespacePolicyError(UUID{}, channel, "presence is disabled"))
+	}
+
+	clientIDs := make([]UUID, 0, h.clients.Count(channel))
+	iterateFunc := func(client WebsocketClient) error {
+		clientIDs = append(clientIDs, client.ID())
+
+		return nil
+	}
+
+	err = h.clients.Find(iterateFunc, channel)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	op.setCount(len(clientIDs))
+
+	return clientIDs, nil
+}
+
+// History returns the last messages published to the channel ordered from the oldest to the newest.
+// The history is dropped along with the channel once its last subscriber leaves.
+// It fails with HubNamespacePolicyError if the history is disabled by the namespace policy.
+func (h *Hub) History(channel string) ([]Message, error) {
+	_, policy := h.namespaces.Lookup(channel)
+	if policy.HistorySize == 0 {
+		return nil, errors.WithStack(NewHubNamespacePolicyError(UUID{}, channel, "history is disabled"))
+	}
+
+	history, ok := h.history.Load(channel)
+	if !ok {
+		return []Message{}, nil
+	}
+
+	return history.(*channelHistory).Messages(), nil
+}
+
+// Reauthenticate refreshes credentials of the client using the token.
+// The refreshed identity must belong to the same subject.
+func (h *Hub) Reauthenticate(clientID UUID, token string) (err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.reauthenticate", ClientID: clientID})
+	defer op.end(&err)
+
+	validator := h.options.Reauthentication.Validator
+	if validator == nil {
+		return errors.WithStack(NewHubUnauthorizedError(errors.New("reauthentication is disabled")))
+	}
+
+	value, ok := h.connections.Load(clientID)
+	if !ok {
+		return errors.WithStack(NewClientNotFoundError(clientID))
+	}
+
+	connection := value.(*hubConnection)
+
+	identity, err := validator.Validate(token)
+	if err != nil {
+		if _, ok := IsHubAuthenticationError(err); ok {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(NewHubUnauthorizedError(err))
+	}
+
+	if identity.ID != connection.Identity().ID {
+		return errors.WithStack(NewHubForbiddenError(errors.New("identity mismatch")))
+	}
+
+	// The client could be disconnected since the connection was loaded
+	if !connection.SetIdentity(identity) {
+		return errors.WithStack(NewClientNotFoundError(clientID))
+	}
+
+	h.logger.Debug("Client reauthenticated", LogFieldClientID, clientID, "expires_at", identity.ExpiresAt)
+
+	return nil
+}
+
 // Publish publishes a message to the channels.
 // If channels were not specified then all clients will receive the message.
+// A client subscribed on several of the channels receives the message once
+// unless ClientStoreOptions.DuplicateDelivery is set.
 func (h *Hub) Publish(message Message, channels ...string) (int, error) {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.publish: took=%s", end)
-			}
-		}()
-	}
+	return h.PublishContext(context.Background(), message, channels...)
+}
+
+// PublishContext publishes a message to the channels like Publish.
+// The span of the context becomes a parent of the publishing span,
+// so a fan-out can be linked with a received message which triggered it (see OnReceiveContext).
+func (h *Hub) PublishContext(ctx context.Context, message Message, channels ...string) (int, error) {
+	return h.publish(ctx, "wspubsub.hub.publish", message, channels, nil)
+}
+
+// PublishWithReport publishes a message to the channels like Publish
+// and reports which clients got the message enqueued, which overflowed, which were already gone
+// and which failed to take it for other reasons.
+// Unlike Publish, it doesn't disconnect overflowing clients, so the publisher can throttle or reroute messages.
+// The fan-out stops once the context is done, the rest of the clients are reported as skipped.
+// A message whose context is done before the publishing starts isn't kept in the history.
+func (h *Hub) PublishWithReport(ctx context.Context, message Message, channels ...string) (PublishReport, error) {
+	report := PublishReport{}
+	_, err := h.publish(ctx, "wspubsub.hub.publish_with_report", message, channels, &report)
+
+	return report, err
+}
+
+// publish delivers a message to the clients subscribed on the channels.
+// Without a report overflowing clients are disconnected.
+func (h *Hub) publish(
+	ctx context.Context,
+	name string,
+	message Message,
+	channels []string,
+	report *PublishReport,
+) (_ int, err error) {
+	op := startOperation(
+		h.options.Observer,
+		OperationEvent{Name: name, Channels: channels, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	spanCtx, span := h.tracer.Start(
+		ctx,
+		"wspubsub.hub.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.StringSlice("wspubsub.channels", channels),
+			attribute.Int("wspubsub.message.size", len(message.Payload)),
+		),
+	)
 
 	numClients := 0
-	iterateFunc := func(client WebsocketClient) error {
-		err := client.Send(message)
+	defer func() {
+		span.SetAttributes(attribute.Int("wspubsub.num_clients", numClients))
+		endSpan(span, err)
+	}()
+
+	// The trace context is injected once, so all the recipients share the payload
+	if h.options.MessagePropagator != nil {
+		message = h.options.MessagePropagator.Inject(spanCtx, message)
+	}
+
+	// A publishing cancelled before any delivery leaves no trace in the history and metrics
+	if report == nil || ctx.Err() == nil {
+		h.appendHistory(message, channels)
+	}
+
+	conflationKey := h.conflationKey(channels)
+
+	now := time.Now()
+	recipients := h.recipientsPool.Get().(*[]WebsocketClient)
+	defer func() {
+		*recipients = (*recipients)[:0]
+		h.recipientsPool.Put(recipients)
+	}()
+
+	_, findSpan := h.tracer.Start(spanCtx, "wspubsub.client_store.find")
+	err = h.clients.Find(func(client WebsocketClient) error {
+		*recipients = append(*recipients, client)
+
+		return nil
+	}, channels...)
+	endSpan(findSpan, err)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	fanOutCtx, fanOutSpan := h.tracer.Start(
+		spanCtx,
+		"wspubsub.hub.fan_out",
+		trace.WithAttributes(attribute.Int("wspubsub.num_recipients", len(*recipients))),
+	)
+
+	for _, client := range *recipients {
+		if report != nil {
+			if ctx.Err() != nil {
+				report.Skipped = append(report.Skipped, client.ID())
+
+				continue
+			}
+		}
+
+		err := h.send(fanOutCtx, client, conflationKey, message)
 		if err != nil {
+			if report != nil {
+				switch {
+				case isClientOverflowed(err):
+					report.Overflowed = append(report.Overflowed, client.ID())
+				case isClientGone(err):
+					// The client was closed after the subscribers were found
+					report.Gone = append(report.Gone, client.ID())
+				default:
+					report.Failed = append(report.Failed, client.ID())
+				}
+
+				continue
+			}
+
+			// The closed client is already being disconnected
+			if isClientGone(err) {
+				continue
+			}
+
 			// A buffer overflow error can occur here,
 			// so we should disconnect the client
-			_ = h.disconnectClient(client)
+			_ = h.disconnectClient(client, DisconnectReasonOverflow)
+
+			continue
+		}
 
-			return nil
+		if report != nil {
+			report.Enqueued = append(report.Enqueued, client.ID())
 		}
 
 		numClients++
+	}
 
-		return nil
+	if report != nil && len(report.Skipped) > 0 {
+		err = ctx.Err()
+	}
+
+	op.setCount(numClients)
+	endSpan(fanOutSpan, err)
+
+	if h.options.Metrics != nil && (err == nil || numClients > 0) {
+		h.options.Metrics.MessagePublished(numClients, len(message.Payload), time.Since(now))
 	}
 
-	err := h.clients.Find(iterateFunc, channels...)
 	if err != nil {
 		return numClients, errors.WithStack(err)
 	}
 
-	if h.options.IsDebug {
-		if numClients > 0 {
-			h.logger.Debugf("Message published: num_clients=%d, channels=[%s]", numClients, strings.Join(channels, ","))
-		}
+	if numClients > 0 {
+		h.logger.Debug("Message published", "num_clients", numClients, LogFieldChannels, channels)
 	}
 
 	return numClients, nil
 }
 
-// Send sends a message to a specific client.
-func (h *Hub) Send(clientID UUID, message Message) error {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.send: took=%s", end)
-			}
-		}()
+// send enqueues the message to the client linking it with the span of the context
+// if the client supports it.
+func (h *Hub) send(ctx context.Context, client WebsocketClient, conflationKey string, message Message) error {
+	if c, ok := client.(conflatingClient); ok && conflationKey != "" {
+		return c.sendConflated(ctx, conflationKey, message)
+	}
+
+	if c, ok := client.(contextClient); ok {
+		return c.SendContext(ctx, message)
+	}
+
+	return client.Send(message)
+}
+
+func isClientOverflowed(err error) bool {
+	_, ok := IsClientSendBufferOverflowError(err)
+
+	return ok
+}
+
+// isClientGone reports whether the send error means that the client had left.
+func isClientGone(err error) bool {
+	if _, ok := IsClientClosedError(err); ok {
+		return true
+	}
+
+	_, ok := IsClientNotFoundError(err)
+
+	return ok
+}
+
+// PublishAt publishes the message to the channels at the time (immediately if the time has passed).
+// Pending publishing is cancelled when the hub is closed. Errors of the publishing are logged.
+func (h *Hub) PublishAt(at time.Time, message Message, channels ...string) (*ScheduledPublish, error) {
+	publish, err := h.scheduler.Schedule(at, false, func(time.Time) (time.Time, bool) {
+		h.publishScheduled(message, channels)
+
+		return time.Time{}, false
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return publish, nil
+}
+
+// PublishEvery publishes messages created by the producer to the channels every interval
+// until the publishing is cancelled or the hub is closed.
+// Intervals missed because of a slow producer are skipped. Errors of the producer and the publishing are logged.
+// It fails with HubScheduleError if the interval isn't positive or the producer is nil.
+func (h *Hub) PublishEvery(interval time.Duration, producer MessageProducer, channels ...string) (*ScheduledPublish, error) {
+	if interval <= 0 {
+		return nil, errors.WithStack(NewHubScheduleError(fmt.Sprintf("non-positive interval %s", interval)))
+	}
+
+	if producer == nil {
+		return nil, errors.WithStack(NewHubScheduleError("nil producer"))
+	}
+
+	run := func(at time.Time) (time.Time, bool) {
+		message, err := producer(at)
+		if err != nil {
+			h.logger.Error("Producing scheduled message failed", LogFieldChannels, channels, LogFieldError, err)
+		} else {
+			h.publishScheduled(message, channels)
+		}
+
+		next := at.Add(interval)
+		now := h.options.Clock.Now()
+		for !next.After(now) {
+			next = next.Add(interval)
+		}
+
+		return next, true
+	}
+
+	publish, err := h.scheduler.Schedule(h.options.Clock.Now().Add(interval), true, run)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return publish, nil
+}
+
+// PublishFromClient publishes a message sent by the client to the channels.
+// It fails with HubNamespacePolicyError if a namespace policy of any channel forbids client publishing.
+func (h *Hub) PublishFromClient(clientID UUID, message Message, channels ...string) (int, error) {
+	if len(channels) == 0 {
+		return 0, NewHubSubscriptionChannelRequiredError()
+	}
+
+	for _, channel := range channels {
+		_, policy := h.namespaces.Lookup(channel)
+		if !policy.ClientPublish {
+			return 0, errors.WithStack(NewHubNamespacePolicyError(clientID, channel, "client publishing is not allowed"))
+		}
 	}
 
+	return h.Publish(message, channels...)
+}
+
+// PublishValue encodes the value once using the hub codec and publishes it to the channels.
+// If channels were not specified then all clients will receive the message.
+func (h *Hub) PublishValue(v interface{}, channels ...string) (int, error) {
+	message, err := h.options.Codec.Encode(v)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return h.Publish(message, channels...)
+}
+
+// Send sends a message to a specific client.
+func (h *Hub) Send(clientID UUID, message Message) (err error) {
+	op := startOperation(
+		h.options.Observer,
+		OperationEvent{Name: "wspubsub.hub.send", ClientID: clientID, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
 	client, err := h.clients.Get(clientID)
 	if err != nil {
 		return errors.WithStack(err)
@@ -275,46 +851,93 @@ func (h *Hub) Send(clientID UUID, message Message) error {
 		return errors.WithStack(err)
 	}
 
-	if h.options.IsDebug {
-		h.logger.Debugf("Message sent: id=%s", clientID)
-	}
+	h.logger.Debug("Message sent", LogFieldClientID, clientID)
 
 	return nil
 }
 
 // Disconnect closes a client connection and removes it from the storage.
-func (h *Hub) Disconnect(clientID UUID) error {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.disconnect: took=%s", end)
-			}
-		}()
-	}
+func (h *Hub) Disconnect(clientID UUID) (err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.disconnect", ClientID: clientID})
+	defer op.end(&err)
 
 	client, err := h.clients.Get(clientID)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	err = h.disconnectClient(client)
+	err = h.disconnectClient(client, DisconnectReasonClosed)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if h.options.IsDebug {
-		h.logger.Debugf("Client disconnected: id=%s", clientID)
+	h.logger.Debug("Client disconnected", LogFieldClientID, clientID)
+
+	return nil
+}
+
+// DisconnectChannel disconnects all clients subscribed to the channel.
+// It returns the number of disconnected clients.
+func (h *Hub) DisconnectChannel(channel string) (_ int, err error) {
+	op := startOperation(
+		h.options.Observer,
+		OperationEvent{Name: "wspubsub.hub.disconnect_channel", Channels: []string{channel}},
+	)
+	defer op.end(&err)
+
+	numClients := 0
+	iterateFunc := func(client WebsocketClient) error {
+		err := h.disconnectClient(client, DisconnectReasonClosed)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		numClients++
+
+		return nil
+	}
+
+	err = h.clients.Find(iterateFunc, channel)
+	op.setCount(numClients)
+	if err != nil {
+		return numClients, errors.WithStack(err)
 	}
 
+	h.logger.Debug("Channel disconnected", LogFieldChannel, channel, "num_clients", numClients)
+
+	return numClients, nil
+}
+
+// DisconnectWithCode sends a close message with the status code and reason to the client,
+// then closes its connection and removes it from the storage.
+func (h *Hub) DisconnectWithCode(clientID UUID, code CloseCode, reason string) (err error) {
+	op := startOperation(
+		h.options.Observer,
+		OperationEvent{Name: "wspubsub.hub.disconnect_with_code", ClientID: clientID},
+	)
+	defer op.end(&err)
+
+	client, err := h.clients.Get(clientID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = h.doDisconnectClient(client, DisconnectReasonClosed, func() error {
+		return client.CloseWithCode(code, reason)
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	h.logger.Debug("Client disconnected", LogFieldClientID, clientID, "code", code)
+
 	return nil
 }
 
 // ListenAndServe listens on the TCP network address and handle requests
 // on incoming connections.
 func (h *Hub) ListenAndServe(addr, path string) error {
-	h.logger.Infof("Listening connection on: addr=%s, path=%s", addr, path)
+	h.logger.Info("Listening connection", "addr", addr, "path", path)
 
 	mux := http.NewServeMux()
 	mux.Handle(path, h)
@@ -335,7 +958,7 @@ func (h *Hub) ListenAndServe(addr, path string) error {
 // ListenAndServe listens on the TCP network address and handle requests
 // on incoming connections.
 func (h *Hub) ListenAndServeTLS(addr, path, certFile, keyFile string) error {
-	h.logger.Infof("Listening TLS connection on: addr=%s, path=%s", addr, path)
+	h.logger.Info("Listening TLS connection", "addr", addr, "path", path)
 
 	mux := http.NewServeMux()
 	mux.Handle(path, h)
@@ -355,51 +978,91 @@ func (h *Hub) ListenAndServeTLS(addr, path, certFile, keyFile string) error {
 
 // ServeHTTP implements http.Handler interface and responsible for connect new clients.
 func (h *Hub) ServeHTTP(response http.ResponseWriter, request *http.Request) {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.connection_upgrade_handler: took=%s", end)
+	var err error
+
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.connection_upgrade_handler"})
+	defer op.end(&err)
+
+	connection := &hubConnection{
+		ip:          h.ipExtractor.Extract(request),
+		remoteAddr:  request.RemoteAddr,
+		connectedAt: time.Now(),
+	}
+
+	var identity Identity
+	if h.options.Authenticator != nil {
+		identity, err = h.options.Authenticator.Authenticate(request)
+		if err != nil {
+			if _, ok := IsHubAuthenticationError(err); !ok {
+				err = NewHubUnauthorizedError(err)
 			}
-		}()
+
+			h.rejectConnection(response, err)
+			h.logger.Debug("Connection rejected", LogFieldRemoteAddr, request.RemoteAddr, "ip", connection.ip, LogFieldError, err)
+
+			return
+		}
+	}
+
+	err = h.connectionLimiter.Acquire(connection.ip, identity.ID)
+	if err != nil {
+		h.rejectConnection(response, err)
+		h.logger.Debug("Connection rejected", LogFieldRemoteAddr, request.RemoteAddr, "ip", connection.ip, LogFieldError, err)
+
+		return
 	}
 
-	receiveHandler := h.receiveHandler.Load().(ReceiveHandler)
+	receiveHandler := h.receiveHandler.Load().(ReceiveContextHandler)
 	errorHandler := h.errorHandler.Load().(ErrorHandler)
 
+	// The request context is done once the handler returns, but its values are kept
+	ctx := contextWithIdentity(context.WithoutCancel(request.Context()), identity)
+
 	client := h.clientFactory.Create()
-	client.OnReceive(receiveHandler)
+	if c, ok := client.(statsAggregatingClient); ok {
+		c.aggregateStats(&h.stats)
+	}
+
+	onReceive := func(ctx context.Context, clientID UUID, message Message) {
+		// The identity could be refreshed since the client was connected
+		receiveHandler(contextWithIdentity(ctx, connection.Identity()), clientID, message)
+	}
+
+	if c, ok := client.(contextClient); ok {
+		c.OnReceiveContext(onReceive)
+	} else {
+		client.OnReceive(func(clientID UUID, message Message) {
+			onReceive(context.Background(), clientID, message)
+		})
+	}
 	client.OnError(errorHandler)
 
-	err := h.connectClient(client, response, request)
+	connection.SetIdentity(identity)
+	h.connections.Store(client.ID(), connection)
+
+	err = h.connectClient(ctx, client, response, request)
 	if err != nil {
-		http.Error(response, "Internal Server Error", http.StatusInternalServerError)
-		if h.options.IsDebug {
-			h.logger.Errorf("cant upgrade connection: %s", err)
-		}
+		h.rejectConnection(response, err)
+		h.logger.Error("Connection upgrade failed", LogFieldRemoteAddr, request.RemoteAddr, LogFieldError, err)
 
 		return
 	}
 
-	if h.options.IsDebug {
-		h.logger.Debugf("connection upgraded: id=%s", client.ID())
-	}
+	// The client is able to be disconnected on expiry only once it's connected
+	h.activateConnection(client.ID(), connection)
+
+	h.logger.Debug("Connection upgraded", LogFieldClientID, client.ID(), LogFieldRemoteAddr, request.RemoteAddr)
 }
 
 // Close shutdowns http-servers and disconnects clients.
-func (h *Hub) Close() error {
-	if h.options.IsDebug {
-		now := time.Now()
-		defer func() {
-			end := time.Since(now)
-			if end > h.options.DebugFuncTimeLimit {
-				h.logger.Warnf("wspubsub.hub.close: took=%s", end)
-			}
-		}()
-	}
+func (h *Hub) Close() (err error) {
+	op := startOperation(h.options.Observer, OperationEvent{Name: "wspubsub.hub.close"})
+	defer op.end(&err)
+
+	h.logger.Info("Closing connections")
 
-	h.logger.Info("Closing connections...")
+	// Scheduled messages aren't published to a closed hub
+	h.scheduler.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), h.options.ShutdownTimeout)
 	defer cancel()
@@ -417,7 +1080,7 @@ func (h *Hub) Close() error {
 	errList := multierr.Combine(eg.Wait())
 
 	iterateFunc := func(client WebsocketClient) error {
-		_ = h.disconnectClient(client)
+		_ = h.disconnectClient(client, DisconnectReasonShutdown)
 
 		return nil
 	}
@@ -428,175 +1091,465 @@ func (h *Hub) Close() error {
 }
 
 // OnConnect registers a handler for client connection.
+// The identity of a connected client is available through Hub.Identity.
 func (h *Hub) OnConnect(handler ConnectHandler) {
-	h.logger.Infof("Registering handler: %T", handler)
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
+	h.connectHandler.Store(ConnectContextHandler(func(ctx context.Context, clientID UUID) {
+		handler(clientID)
+	}))
+}
+
+// OnConnectContext registers a handler for connecting clients
+// which gets the identity of the client from the context (it replaces a handler registered with OnConnect).
+func (h *Hub) OnConnectContext(handler ConnectContextHandler) {
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
 	h.connectHandler.Store(handler)
 }
 
 // OnDisconnect registers a handler for client disconnection.
 func (h *Hub) OnDisconnect(handler DisconnectHandler) {
-	h.logger.Infof("Registering handler: %T", handler)
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
 	h.disconnectHandler.Store(handler)
 }
 
 // OnReceive registers a handler for incoming messages.
 func (h *Hub) OnReceive(handler ReceiveHandler) {
-	h.logger.Infof("Registering handler: %T", handler)
-	h.receiveHandler.Store(handler)
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
+	h.receiveHandler.Store(h.wrapReceiveHandler(func(ctx context.Context, clientID UUID, message Message) {
+		handler(clientID, message)
+	}))
+}
+
+// OnReceiveContext registers a handler for incoming messages
+// which gets the identity of the client from the context (it replaces a handler registered with OnReceive).
+func (h *Hub) OnReceiveContext(handler ReceiveContextHandler) {
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
+	h.receiveHandler.Store(h.wrapReceiveHandler(handler))
+}
+
+// OnReceiveValue registers a handler for incoming messages which decodes them into values of type T
+// using the hub codec (it replaces a handler registered with OnReceive).
+// Messages which can't be decoded are reported to the error handler as ClientDecodeError
+// and skipped, the client stays connected since a single malformed message doesn't break the connection.
+func OnReceiveValue[T any](h *Hub, handler func(clientID UUID, v T)) {
+	h.OnReceive(func(clientID UUID, message Message) {
+		var v T
+
+		err := h.options.Codec.Decode(message, &v)
+		if err != nil {
+			errorHandler := h.errorHandler.Load().(ErrorHandler)
+			errorHandler(clientID, errors.WithStack(NewClientDecodeError(clientID, message, err)))
+
+			return
+		}
+
+		handler(clientID, v)
+	})
 }
 
 // OnError registers a handler for errors occurred while reading or writing connection.
+// The client is disconnected after the handler returns unless the error is a ClientDecodeError.
 func (h *Hub) OnError(handler ErrorHandler) {
-	h.logger.Infof("Registering handler: %T", handler)
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
 	h.errorHandler.Store(h.wrapErrorHandler(handler))
 }
 
-// LogDebug logs a message at level Debug.
-func (h *Hub) LogDebug(args ...interface{}) {
-	h.logger.Debug(args...)
+// OnChannelCreated registers a handler called when a channel gets its first subscriber.
+// It can be used to start an upstream feed of the channel only while it has subscribers.
+// Handlers of a channel are called synchronously in the order of its changes,
+// so they must not block and must not subscribe or unsubscribe clients.
+func (h *Hub) OnChannelCreated(handler ChannelHandler) {
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
+	h.clients.OnChannelCreated(handler)
 }
 
-// LogInfo logs a message at level Info.
-func (h *Hub) LogInfo(args ...interface{}) {
-	h.logger.Info(args...)
+// OnChannelEmptied registers a handler called when the last subscriber leaves a channel
+// (unsubscribes or disconnects).
+// Handlers of a channel are called synchronously in the order of its changes,
+// so they must not block and must not subscribe or unsubscribe clients.
+func (h *Hub) OnChannelEmptied(handler ChannelHandler) {
+	h.logger.Info("Registering handler", "handler", fmt.Sprintf("%T", handler))
+	h.emptiedHandler.Store(handler)
+	h.reclaimHistory()
 }
 
-// LogPrint logs a message at level Info.
-func (h *Hub) LogPrint(args ...interface{}) {
-	h.logger.Print(args...)
+// reclaimHistory makes the store report emptied channels to the hub,
+// so histories are dropped along with their channels instead of piling up.
+func (h *Hub) reclaimHistory() {
+	h.historyReclaim.Do(func() {
+		h.clients.OnChannelEmptied(h.channelEmptied)
+	})
 }
 
-// LogWarn logs a message at level Warn.
-func (h *Hub) LogWarn(args ...interface{}) {
-	h.logger.Warn(args...)
-}
+func (h *Hub) channelEmptied(channel string) {
+	h.history.Delete(channel)
 
-// LogError logs a message at level Error.
-func (h *Hub) LogError(args ...interface{}) {
-	h.logger.Error(args...)
+	handler := h.emptiedHandler.Load().(ChannelHandler)
+	handler(channel)
 }
 
-// Fatal logs a message at level Fatal then the process will exit with status set to 1.
-func (h *Hub) LogFatal(args ...interface{}) {
-	h.logger.Fatal(args...)
+// LogDebug logs a message with fields at level Debug.
+func (h *Hub) LogDebug(msg string, keysAndValues ...interface{}) {
+	h.logger.Debug(msg, keysAndValues...)
 }
 
-// Panic logs a message at level Panic and panics.
-func (h *Hub) LogPanic(args ...interface{}) {
-	h.logger.Panic(args...)
+// LogInfo logs a message with fields at level Info.
+func (h *Hub) LogInfo(msg string, keysAndValues ...interface{}) {
+	h.logger.Info(msg, keysAndValues...)
 }
 
-// LogDebugln is like LogDebug but adds a new line.
-func (h *Hub) LogDebugln(args ...interface{}) {
-	h.logger.Debugln(args...)
+// LogWarn logs a message with fields at level Warn.
+func (h *Hub) LogWarn(msg string, keysAndValues ...interface{}) {
+	h.logger.Warn(msg, keysAndValues...)
 }
 
-// LogInfoln is like LogInfo but adds a new line.
-func (h *Hub) LogInfoln(args ...interface{}) {
-	h.logger.Infoln(args...)
+// LogError logs a message with fields at level Error.
+func (h *Hub) LogError(msg string, keysAndValues ...interface{}) {
+	h.logger.Error(msg, keysAndValues...)
 }
 
-// LogPrintln is like LogPrint but adds a new line.
-func (h *Hub) LogPrintln(args ...interface{}) {
-	h.logger.Println(args...)
-}
+func (h *Hub) connectClient(
+	ctx context.Context,
+	client WebsocketClient,
+	response http.ResponseWriter,
+	request *http.Request,
+) error {
+	h.clients.Set(client)
 
-// LogWarnln is like LogWarn but adds a new line.
-func (h *Hub) LogWarnln(args ...interface{}) {
-	h.logger.Warnln(args...)
-}
+	err := client.Connect(response, request)
+	if err != nil {
+		_ = h.clients.Unset(client.ID())
+		h.releaseConnection(client.ID())
 
-// LogErrorln is like LogError but adds a new line.
-func (h *Hub) LogErrorln(args ...interface{}) {
-	h.logger.Errorln(args...)
-}
+		return errors.WithStack(err)
+	}
 
-// LogFatalln is like LogFatal but adds a new line.
-func (h *Hub) LogFatalln(args ...interface{}) {
-	h.logger.Fatalln(args...)
-}
+	if h.options.Metrics != nil {
+		h.options.Metrics.ClientConnected()
+	}
 
-// LogPanicln is like LogPanic but adds a new line.
-func (h *Hub) LogPanicln(args ...interface{}) {
-	h.logger.Panicln(args...)
-}
+	connectHandler := h.connectHandler.Load().(ConnectContextHandler)
+	connectHandler(ctx, client.ID())
 
-// LogDebugf is like LogDebug but allows specifying a message format.
-func (h *Hub) LogDebugf(format string, args ...interface{}) {
-	h.logger.Debugf(format, args...)
+	return nil
 }
 
-// LogInfof is like LogInfo but allows specifying a message format.
-func (h *Hub) LogInfof(format string, args ...interface{}) {
-	h.logger.Infof(format, args...)
+func (h *Hub) disconnectClient(client WebsocketClient, reason DisconnectReason) error {
+	return h.doDisconnectClient(client, reason, client.Close)
 }
 
-// LogPrintf is like LogPrint but allows specifying a message format.
-func (h *Hub) LogPrintf(format string, args ...interface{}) {
-	h.logger.Printf(format, args...)
-}
+func (h *Hub) doDisconnectClient(client WebsocketClient, reason DisconnectReason, closeFunc func() error) error {
+	var channels []string
+	if h.options.Metrics != nil {
+		channels, _ = h.clients.Channels(client.ID())
+	}
+
+	err := h.clients.Unset(client.ID())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Only the first of concurrent disconnects releases the connection,
+	// so the client is reported as disconnected once
+	released := h.releaseConnection(client.ID())
+
+	if h.options.Metrics != nil && released {
+		h.options.Metrics.ClientDisconnected(reason)
+		h.collectChannelSubscribers(channels)
+	}
+
+	err = closeFunc()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !released {
+		return nil
+	}
+
+	disconnectHandler := h.disconnectHandler.Load().(DisconnectHandler)
+	disconnectHandler(client.ID())
 
-// LogWarnf is like LogWarn but allows specifying a message format.
-func (h *Hub) LogWarnf(format string, args ...interface{}) {
-	h.logger.Warnf(format, args...)
+	return nil
 }
 
-// LogErrorf is like LogError but allows specifying a message format.
-func (h *Hub) LogErrorf(format string, args ...interface{}) {
-	h.logger.Errorf(format, args...)
+func (h *Hub) clientInfo(client WebsocketClient) ClientInfo {
+	info := ClientInfo{
+		ID:         client.ID(),
+		QueueDepth: client.QueueDepth(),
+		RTT:        client.RTT(),
+	}
+
+	info.Channels, _ = h.clients.Channels(client.ID())
+
+	if value, ok := h.connections.Load(client.ID()); ok {
+		connection := value.(*hubConnection)
+		info.RemoteAddr = connection.remoteAddr
+		info.IP = connection.ip
+		info.ConnectedAt = connection.connectedAt
+		info.Identity = connection.Identity()
+	}
+
+	return info
 }
 
-// LogFatalf is like LogFatal but allows specifying a message format.
-func (h *Hub) LogFatalf(format string, args ...interface{}) {
-	h.logger.Fatalf(format, args...)
+// releaseConnection reports false if the connection was already released.
+func (h *Hub) releaseConnection(clientID UUID) bool {
+	value, ok := h.connections.LoadAndDelete(clientID)
+	if !ok {
+		return false
+	}
+
+	connection := value.(*hubConnection)
+	connection.Release()
+	h.connectionLimiter.Release(connection.ip, connection.Identity().ID)
+
+	return true
 }
 
-// LogPanicf is like LogPanic but allows specifying a message format.
-func (h *Hub) LogPanicf(format string, args ...interface{}) {
-	h.logger.Panicf(format, args...)
+func (h *Hub) activateConnection(clientID UUID, connection *hubConnection) {
+	var reauth func(identity Identity)
+	if h.options.Reauthentication.Validator != nil {
+		reauth = func(identity Identity) {
+			message := h.options.Reauthentication.RequestMessage(identity)
+			_ = h.Send(clientID, message)
+		}
+	}
+
+	expiry := func() {
+		client, err := h.clients.Get(clientID)
+		if err != nil {
+			return
+		}
+
+		_ = h.doDisconnectClient(client, DisconnectReasonExpired, func() error {
+			return client.CloseWithCode(CloseCodeCredentialsExpired, "credentials expired")
+		})
+	}
+
+	connection.Activate(h.options.Reauthentication.Advance, reauth, expiry)
 }
 
-func (h *Hub) connectClient(client WebsocketClient, response http.ResponseWriter, request *http.Request) error {
-	h.clients.Set(client)
+// authorize checks access to the channels using authorizers of their namespaces.
+func (h *Hub) authorize(clientID UUID, channels []string) error {
+	var (
+		keys        []string
+		authorizers = make(map[string]Authorizer)
+		groups      = make(map[string][]string)
+	)
+
+	for _, channel := range channels {
+		prefix, policy := h.namespaces.Lookup(channel)
+
+		// Channels of namespaces without own authorizer are checked by the hub one at once
+		key, authorizer := "", h.options.Authorizer
+		if policy.Authorizer != nil {
+			key, authorizer = "namespace:"+prefix, policy.Authorizer
+		}
 
-	err := client.Connect(response, request)
-	if err != nil {
-		_ = h.clients.Unset(client.ID())
+		if authorizer == nil {
+			continue
+		}
+
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			authorizers[key] = authorizer
+		}
+
+		groups[key] = append(groups[key], channel)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
 
+	identity, err := h.Identity(clientID)
+	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	connectHandler := h.connectHandler.Load().(ConnectHandler)
-	connectHandler(client.ID())
+	for _, key := range keys {
+		err = authorizers[key].Authorize(identity, groups[key]...)
+		if err != nil {
+			return errors.WithStack(NewHubAuthorizationError(clientID, channels, err))
+		}
+	}
 
 	return nil
 }
 
-func (h *Hub) disconnectClient(client WebsocketClient) error {
-	err := h.clients.Unset(client.ID())
-	if err != nil {
-		return errors.WithStack(err)
+// subscribe links the client with the channels.
+// Channels limiting subscribers are locked while their limits are checked and the client is linked,
+// so concurrent subscriptions can't exceed the limits.
+func (h *Hub) subscribe(clientID UUID, channels []string) error {
+	var limited []string
+	if !h.namespaces.IsEmpty() {
+		for _, channel := range channels {
+			_, policy := h.namespaces.Lookup(channel)
+			if policy.MaxSubscribers > 0 {
+				limited = append(limited, channel)
+			}
+		}
+	}
+
+	if len(limited) > 0 {
+		unlock := h.subscriberLocks.Lock(limited)
+		defer unlock()
+
+		err := h.checkSubscriberLimits(clientID, limited)
+		if err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
-	err = client.Close()
+	err := h.clients.SetChannels(clientID, channels...)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	disconnectHandler := h.disconnectHandler.Load().(DisconnectHandler)
-	disconnectHandler(client.ID())
+	return nil
+}
+
+// checkSubscriberLimits checks that the client can join the channels
+// without exceeding limits of subscribers of their namespaces.
+func (h *Hub) checkSubscriberLimits(clientID UUID, channels []string) error {
+	var subscribed []string
+	for _, channel := range channels {
+		_, policy := h.namespaces.Lookup(channel)
+		if h.clients.Count(channel) < policy.MaxSubscribers {
+			continue
+		}
+
+		// Repeated subscription doesn't add a subscriber
+		if subscribed == nil {
+			var err error
+			subscribed, err = h.clients.Channels(clientID)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if !slices.Contains(subscribed, channel) {
+			return errors.WithStack(NewHubNamespacePolicyError(clientID, channel, "subscriber limit reached"))
+		}
+	}
 
 	return nil
 }
 
+func (h *Hub) publishScheduled(message Message, channels []string) {
+	_, err := h.Publish(message, channels...)
+	if err != nil {
+		h.logger.Error("Scheduled publishing failed", LogFieldChannels, channels, LogFieldError, err)
+	}
+}
+
+// appendHistory adds the message to histories of the channels which keep it.
+func (h *Hub) appendHistory(message Message, channels []string) {
+	if h.namespaces.IsEmpty() {
+		return
+	}
+
+	for _, channel := range channels {
+		_, policy := h.namespaces.Lookup(channel)
+		if policy.HistorySize == 0 {
+			continue
+		}
+
+		history, _ := h.history.LoadOrStore(channel, &channelHistory{})
+		history.(*channelHistory).Append(Message{Type: message.Type, Payload: message.Payload}, policy.HistorySize)
+	}
+}
+
+// conflationKey returns a key of conflation if namespace policies of all the channels enable it.
+func (h *Hub) conflationKey(channels []string) string {
+	if len(channels) == 0 || h.namespaces.IsEmpty() {
+		return ""
+	}
+
+	for _, channel := range channels {
+		_, policy := h.namespaces.Lookup(channel)
+		if !policy.Conflation {
+			return ""
+		}
+	}
+
+	return strings.Join(channels, "\x00")
+}
+
+func (h *Hub) collectChannelSubscribers(channels []string) {
+	if h.options.Metrics == nil {
+		return
+	}
+
+	for _, channel := range channels {
+		h.options.Metrics.ChannelSubscribers(channel, h.clients.Count(channel))
+	}
+}
+
+func (h *Hub) rejectConnection(response http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if authErr, ok := IsHubAuthenticationError(err); ok {
+		status = authErr.StatusCode
+	}
+
+	if limitErr, ok := IsHubConnectionLimitError(err); ok {
+		status = limitErr.StatusCode
+
+		retryAfter := int(math.Ceil(h.options.ConnectionLimits.RetryAfter.Seconds()))
+		if retryAfter > 0 {
+			response.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+	}
+
+	http.Error(response, http.StatusText(status), status)
+}
+
+func (h *Hub) wrapReceiveHandler(handler ReceiveContextHandler) ReceiveContextHandler {
+	if h.options.Reauthentication.Validator == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, clientID UUID, message Message) {
+		// Refreshed credentials are consumed by the hub
+		// and never reach the receive handler
+		token, ok := h.options.Reauthentication.TokenFunc(message)
+		if !ok {
+			handler(ctx, clientID, message)
+
+			return
+		}
+
+		err := h.Reauthenticate(clientID, token)
+		if err != nil {
+			h.logger.Debug("Client reauthentication failed", LogFieldClientID, clientID, LogFieldError, err)
+		}
+	}
+}
+
 func (h *Hub) wrapErrorHandler(handler ErrorHandler) ErrorHandler {
 	return func(clientID UUID, err error) {
 		handler(clientID, err)
 
+		// A message which can't be decoded is skipped, the connection is still usable
+		if _, ok := IsClientDecodeError(err); ok {
+			return
+		}
+
 		// We should disconnect the client
 		// if it reported (called the error_handler) that
 		// an error has occurred while reading or writing a websocket
-		_ = h.Disconnect(clientID)
+		client, getErr := h.clients.Get(clientID)
+		if getErr != nil {
+			return
+		}
+
+		reason := DisconnectReasonError
+		if _, ok := IsClientRateLimitError(err); ok {
+			reason = DisconnectReasonRateLimit
+		}
+
+		_ = h.disconnectClient(client, reason)
 	}
 }
 
@@ -607,6 +1560,11 @@ func NewHub(
 	clientFactory WebsocketClientFactory,
 	logger Logger,
 ) *Hub {
+	ipExtractor, invalidProxies := newRemoteIPExtractor(options.TrustedProxies)
+	for _, proxy := range invalidProxies {
+		logger.Warn("Invalid trusted proxy is ignored", "proxy", proxy)
+	}
+
 	hub := &Hub{
 		options:       options,
 		clients:       clientStore,
@@ -614,12 +1572,26 @@ func NewHub(
 		logger:        logger,
 		httpServer:    &http.Server{},
 		httpServerTLS: &http.Server{},
+		ipExtractor:   ipExtractor,
+		connectionLimiter: newConnectionLimiter(
+			options.ConnectionLimits.Total,
+			options.ConnectionLimits.PerIP,
+			options.ConnectionLimits.PerIdentity,
+		),
+		tracer:    newTracer(options.TracerProvider),
+		scheduler: newScheduler(options.Clock),
+		recipientsPool: sync.Pool{
+			New: func() interface{} {
+				return &[]WebsocketClient{}
+			},
+		},
 	}
 
 	hub.connectHandler.Store(defaultConnectHandler)
 	hub.disconnectHandler.Store(defaultDisconnectHandler)
-	hub.receiveHandler.Store(defaultReceiveHandler)
+	hub.receiveHandler.Store(hub.wrapReceiveHandler(defaultReceiveContextHandler))
 	hub.errorHandler.Store(hub.wrapErrorHandler(defaultErrorHandler))
+	hub.emptiedHandler.Store(defaultChannelHandler)
 
 	return hub
 }
@@ -0,0 +1,43 @@
+package wspubsub
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// HubAuthenticationError returned when a client can't be authenticated.
+type HubAuthenticationError struct {
+	StatusCode int
+	Err        error
+}
+
+// HubAuthenticationError implements an error interface.
+func (e *HubAuthenticationError) Error() string {
+	return fmt.Sprintf("wspubsub: authentication failed: status=%d, err=%s", e.StatusCode, e.Err)
+}
+
+// NewHubAuthenticationError initializes a new HubAuthenticationError.
+func NewHubAuthenticationError(statusCode int, err error) *HubAuthenticationError {
+	return &HubAuthenticationError{StatusCode: statusCode, Err: err}
+}
+
+// NewHubUnauthorizedError initializes a new HubAuthenticationError
+// for a client who didn't provide valid credentials.
+func NewHubUnauthorizedError(err error) *HubAuthenticationError {
+	return NewHubAuthenticationError(http.StatusUnauthorized, err)
+}
+
+// NewHubForbiddenError initializes a new HubAuthenticationError
+// for a client who isn't allowed to connect.
+func NewHubForbiddenError(err error) *HubAuthenticationError {
+	return NewHubAuthenticationError(http.StatusForbidden, err)
+}
+
+// IsHubAuthenticationError checks if error type is HubAuthenticationError.
+func IsHubAuthenticationError(err error) (*HubAuthenticationError, bool) {
+	v, ok := errors.Cause(err).(*HubAuthenticationError)
+
+	return v, ok
+}
@@ -0,0 +1,29 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubAuthenticationError(t *testing.T) {
+	rawErr := errors.New("TEST")
+	err := wspubsub.NewHubAuthenticationError(http.StatusForbidden, rawErr)
+	require.Equal(t, http.StatusForbidden, err.StatusCode)
+	require.Equal(t, rawErr, err.Err)
+	require.NotEmpty(t, err.Error())
+
+	require.Equal(t, http.StatusUnauthorized, wspubsub.NewHubUnauthorizedError(rawErr).StatusCode)
+	require.Equal(t, http.StatusForbidden, wspubsub.NewHubForbiddenError(rawErr).StatusCode)
+
+	e, ok := wspubsub.IsHubAuthenticationError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsHubAuthenticationError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
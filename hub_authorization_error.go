@@ -0,0 +1,37 @@
+package wspubsub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HubAuthorizationError returned when a client isn't allowed to subscribe to the channels.
+type HubAuthorizationError struct {
+	ID       UUID
+	Channels []string
+	Err      error
+}
+
+// HubAuthorizationError implements an error interface.
+func (e *HubAuthorizationError) Error() string {
+	return fmt.Sprintf(
+		"wspubsub: client is not authorized: id=%s, channels=[%s], err=%s",
+		e.ID,
+		strings.Join(e.Channels, ","),
+		e.Err,
+	)
+}
+
+// NewHubAuthorizationError initializes a new HubAuthorizationError.
+func NewHubAuthorizationError(id UUID, channels []string, err error) *HubAuthorizationError {
+	return &HubAuthorizationError{ID: id, Channels: channels, Err: err}
+}
+
+// IsHubAuthorizationError checks if error type is HubAuthorizationError.
+func IsHubAuthorizationError(err error) (*HubAuthorizationError, bool) {
+	v, ok := errors.Cause(err).(*HubAuthorizationError)
+
+	return v, ok
+}
@@ -0,0 +1,27 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubAuthorizationError(t *testing.T) {
+	channels := []string{"X", "Y"}
+	rawErr := errors.New("TEST")
+	err := wspubsub.NewHubAuthorizationError(clientID, channels, rawErr)
+	require.Equal(t, clientID, err.ID)
+	require.Equal(t, channels, err.Channels)
+	require.Equal(t, rawErr, err.Err)
+	require.NotEmpty(t, err.Error())
+
+	e, ok := wspubsub.IsHubAuthorizationError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsHubAuthorizationError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -0,0 +1,29 @@
+package wspubsub
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// HubClosedError returned when trying to schedule publishing on a closed hub.
+type HubClosedError struct {
+	message string
+}
+
+// HubClosedError implements an error interface.
+func (e *HubClosedError) Error() string {
+	return fmt.Sprintf("wspubsub: %s", e.message)
+}
+
+// NewHubClosedError initializes a new HubClosedError.
+func NewHubClosedError() *HubClosedError {
+	return &HubClosedError{message: "hub is closed"}
+}
+
+// IsHubClosedError checks if error type is HubClosedError.
+func IsHubClosedError(err error) (*HubClosedError, bool) {
+	v, ok := errors.Cause(err).(*HubClosedError)
+
+	return v, ok
+}
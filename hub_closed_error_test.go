@@ -0,0 +1,23 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubClosedError(t *testing.T) {
+	rawErr := errors.New("TEST")
+	err := wspubsub.NewHubClosedError()
+	require.NotEmpty(t, clientID, err.Error())
+
+	e, ok := wspubsub.IsHubClosedError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsHubClosedError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
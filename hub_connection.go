@@ -0,0 +1,105 @@
+package wspubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// hubConnection holds the hub-side state of a connected client.
+type hubConnection struct {
+	ip          string
+	remoteAddr  string
+	connectedAt time.Time
+	mu          sync.Mutex
+	identity    Identity
+	isActive    bool
+	isReleased  bool
+	advance     time.Duration
+	reauth      func(identity Identity)
+	expiry      func()
+	reauthTimer *time.Timer
+	expiryTimer *time.Timer
+}
+
+// Identity returns the current identity of the client.
+func (c *hubConnection) Identity() Identity {
+	c.mu.Lock()
+	identity := c.identity
+	c.mu.Unlock()
+
+	return identity
+}
+
+// SetIdentity replaces the identity of the client and reschedules expiry callbacks.
+// It reports false if the connection is already released.
+func (c *hubConnection) SetIdentity(identity Identity) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isReleased {
+		return false
+	}
+
+	c.identity = identity
+	c.schedule()
+
+	return true
+}
+
+// Activate schedules expiry callbacks once the client is connected,
+// so the expiry callback is always able to find the client to disconnect it.
+// The reauth callback is called the advance duration before the credentials expire
+// and the expiry callback is called when they are expired.
+func (c *hubConnection) Activate(advance time.Duration, reauth func(identity Identity), expiry func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isReleased {
+		return
+	}
+
+	c.isActive = true
+	c.advance = advance
+	c.reauth = reauth
+	c.expiry = expiry
+	c.schedule()
+}
+
+// Release stops expiry callbacks, so they are never scheduled again.
+func (c *hubConnection) Release() {
+	c.mu.Lock()
+	c.isReleased = true
+	c.stopTimers()
+	c.mu.Unlock()
+}
+
+func (c *hubConnection) schedule() {
+	c.stopTimers()
+
+	if !c.isActive || c.identity.ExpiresAt.IsZero() {
+		return
+	}
+
+	identity := c.identity
+	ttl := time.Until(identity.ExpiresAt)
+	if c.reauth != nil {
+		reauth := c.reauth
+		c.reauthTimer = time.AfterFunc(ttl-c.advance, func() {
+			reauth(identity)
+		})
+	}
+
+	c.expiryTimer = time.AfterFunc(ttl, c.expiry)
+}
+
+func (c *hubConnection) stopTimers() {
+	if c.reauthTimer != nil {
+		c.reauthTimer.Stop()
+		c.reauthTimer = nil
+	}
+
+	if c.expiryTimer != nil {
+		c.expiryTimer.Stop()
+		c.expiryTimer = nil
+	}
+}
@@ -0,0 +1,54 @@
+package wspubsub
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ConnectionLimitScope enumerates possible scopes of connection limits.
+type ConnectionLimitScope string
+
+const (
+	// ConnectionLimitScopeTotal limits the total number of connections.
+	ConnectionLimitScopeTotal ConnectionLimitScope = "total"
+
+	// ConnectionLimitScopeIP limits the number of connections from a single IP address.
+	ConnectionLimitScopeIP ConnectionLimitScope = "ip"
+
+	// ConnectionLimitScopeIdentity limits the number of connections of a single identity.
+	ConnectionLimitScopeIdentity ConnectionLimitScope = "identity"
+)
+
+// HubConnectionLimitError returned when a new connection exceeds one of the connection limits.
+type HubConnectionLimitError struct {
+	Scope ConnectionLimitScope
+	Limit int
+
+	// HTTP status code which should be used to reject the connection
+	StatusCode int
+}
+
+// HubConnectionLimitError implements an error interface.
+func (e *HubConnectionLimitError) Error() string {
+	return fmt.Sprintf("wspubsub: connection limit exceeded: scope=%s, limit=%d", e.Scope, e.Limit)
+}
+
+// NewHubConnectionLimitError initializes a new HubConnectionLimitError.
+// The total limit is rejected as an unavailable service, other limits as too many requests.
+func NewHubConnectionLimitError(scope ConnectionLimitScope, limit int) *HubConnectionLimitError {
+	statusCode := http.StatusTooManyRequests
+	if scope == ConnectionLimitScopeTotal {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	return &HubConnectionLimitError{Scope: scope, Limit: limit, StatusCode: statusCode}
+}
+
+// IsHubConnectionLimitError checks if error type is HubConnectionLimitError.
+func IsHubConnectionLimitError(err error) (*HubConnectionLimitError, bool) {
+	v, ok := errors.Cause(err).(*HubConnectionLimitError)
+
+	return v, ok
+}
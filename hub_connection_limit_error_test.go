@@ -0,0 +1,30 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubConnectionLimitError(t *testing.T) {
+	rawErr := errors.New("TEST")
+	err := wspubsub.NewHubConnectionLimitError(wspubsub.ConnectionLimitScopeIP, 10)
+	require.Equal(t, wspubsub.ConnectionLimitScopeIP, err.Scope)
+	require.Equal(t, 10, err.Limit)
+	require.Equal(t, http.StatusTooManyRequests, err.StatusCode)
+	require.NotEmpty(t, err.Error())
+
+	err = wspubsub.NewHubConnectionLimitError(wspubsub.ConnectionLimitScopeTotal, 10)
+	require.Equal(t, http.StatusServiceUnavailable, err.StatusCode)
+
+	e, ok := wspubsub.IsHubConnectionLimitError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsHubConnectionLimitError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -0,0 +1,31 @@
+package wspubsub
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// HubNamespacePolicyError returned when an operation on a channel is forbidden by its namespace policy.
+type HubNamespacePolicyError struct {
+	ID      UUID
+	Channel string
+	Reason  string
+}
+
+// HubNamespacePolicyError implements an error interface.
+func (e *HubNamespacePolicyError) Error() string {
+	return fmt.Sprintf("wspubsub: namespace policy violation: id=%s, channel=%s, reason=%s", e.ID, e.Channel, e.Reason)
+}
+
+// NewHubNamespacePolicyError initializes a new HubNamespacePolicyError.
+func NewHubNamespacePolicyError(id UUID, channel string, reason string) *HubNamespacePolicyError {
+	return &HubNamespacePolicyError{ID: id, Channel: channel, Reason: reason}
+}
+
+// IsHubNamespacePolicyError checks if error type is HubNamespacePolicyError.
+func IsHubNamespacePolicyError(err error) (*HubNamespacePolicyError, bool) {
+	v, ok := errors.Cause(err).(*HubNamespacePolicyError)
+
+	return v, ok
+}
@@ -0,0 +1,25 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubNamespacePolicyError(t *testing.T) {
+	err := wspubsub.NewHubNamespacePolicyError(clientID, "chat:X", "TEST")
+	require.Equal(t, clientID, err.ID)
+	require.Equal(t, "chat:X", err.Channel)
+	require.Equal(t, "TEST", err.Reason)
+	require.NotEmpty(t, err.Error())
+
+	e, ok := wspubsub.IsHubNamespacePolicyError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsHubNamespacePolicyError(errors.New("TEST"))
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -1,7 +1,11 @@
 package wspubsub
 
 import (
+	"bytes"
+	"encoding/json"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HubOptions represents configuration of the hub.
@@ -9,20 +13,118 @@ type HubOptions struct {
 	// Time to gracefully shutdown a server
 	ShutdownTimeout time.Duration
 
-	// Enable/disable debug mode.
-	IsDebug bool
+	// Limits of simultaneously connected clients.
+	// Exceeding them will cause rejecting of a connection upgrade.
+	ConnectionLimits struct {
+		// Total number of connections (zero means unlimited)
+		Total int
+
+		// Number of connections from a single IP address (zero means unlimited)
+		PerIP int
+
+		// Number of connections of a single identity (zero means unlimited)
+		PerIdentity int
+
+		// Value of the Retry-After header sent with a rejected upgrade
+		RetryAfter time.Duration
+	}
+
+	// Proxies (IP addresses or networks in CIDR notation) which are trusted
+	// to set X-Forwarded-For and X-Real-IP headers.
+	// X-Real-IP is used only if the request has no X-Forwarded-For header.
+	TrustedProxies []string
+
+	// Authenticates a client before upgrading its connection (nil disables authentication).
+	// The resulting identity is used by the per-identity connection limit.
+	Authenticator Authenticator
+
+	// Grants access to channels on subscription (nil allows all channels).
+	Authorizer Authorizer
+
+	// In-band refreshing of credentials which expire while a client stays connected.
+	// Clients are disconnected with CloseCodeCredentialsExpired once their identity expires.
+	Reauthentication struct {
+		// Validates a refreshed token (nil disables refreshing)
+		Validator TokenValidator
+
+		// How long before the expiry the client is asked to refresh credentials
+		Advance time.Duration
+
+		// Creates a message asking the client to refresh credentials
+		RequestMessage func(identity Identity) Message
+
+		// Extracts a refreshed token from a received message.
+		// Messages without a token are passed to the receive handler.
+		TokenFunc func(message Message) (string, bool)
+	}
+
+	// Encodes values published by PublishValue and decodes messages
+	// received by handlers registered with OnReceiveValue.
+	Codec Codec
+
+	// Provides time to the scheduler of delayed and periodic publishing.
+	Clock Clock
+
+	// Collects metrics of the hub (nil disables collecting).
+	Metrics MetricsCollector
 
-	// Function execution time limit in debug mode.
-	// Exceeding this time limit will cause a new warn log message.
-	DebugFuncTimeLimit time.Duration
+	// Provides a tracer of publishing (nil means the global provider).
+	TracerProvider trace.TracerProvider
+
+	// Carries a trace context of publishing inside published messages (nil disables propagation).
+	// The context is injected once per publishing, so all the recipients get the same payload.
+	MessagePropagator MessagePropagator
+
+	// Observes operations (nil disables observing).
+	Observer Observer
 }
 
 // NewHubOptions initializes a new HubOptions.
 // nolint: gomnd
 func NewHubOptions() HubOptions {
-	return HubOptions{
-		ShutdownTimeout:    10 * time.Second,
-		IsDebug:            false,
-		DebugFuncTimeLimit: 1 * time.Millisecond,
+	options := HubOptions{
+		ShutdownTimeout: 10 * time.Second,
+		Codec:           JSONCodec{},
+		Clock:           SystemClock{},
 	}
+
+	options.ConnectionLimits.RetryAfter = 5 * time.Second
+
+	options.Reauthentication.Advance = 30 * time.Second
+	options.Reauthentication.RequestMessage = newReauthRequestMessage
+	options.Reauthentication.TokenFunc = parseReauthToken
+
+	return options
+}
+
+// newReauthRequestMessage creates a message like:
+// {"type":"reauth_required","expires_at":"2020-01-01T00:00:00Z"}.
+func newReauthRequestMessage(identity Identity) Message {
+	payload, _ := json.Marshal(struct {
+		Type      string    `json:"type"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{Type: "reauth_required", ExpiresAt: identity.ExpiresAt})
+
+	return NewTextMessage(payload)
+}
+
+// parseReauthToken extracts a token from a message like:
+// {"type":"reauth","token":"..."}.
+func parseReauthToken(message Message) (string, bool) {
+	// Cheap check to avoid unmarshalling of regular messages
+	if !bytes.Contains(message.Payload, []byte(`"reauth"`)) {
+		return "", false
+	}
+
+	m := struct {
+		Type  string `json:"type"`
+		Token string `json:"token"`
+	}{}
+
+	err := json.Unmarshal(message.Payload, &m)
+	if err != nil || m.Type != "reauth" {
+		return "", false
+	}
+
+	return m.Token, true
 }
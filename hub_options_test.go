@@ -10,6 +10,24 @@ import (
 func TestNewHubOptions(t *testing.T) {
 	options := wspubsub.NewHubOptions()
 	require.NotZero(t, options.ShutdownTimeout)
-	require.False(t, options.IsDebug)
-	require.NotZero(t, options.DebugFuncTimeLimit)
+	require.Zero(t, options.ConnectionLimits.Total)
+	require.Zero(t, options.ConnectionLimits.PerIP)
+	require.Zero(t, options.ConnectionLimits.PerIdentity)
+	require.NotZero(t, options.ConnectionLimits.RetryAfter)
+	require.Empty(t, options.TrustedProxies)
+	require.Nil(t, options.Authenticator)
+	require.Nil(t, options.Authorizer)
+	require.Nil(t, options.Reauthentication.Validator)
+	require.NotZero(t, options.Reauthentication.Advance)
+	require.NotEmpty(t, options.Reauthentication.RequestMessage(wspubsub.Identity{}).Payload)
+
+	token, ok := options.Reauthentication.TokenFunc(wspubsub.NewTextMessageFromString(`{"type":"reauth","token":"TEST"}`))
+	require.True(t, ok)
+	require.Equal(t, "TEST", token)
+
+	_, ok = options.Reauthentication.TokenFunc(wspubsub.NewTextMessageFromString(`{"type":"message"}`))
+	require.False(t, ok)
+	require.Equal(t, wspubsub.JSONCodec{}, options.Codec)
+	require.Equal(t, wspubsub.SystemClock{}, options.Clock)
+	require.Nil(t, options.Observer)
 }
@@ -0,0 +1,29 @@
+package wspubsub
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// HubScheduleError returned when scheduled publishing can't be created from the given arguments.
+type HubScheduleError struct {
+	Reason string
+}
+
+// HubScheduleError implements an error interface.
+func (e *HubScheduleError) Error() string {
+	return fmt.Sprintf("wspubsub: invalid schedule: reason=%s", e.Reason)
+}
+
+// NewHubScheduleError initializes a new HubScheduleError.
+func NewHubScheduleError(reason string) *HubScheduleError {
+	return &HubScheduleError{Reason: reason}
+}
+
+// IsHubScheduleError checks if error type is HubScheduleError.
+func IsHubScheduleError(err error) (*HubScheduleError, bool) {
+	v, ok := errors.Cause(err).(*HubScheduleError)
+
+	return v, ok
+}
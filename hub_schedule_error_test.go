@@ -0,0 +1,23 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubScheduleError(t *testing.T) {
+	err := wspubsub.NewHubScheduleError("TEST")
+	require.Equal(t, "TEST", err.Reason)
+	require.NotEmpty(t, err.Error())
+
+	e, ok := wspubsub.IsHubScheduleError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsub.IsHubScheduleError(errors.New("TEST"))
+	require.Nil(t, e)
+	require.False(t, ok)
+}
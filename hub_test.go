@@ -1,17 +1,24 @@
 package wspubsub_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kpeu3i/wspubsub"
 	"github.com/kpeu3i/wspubsub/mock"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestHub_Subscription(t *testing.T) {
@@ -19,6 +26,11 @@ func TestHub_Subscription(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 
@@ -107,6 +119,11 @@ func TestHub_Channels(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 
@@ -148,6 +165,11 @@ func TestHub_Count(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 
@@ -172,6 +194,11 @@ func TestHub_Publish(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 	client := mock.NewMockWebsocketClient(ctrl)
@@ -244,11 +271,215 @@ func TestHub_Publish(t *testing.T) {
 	})
 }
 
+func TestHub_PublishToSeveralChannels(t *testing.T) {
+	harness := wspubsubtest.NewHarness(t, wspubsubtest.NewHarnessOptions())
+	hub := harness.Hub()
+
+	client := harness.Connect()
+	err := hub.Subscribe(client.ID(), "X", "Y")
+	require.NoError(t, err)
+
+	numClients, err := hub.Publish(wspubsub.NewTextMessageFromString("TEST"), "X", "Y")
+	require.NoError(t, err)
+	require.Equal(t, 1, numClients)
+
+	client.ExpectText("TEST")
+	client.ExpectNothing(10 * time.Millisecond)
+}
+
+func TestHub_PublishWithReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	enqueuedClient := mock.NewMockWebsocketClient(ctrl)
+	overflowedClient := mock.NewMockWebsocketClient(ctrl)
+	goneClient := mock.NewMockWebsocketClient(ctrl)
+	failedClient := mock.NewMockWebsocketClient(ctrl)
+
+	enqueuedID := wspubsubtest.SequentialUUID(1)
+	overflowedID := wspubsubtest.SequentialUUID(2)
+	goneID := wspubsubtest.SequentialUUID(3)
+	failedID := wspubsubtest.SequentialUUID(4)
+	message := wspubsub.NewTextMessageFromString("TEST")
+
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Eq("X")).
+		Times(2).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			for _, client := range []wspubsub.WebsocketClient{enqueuedClient, overflowedClient, goneClient, failedClient} {
+				err := fn(client)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+	enqueuedClient.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(enqueuedID)
+
+	enqueuedClient.
+		EXPECT().
+		Send(gomock.Any()).
+		Times(1)
+
+	overflowedClient.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(overflowedID)
+
+	// The overflowing client mustn't be disconnected
+	overflowedClient.
+		EXPECT().
+		Send(gomock.Any()).
+		Times(1).
+		Return(wspubsub.NewClientSendBufferOverflowError(overflowedID))
+
+	goneClient.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(goneID)
+
+	// The gone client mustn't be disconnected either
+	goneClient.
+		EXPECT().
+		Send(gomock.Any()).
+		Times(1).
+		Return(wspubsub.NewClientClosedError(goneID))
+
+	failedClient.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(failedID)
+
+	// A failure isn't mistaken for a gone client
+	failedClient.
+		EXPECT().
+		Send(gomock.Any()).
+		Times(1).
+		Return(errors.New("TEST"))
+
+	// The publishing with done context isn't measured
+	metrics := mock.NewMockMetricsCollector(ctrl)
+	metrics.
+		EXPECT().
+		MessagePublished(gomock.Eq(1), gomock.Eq(len(message.Payload)), gomock.Any()).
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.Metrics = metrics
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+	clientStore.
+		EXPECT().
+		OnChannelEmptied(gomock.Any()).
+		Times(1)
+
+	hub.RegisterNamespace("X", wspubsub.NamespacePolicy{HistorySize: 10})
+
+	t.Run("Publishing message success", func(t *testing.T) {
+		report, err := hub.PublishWithReport(context.Background(), message, "X")
+		require.NoError(t, err)
+		require.Equal(t, []wspubsub.UUID{enqueuedID}, report.Enqueued)
+		require.Equal(t, []wspubsub.UUID{overflowedID}, report.Overflowed)
+		require.Equal(t, []wspubsub.UUID{goneID}, report.Gone)
+		require.Equal(t, []wspubsub.UUID{failedID}, report.Failed)
+		require.Empty(t, report.Skipped)
+		require.False(t, report.Delivered())
+
+		history, err := hub.History("X")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+	})
+
+	t.Run("Publishing message with done context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report, err := hub.PublishWithReport(ctx, message, "X")
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Empty(t, report.Enqueued)
+		require.Equal(t, []wspubsub.UUID{enqueuedID, overflowedID, goneID, failedID}, report.Skipped)
+
+		history, err := hub.History("X")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+	})
+}
+
+func TestHub_PublishValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Eq("X")).
+		Times(1).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			return fn(client)
+		})
+
+	client.
+		EXPECT().
+		Send(gomock.Eq(wspubsub.NewTextMessageFromString(`{"name":"TEST","count":1}`))).
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	t.Run("Publishing value success", func(t *testing.T) {
+		numClients, err := hub.PublishValue(codecValue{Name: "TEST", Count: 1}, "X")
+		require.NoError(t, err)
+		require.Equal(t, 1, numClients)
+	})
+
+	t.Run("Publishing value error", func(t *testing.T) {
+		numClients, err := hub.PublishValue(make(chan int), "X")
+		require.Error(t, err)
+		require.Equal(t, 0, numClients)
+	})
+}
+
 func TestHub_Send(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 	client := mock.NewMockWebsocketClient(ctrl)
@@ -315,6 +546,11 @@ func TestHub_Disconnect(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 	client := mock.NewMockWebsocketClient(ctrl)
@@ -416,10 +652,14 @@ func TestHub_ConnectSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
 
 	logger.
 		EXPECT().
-		Infof(gomock.Any(), gomock.Any()).
+		Info(gomock.Any(), gomock.Any()).
 		AnyTimes()
 
 	logger.
@@ -502,10 +742,19 @@ func TestHub_ConnectError(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
 
 	logger.
 		EXPECT().
-		Infof(gomock.Any(), gomock.Any()).
+		Error(gomock.Eq("Connection upgrade failed"), gomock.Any()).
+		Times(1)
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
 		AnyTimes()
 
 	logger.
@@ -593,10 +842,14 @@ func TestHub_ErrorHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
 
 	logger.
 		EXPECT().
-		Infof(gomock.Any(), gomock.Any()).
+		Info(gomock.Any(), gomock.Any()).
 		AnyTimes()
 
 	logger.
@@ -677,32 +930,42 @@ func TestHub_ErrorHandler(t *testing.T) {
 	require.Equal(t, http.StatusOK, response.Result().StatusCode)
 }
 
-func TestHub_Close(t *testing.T) {
+func TestHub_OnReceiveValue(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	logger := mock.NewMockLogger(ctrl)
-
 	logger.
 		EXPECT().
-		Infof(gomock.Any(), gomock.Any()).
+		Debug(gomock.Any(), gomock.Any()).
 		AnyTimes()
 
 	logger.
 		EXPECT().
-		Info(gomock.Any()).
+		Info(gomock.Any(), gomock.Any()).
 		AnyTimes()
 
 	clientStore := mock.NewMockWebsocketClientStore(ctrl)
 	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
 	client := mock.NewMockWebsocketClient(ctrl)
 
-	hubOptions := wspubsub.NewHubOptions()
-	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
 
-	findErrText := "find_error"
+	var receiveHandler wspubsub.ReceiveHandler
+	invalidMessage := wspubsub.NewTextMessageFromString("{")
+
+	clientFactory.
+		EXPECT().
+		Create().
+		Times(1).
+		Return(client)
+
+	clientStore.
+		EXPECT().
+		Set(gomock.Eq(client)).
+		Times(1)
 
-	// *** Success case
 	client.
 		EXPECT().
 		ID().
@@ -711,106 +974,1552 @@ func TestHub_Close(t *testing.T) {
 
 	client.
 		EXPECT().
-		Close().
+		OnReceive(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(handler wspubsub.ReceiveHandler) {
+			// Just remember the receive handler to call it later
+			receiveHandler = handler
+		})
+
+	client.
+		EXPECT().
+		OnError(gomock.Any()).
 		Times(1)
 
-	clientStore.
+	client.
 		EXPECT().
-		Unset(gomock.Eq(clientID)).
+		Connect(gomock.Eq(response), gomock.Eq(request)).
 		Times(1)
 
-	clientStore.
+	// The client isn't disconnected on a decode error
+	client.
 		EXPECT().
-		Find(gomock.Any(), gomock.Eq([]string{})).
-		Times(1).
-		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
-			err := fn(client)
-			require.NoError(t, err)
+		Close().
+		Times(0)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	var values []codecValue
+	wspubsub.OnReceiveValue(hub, func(cid wspubsub.UUID, v codecValue) {
+		require.Equal(t, clientID, cid)
+		values = append(values, v)
+	})
+
+	var errs []error
+	hub.OnError(func(cid wspubsub.UUID, err error) {
+		errs = append(errs, err)
+	})
+
+	hub.ServeHTTP(response, request)
+
+	receiveHandler(clientID, wspubsub.NewTextMessageFromString(`{"name":"TEST","count":1}`))
+	require.Equal(t, []codecValue{{Name: "TEST", Count: 1}}, values)
+	require.Empty(t, errs)
+
+	receiveHandler(clientID, invalidMessage)
+	require.Len(t, values, 1)
+	require.Len(t, errs, 1)
+
+	decodeErr, ok := wspubsub.IsClientDecodeError(errs[0])
+	require.True(t, ok)
+	require.Equal(t, clientID, decodeErr.ID)
+	require.Equal(t, invalidMessage, decodeErr.Message)
+
+	receiveHandler(clientID, wspubsub.NewTextMessageFromString(`{"name":"TEST","count":2}`))
+	require.Len(t, values, 2)
+	require.Len(t, errs, 1)
+}
+
+func TestHub_Namespaces(t *testing.T) {
+	var authorizedChannels [][]string
+	harnessOptions := wspubsubtest.NewHarnessOptions()
+	harnessOptions.HubOptions.Authorizer = wspubsub.AuthorizerFunc(func(identity wspubsub.Identity, channels ...string) error {
+		authorizedChannels = append(authorizedChannels, channels)
+
+		return nil
+	})
+
+	harness := wspubsubtest.NewHarness(t, harnessOptions)
+	hub := harness.Hub()
+
+	chatPolicy := wspubsub.NamespacePolicy{
+		Authorizer: wspubsub.AuthorizerFunc(func(identity wspubsub.Identity, channels ...string) error {
+			for _, channel := range channels {
+				if channel == "chat:secret" {
+					return errors.New("forbidden")
+				}
+			}
 
 			return nil
-		})
+		}),
+		HistorySize:    2,
+		MaxSubscribers: 1,
+	}
+	hub.RegisterNamespace("chat:", chatPolicy)
+	hub.RegisterNamespace("chat:public:", wspubsub.NewNamespacePolicy())
 
-	// *** Error case
-	clientStore.
-		EXPECT().
-		Find(gomock.Any(), gomock.Eq([]string{})).
-		Times(1).
-		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
-			return errors.New(findErrText)
-		})
+	client1 := harness.Connect()
+	client2 := harness.Connect()
 
-	t.Run("Closing success", func(t *testing.T) {
-		err := hub.Close()
+	t.Run("Authorization", func(t *testing.T) {
+		err := hub.Subscribe(client1.ID(), "chat:secret")
+		_, ok := wspubsub.IsHubAuthorizationError(err)
+		require.True(t, ok)
+
+		err = hub.Subscribe(client1.ID(), "chat:X", "news")
 		require.NoError(t, err)
+		require.Equal(t, [][]string{{"news"}}, authorizedChannels)
 	})
 
-	t.Run("Closing error", func(t *testing.T) {
-		err := hub.Close()
-		require.Error(t, err)
-		require.True(t, strings.Contains(err.Error(), findErrText))
+	t.Run("Subscriber limit", func(t *testing.T) {
+		err := hub.Subscribe(client2.ID(), "chat:X")
+		policyErr, ok := wspubsub.IsHubNamespacePolicyError(err)
+		require.True(t, ok)
+		require.Equal(t, "chat:X", policyErr.Channel)
+
+		err = hub.Subscribe(client1.ID(), "chat:X")
+		require.NoError(t, err)
+
+		err = hub.Subscribe(client2.ID(), "chat:public:X")
+		require.NoError(t, err)
+
+		err = hub.Subscribe(client1.ID(), "chat:public:X")
+		require.NoError(t, err)
+		require.Equal(t, 2, hub.Count("chat:public:X"))
 	})
-}
 
-func TestHub_Logging(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	t.Run("Presence", func(t *testing.T) {
+		_, err := hub.Presence("chat:X")
+		_, ok := wspubsub.IsHubNamespacePolicyError(err)
+		require.True(t, ok)
 
-	logger := mock.NewMockLogger(ctrl)
+		clientIDs, err := hub.Presence("news")
+		require.NoError(t, err)
+		require.Equal(t, []wspubsub.UUID{client1.ID()}, clientIDs)
+	})
 
-	format := "log_format"
-	message := "log_message"
+	t.Run("History", func(t *testing.T) {
+		messages, err := hub.History("chat:Y")
+		require.NoError(t, err)
+		require.Empty(t, messages)
 
-	logger.EXPECT().Debug(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Info(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Print(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Warn(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Error(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Fatal(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Panic(gomock.Eq(message)).Times(1)
-
-	logger.EXPECT().Debugln(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Infoln(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Println(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Warnln(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Errorln(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Fatalln(gomock.Eq(message)).Times(1)
-	logger.EXPECT().Panicln(gomock.Eq(message)).Times(1)
-
-	logger.EXPECT().Debugf(gomock.Eq(format), gomock.Eq(message)).Times(1)
-	logger.EXPECT().Infof(gomock.Eq(format), gomock.Eq(message)).Times(1)
-	logger.EXPECT().Printf(gomock.Eq(format), gomock.Eq(message)).Times(1)
-	logger.EXPECT().Warnf(gomock.Eq(format), gomock.Eq(message)).Times(1)
-	logger.EXPECT().Errorf(gomock.Eq(format), gomock.Eq(message)).Times(1)
-	logger.EXPECT().Fatalf(gomock.Eq(format), gomock.Eq(message)).Times(1)
-	logger.EXPECT().Panicf(gomock.Eq(format), gomock.Eq(message)).Times(1)
+		for _, payload := range []string{"1", "2", "3"} {
+			_, err = hub.Publish(wspubsub.NewTextMessageFromString(payload), "chat:X")
+			require.NoError(t, err)
+		}
 
-	clientStore := mock.NewMockWebsocketClientStore(ctrl)
-	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+		client1.ExpectText("1", "2", "3")
 
-	hubOptions := wspubsub.NewHubOptions()
-	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+		messages, err = hub.History("chat:X")
+		require.NoError(t, err)
+		require.Equal(t, []wspubsub.Message{
+			wspubsub.NewTextMessageFromString("2"),
+			wspubsub.NewTextMessageFromString("3"),
+		}, messages)
+
+		_, err = hub.History("news")
+		_, ok := wspubsub.IsHubNamespacePolicyError(err)
+		require.True(t, ok)
+	})
+
+	t.Run("History reclaim", func(t *testing.T) {
+		err := hub.Unsubscribe(client1.ID(), "chat:X")
+		require.NoError(t, err)
+
+		messages, err := hub.History("chat:X")
+		require.NoError(t, err)
+		require.Empty(t, messages)
+
+		err = hub.Subscribe(client1.ID(), "chat:X")
+		require.NoError(t, err)
+	})
+
+	t.Run("Client publishing", func(t *testing.T) {
+		_, err := hub.PublishFromClient(client2.ID(), wspubsub.NewTextMessageFromString("TEST"), "news", "chat:X")
+		_, ok := wspubsub.IsHubNamespacePolicyError(err)
+		require.True(t, ok)
+
+		_, err = hub.PublishFromClient(client2.ID(), wspubsub.NewTextMessageFromString("TEST"))
+		_, ok = wspubsub.IsHubSubscriptionChannelRequiredError(err)
+		require.True(t, ok)
+
+		numClients, err := hub.PublishFromClient(client2.ID(), wspubsub.NewTextMessageFromString("TEST"), "news")
+		require.NoError(t, err)
+		require.Equal(t, 1, numClients)
+		client1.ExpectText("TEST")
+	})
+}
+
+func TestHub_NamespaceSubscriberLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+
+	var numSubscribers atomic.Int32
+
+	// The slow counting widens the window between checking the limit and linking the client
+	clientStore.
+		EXPECT().
+		Count(gomock.Eq("room:1")).
+		AnyTimes().
+		DoAndReturn(func(channels ...string) int {
+			count := numSubscribers.Load()
+			time.Sleep(10 * time.Millisecond)
+
+			return int(count)
+		})
+
+	clientStore.
+		EXPECT().
+		Channels(gomock.Any()).
+		AnyTimes().
+		Return(nil, nil)
+
+	clientStore.
+		EXPECT().
+		SetChannels(gomock.Any(), gomock.Eq("room:1")).
+		AnyTimes().
+		DoAndReturn(func(clientID wspubsub.UUID, channels ...string) error {
+			numSubscribers.Add(1)
+
+			return nil
+		})
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	maxSubscribers := 5
+	hub.RegisterNamespace("room:", wspubsub.NamespacePolicy{MaxSubscribers: maxSubscribers})
+
+	var (
+		wg       sync.WaitGroup
+		rejected atomic.Int32
+		start    = make(chan struct{})
+	)
+
+	numClients := 20
+	for i := 0; i < numClients; i++ {
+		wg.Add(1)
+		go func(clientID wspubsub.UUID) {
+			defer wg.Done()
+
+			<-start
+			err := hub.Subscribe(clientID, "room:1")
+			if _, ok := wspubsub.IsHubNamespacePolicyError(err); ok {
+				rejected.Add(1)
+			}
+		}(wspubsubtest.SequentialUUID(uint64(i + 1)))
+	}
+
+	close(start)
+	wg.Wait()
+
+	require.Equal(t, int32(maxSubscribers), numSubscribers.Load())
+	require.Equal(t, int32(numClients-maxSubscribers), rejected.Load())
+}
+
+func TestHub_NamespaceConflation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	// The client isn't connected, so published messages stay in its send buffer
+	client := wspubsub.NewClient(wspubsub.NewClientOptions(), clientID, upgrader, nil)
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientStore.Set(client)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	policy := wspubsub.NewNamespacePolicy()
+	policy.Conflation = true
+	hub.RegisterNamespace("prices:", policy)
+
+	err := hub.Subscribe(clientID, "prices:X", "news")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		numClients, err := hub.Publish(wspubsub.NewTextMessageFromString("TEST"), "prices:X")
+		require.NoError(t, err)
+		require.Equal(t, 1, numClients)
+	}
+
+	require.Equal(t, 1, client.QueueDepth())
+
+	for i := 0; i < 3; i++ {
+		_, err = hub.Publish(wspubsub.NewTextMessageFromString("TEST"), "news")
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 4, client.QueueDepth())
+}
+
+func TestHub_PublishAt(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := wspubsubtest.NewManualClock(start)
+
+	harnessOptions := wspubsubtest.NewHarnessOptions()
+	harnessOptions.HubOptions.Clock = clock
+	harness := wspubsubtest.NewHarness(t, harnessOptions)
+	hub := harness.Hub()
+
+	client := harness.Connect()
+	err := hub.Subscribe(client.ID(), "X")
+	require.NoError(t, err)
+
+	publish1, err := hub.PublishAt(start.Add(time.Second), wspubsub.NewTextMessageFromString("1"), "X")
+	require.NoError(t, err)
+
+	publish2, err := hub.PublishAt(start.Add(2*time.Second), wspubsub.NewTextMessageFromString("2"), "X")
+	require.NoError(t, err)
+	require.True(t, publish2.Cancel())
+	require.False(t, publish2.Cancel())
+
+	_, err = hub.PublishAt(start.Add(-time.Second), wspubsub.NewTextMessageFromString("0"), "X")
+	require.NoError(t, err)
+
+	clock.Advance(500 * time.Millisecond)
+	client.ExpectText("0")
+
+	clock.Advance(2 * time.Second)
+	client.ExpectText("1")
+	client.ExpectNothing(10 * time.Millisecond)
+	require.False(t, publish1.Cancel())
+
+	pending, err := hub.PublishAt(start.Add(time.Hour), wspubsub.NewTextMessageFromString("3"), "X")
+	require.NoError(t, err)
+
+	err = hub.Close()
+	require.NoError(t, err)
+	require.False(t, pending.Cancel())
+
+	_, err = hub.PublishAt(start.Add(time.Hour), wspubsub.NewTextMessageFromString("4"), "X")
+	_, ok := wspubsub.IsHubClosedError(err)
+	require.True(t, ok)
+}
+
+func TestHub_PublishEvery(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := wspubsubtest.NewManualClock(start)
+
+	harnessOptions := wspubsubtest.NewHarnessOptions()
+	harnessOptions.HubOptions.Clock = clock
+	harness := wspubsubtest.NewHarness(t, harnessOptions)
+	hub := harness.Hub()
+
+	client := harness.Connect()
+	err := hub.Subscribe(client.ID(), "X")
+	require.NoError(t, err)
+
+	var calls int
+	producer := func(now time.Time) (wspubsub.Message, error) {
+		calls++
+		if calls == 2 {
+			return wspubsub.Message{}, errors.New("producer_error")
+		}
+
+		// A slow producer misses the next interval
+		if calls == 3 {
+			clock.Advance(1500 * time.Millisecond)
+		}
+
+		return wspubsub.NewTextMessageFromString(now.Sub(start).String()), nil
+	}
+
+	_, err = hub.PublishEvery(0, producer, "X")
+	_, ok := wspubsub.IsHubScheduleError(err)
+	require.True(t, ok)
+
+	_, err = hub.PublishEvery(time.Second, nil, "X")
+	_, ok = wspubsub.IsHubScheduleError(err)
+	require.True(t, ok)
+
+	publish, err := hub.PublishEvery(time.Second, producer, "X")
+	require.NoError(t, err)
+
+	clock.Advance(500 * time.Millisecond)
+	client.ExpectNothing(10 * time.Millisecond)
+
+	clock.Advance(3 * time.Second)
+	client.ExpectText("1s", "3s")
+	require.Equal(t, 3, calls)
+
+	clock.Advance(time.Second)
+	client.ExpectText("5s")
+	require.Equal(t, 4, calls)
+
+	require.True(t, publish.Cancel())
+	require.False(t, publish.Cancel())
+
+	clock.Advance(3 * time.Second)
+	client.ExpectNothing(10 * time.Millisecond)
+	require.Equal(t, 4, calls)
+
+	_, err = hub.PublishEvery(time.Second, producer, "X")
+	require.NoError(t, err)
+
+	err = hub.Close()
+	require.NoError(t, err)
+
+	clock.Advance(3 * time.Second)
+	require.Equal(t, 4, calls)
+}
+
+func TestHub_Stats(t *testing.T) {
+	harness := wspubsubtest.NewHarness(t, wspubsubtest.NewHarnessOptions())
+	hub := harness.Hub()
+
+	received := make(chan wspubsub.Message, 1)
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+	})
+
+	client1 := harness.Connect()
+	client2 := harness.Connect()
+
+	err := hub.Subscribe(client1.ID(), "X")
+	require.NoError(t, err)
+	err = hub.Subscribe(client2.ID(), "X")
+	require.NoError(t, err)
+
+	_, err = hub.Publish(wspubsub.NewTextMessageFromString("ABC"), "X")
+	require.NoError(t, err)
+	client1.ExpectText("ABC")
+	client2.ExpectText("ABC")
+
+	err = hub.Send(client1.ID(), wspubsub.NewTextMessageFromString("DE"))
+	require.NoError(t, err)
+	client1.ExpectText("DE")
+
+	client2.SendText("HELLO")
+	receiveMessage(t, received)
+
+	// Messages are counted once the connection write returns
+	require.Eventually(t, func() bool {
+		return hub.Stats().MessagesSent == 3
+	}, time.Second, time.Millisecond)
+
+	stats, err := hub.ClientStats(client1.ID())
+	require.NoError(t, err)
+	require.Equal(t, client1.ID(), stats.ID)
+	require.Zero(t, stats.QueueDepth)
+	require.Zero(t, stats.QueueBytes)
+	require.Equal(t, uint64(2), stats.MessagesSent)
+	require.Equal(t, uint64(5), stats.BytesSent)
+	require.Zero(t, stats.MessagesReceived)
+	require.False(t, stats.ConnectedAt.IsZero())
+	require.False(t, stats.LastWriteAt.IsZero())
+	require.True(t, stats.LastReadAt.IsZero())
+
+	stats, err = hub.ClientStats(client2.ID())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.MessagesSent)
+	require.Equal(t, uint64(1), stats.MessagesReceived)
+	require.Equal(t, uint64(5), stats.BytesReceived)
+	require.False(t, stats.LastReadAt.IsZero())
+
+	_, err = hub.ClientStats(wspubsubtest.SequentialUUID(100))
+	_, ok := wspubsub.IsClientNotFoundError(err)
+	require.True(t, ok)
+
+	hubStats := hub.Stats()
+	require.Equal(t, 2, hubStats.Clients)
+	require.Equal(t, uint64(3), hubStats.MessagesSent)
+	require.Equal(t, uint64(8), hubStats.BytesSent)
+	require.Equal(t, uint64(1), hubStats.MessagesReceived)
+	require.Equal(t, uint64(5), hubStats.BytesReceived)
+	require.Zero(t, hubStats.MessagesDropped)
+	require.Zero(t, hubStats.WriteErrors)
+
+	// Totals outlive disconnected clients
+	err = hub.Disconnect(client2.ID())
+	require.NoError(t, err)
+
+	hubStats = hub.Stats()
+	require.Equal(t, 1, hubStats.Clients)
+	require.Equal(t, uint64(3), hubStats.MessagesSent)
+}
+
+func TestHub_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	findErrText := "find_error"
+
+	// *** Success case
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(clientID)
+
+	client.
+		EXPECT().
+		Close().
+		Times(1)
+
+	clientStore.
+		EXPECT().
+		Unset(gomock.Eq(clientID)).
+		Times(1)
+
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Eq([]string{})).
+		Times(1).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			err := fn(client)
+			require.NoError(t, err)
+
+			return nil
+		})
+
+	// *** Error case
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Eq([]string{})).
+		Times(1).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			return errors.New(findErrText)
+		})
+
+	t.Run("Closing success", func(t *testing.T) {
+		err := hub.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("Closing error", func(t *testing.T) {
+		err := hub.Close()
+		require.Error(t, err)
+		require.True(t, strings.Contains(err.Error(), findErrText))
+	})
+}
+
+func TestHub_Logging(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+
+	message := "log_message"
+	key := wspubsub.LogFieldClientID
+
+	logger.EXPECT().Debug(gomock.Eq(message), gomock.Eq(key), gomock.Eq(clientID)).Times(1)
+	logger.EXPECT().Info(gomock.Eq(message), gomock.Eq(key), gomock.Eq(clientID)).Times(1)
+	logger.EXPECT().Warn(gomock.Eq(message), gomock.Eq(key), gomock.Eq(clientID)).Times(1)
+	logger.EXPECT().Error(gomock.Eq(message), gomock.Eq(key), gomock.Eq(clientID)).Times(1)
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	hub.LogDebug(message, key, clientID)
+	hub.LogInfo(message, key, clientID)
+	hub.LogWarn(message, key, clientID)
+	hub.LogError(message, key, clientID)
+}
+
+func TestHub_ConnectionLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	identity := "user"
+	clientIP := "203.0.113.5"
+
+	newRequest := func() *http.Request {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.Header.Set("X-Forwarded-For", clientIP+", 10.0.0.2")
+
+		return request
+	}
+
+	clientFactory.
+		EXPECT().
+		Create().
+		Times(1).
+		Return(client)
+
+	clientStore.
+		EXPECT().
+		Set(gomock.Eq(client)).
+		Times(1)
+
+	clientStore.
+		EXPECT().
+		Get(gomock.Eq(clientID)).
+		Times(1).
+		Return(client, nil)
+
+	clientStore.
+		EXPECT().
+		Unset(gomock.Eq(clientID)).
+		Times(1)
+
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(clientID)
+
+	client.
+		EXPECT().
+		OnReceive(gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		OnError(gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		Connect(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		Close().
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.ConnectionLimits.PerIP = 1
+	hubOptions.TrustedProxies = []string{"10.0.0.0/8"}
+	hubOptions.Authenticator = wspubsub.AuthenticatorFunc(func(request *http.Request) (wspubsub.Identity, error) {
+		return wspubsub.Identity{ID: identity}, nil
+	})
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	t.Run("Connection accepted", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		hub.ServeHTTP(response, newRequest())
+		require.Equal(t, http.StatusOK, response.Result().StatusCode)
+		require.Equal(t, 1, hub.CountIP(clientIP))
+		require.Equal(t, 1, hub.CountIdentity(identity))
+	})
+
+	t.Run("Connection rejected", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		hub.ServeHTTP(response, newRequest())
+		require.Equal(t, http.StatusTooManyRequests, response.Result().StatusCode)
+		require.Equal(t, "5", response.Result().Header.Get("Retry-After"))
+		require.Equal(t, 1, hub.CountIP(clientIP))
+	})
+
+	t.Run("Connection released", func(t *testing.T) {
+		err := hub.Disconnect(clientID)
+		require.NoError(t, err)
+		require.Equal(t, 0, hub.CountIP(clientIP))
+		require.Equal(t, 0, hub.CountIdentity(identity))
+	})
+}
+
+func TestHub_RemoteIP(t *testing.T) {
+	harnessOptions := wspubsubtest.NewHarnessOptions()
+	harnessOptions.HubOptions.TrustedProxies = []string{"10.0.0.0/8"}
+	harness := wspubsubtest.NewHarness(t, harnessOptions)
+	hub := harness.Hub()
+
+	newRequest := func(remoteAddr string, forwardedFor []string, realIP string) *http.Request {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = remoteAddr
+		for _, line := range forwardedFor {
+			request.Header.Add("X-Forwarded-For", line)
+		}
+
+		if realIP != "" {
+			request.Header.Set("X-Real-IP", realIP)
+		}
+
+		return request
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		expectedIP   string
+	}{
+		{"Untrusted peer", "203.0.113.5:1234", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.5"},
+		{"Forwarded by trusted proxy", "10.0.0.1:1234", []string{"198.51.100.3, 10.0.0.2"}, "", "198.51.100.3"},
+		{"Spoofed real IP behind trusted proxies", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "198.51.100.4", "10.0.0.3"},
+		{"Real IP set by trusted proxy", "10.0.0.1:1234", nil, "198.51.100.5", "198.51.100.5"},
+		{"Forwarded by trusted proxy in separate line", "10.0.0.1:1234", []string{"198.51.100.6", "198.51.100.7"}, "", "198.51.100.7"},
+		{"Unparseable hop", "10.0.0.1:1234", []string{"198.51.100.8, garbage, 10.0.0.4"}, "", "10.0.0.4"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := harness.ConnectRequest(newRequest(testCase.remoteAddr, testCase.forwardedFor, testCase.realIP))
+			require.NoError(t, err)
+			require.Equal(t, 1, hub.CountIP(testCase.expectedIP))
+		})
+	}
+
+	require.Zero(t, hub.CountIP("198.51.100.1"))
+	require.Zero(t, hub.CountIP("198.51.100.2"))
+	require.Zero(t, hub.CountIP("198.51.100.4"))
+	require.Zero(t, hub.CountIP("198.51.100.6"))
+	require.Zero(t, hub.CountIP("198.51.100.8"))
+}
+
+func TestHub_Authentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	identity := wspubsub.Identity{ID: "user"}
+
+	clientFactory.
+		EXPECT().
+		Create().
+		Times(1).
+		Return(client)
+
+	clientStore.
+		EXPECT().
+		Set(gomock.Eq(client)).
+		Times(1)
+
+	clientStore.
+		EXPECT().
+		SetChannels(gomock.Eq(clientID), gomock.Eq("public")).
+		Times(1)
+
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(clientID)
+
+	var clientReceiveHandler wspubsub.ReceiveHandler
+	client.
+		EXPECT().
+		OnReceive(gomock.Any()).
+		Times(1).
+		Do(func(handler wspubsub.ReceiveHandler) {
+			clientReceiveHandler = handler
+		})
+
+	client.
+		EXPECT().
+		OnError(gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		Connect(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.Authenticator = wspubsub.AuthenticatorFunc(func(request *http.Request) (wspubsub.Identity, error) {
+		switch request.URL.Query().Get("token") {
+		case "valid":
+			return identity, nil
+		case "banned":
+			return wspubsub.Identity{}, wspubsub.NewHubForbiddenError(errors.New("banned"))
+		}
+
+		return wspubsub.Identity{}, errors.New("invalid token")
+	})
+	hubOptions.Authorizer = wspubsub.AuthorizerFunc(func(id wspubsub.Identity, channels ...string) error {
+		require.Equal(t, identity, id)
+		for _, channel := range channels {
+			if channel != "public" {
+				return errors.New("access denied")
+			}
+		}
+
+		return nil
+	})
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	connectedIdentities := make(chan wspubsub.Identity, 1)
+	hub.OnConnectContext(func(ctx context.Context, clientID wspubsub.UUID) {
+		id, ok := wspubsub.IdentityFromContext(ctx)
+		require.True(t, ok)
+		connectedIdentities <- id
+	})
+
+	receivedIdentities := make(chan wspubsub.Identity, 1)
+	hub.OnReceiveContext(func(ctx context.Context, clientID wspubsub.UUID, message wspubsub.Message) {
+		id, ok := wspubsub.IdentityFromContext(ctx)
+		require.True(t, ok)
+		receivedIdentities <- id
+	})
+
+	t.Run("Invalid credentials", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		hub.ServeHTTP(response, httptest.NewRequest("GET", "/?token=invalid", nil))
+		require.Equal(t, http.StatusUnauthorized, response.Result().StatusCode)
+	})
+
+	t.Run("Forbidden credentials", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		hub.ServeHTTP(response, httptest.NewRequest("GET", "/?token=banned", nil))
+		require.Equal(t, http.StatusForbidden, response.Result().StatusCode)
+	})
+
+	t.Run("Valid credentials", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		hub.ServeHTTP(response, httptest.NewRequest("GET", "/?token=valid", nil))
+		require.Equal(t, http.StatusOK, response.Result().StatusCode)
+		require.Equal(t, identity, <-connectedIdentities)
+
+		clientReceiveHandler(clientID, wspubsub.NewTextMessageFromString("TEST"))
+		require.Equal(t, identity, <-receivedIdentities)
+
+		id, err := hub.Identity(clientID)
+		require.NoError(t, err)
+		require.Equal(t, identity, id)
+
+		_, err = hub.Identity(wspubsub.UUID{})
+		_, ok := wspubsub.IsClientNotFoundError(err)
+		require.True(t, ok)
+	})
+
+	t.Run("Authorization", func(t *testing.T) {
+		err := hub.Subscribe(clientID, "public")
+		require.NoError(t, err)
+
+		err = hub.Subscribe(clientID, "public", "private")
+		_, ok := wspubsub.IsHubAuthorizationError(err)
+		require.True(t, ok)
+	})
+}
+
+func TestHub_Reauthentication(t *testing.T) {
+	newHub := func(
+		ctrl *gomock.Controller,
+		hubOptions wspubsub.HubOptions,
+		identity wspubsub.Identity,
+		expect func(clientStore *mock.MockWebsocketClientStore, client *mock.MockWebsocketClient),
+	) (*wspubsub.Hub, *wspubsub.ReceiveHandler) {
+		logger := mock.NewMockLogger(ctrl)
+		logger.
+			EXPECT().
+			Debug(gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		logger.
+			EXPECT().
+			Info(gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		clientStore := mock.NewMockWebsocketClientStore(ctrl)
+		clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+		client := mock.NewMockWebsocketClient(ctrl)
+
+		var receiveHandler wspubsub.ReceiveHandler
+
+		clientFactory.
+			EXPECT().
+			Create().
+			Times(1).
+			Return(client)
+
+		// The client is found only once it's registered in the store
+		var isRegistered int32
+
+		clientStore.
+			EXPECT().
+			Set(gomock.Eq(client)).
+			Times(1).
+			Do(func(client wspubsub.WebsocketClient) {
+				atomic.StoreInt32(&isRegistered, 1)
+			})
+
+		clientStore.
+			EXPECT().
+			Get(gomock.Eq(clientID)).
+			AnyTimes().
+			DoAndReturn(func(cid wspubsub.UUID) (wspubsub.WebsocketClient, error) {
+				if atomic.LoadInt32(&isRegistered) == 0 {
+					return nil, wspubsub.NewClientNotFoundError(cid)
+				}
+
+				return client, nil
+			})
+
+		client.
+			EXPECT().
+			ID().
+			AnyTimes().
+			Return(clientID)
+
+		client.
+			EXPECT().
+			OnReceive(gomock.Any()).
+			Times(1).
+			DoAndReturn(func(handler func(cid wspubsub.UUID, message wspubsub.Message)) {
+				receiveHandler = handler
+			})
+
+		client.
+			EXPECT().
+			OnError(gomock.Any()).
+			Times(1)
+
+		client.
+			EXPECT().
+			Connect(gomock.Any(), gomock.Any()).
+			Times(1)
+
+		expect(clientStore, client)
+
+		hubOptions.Authenticator = wspubsub.AuthenticatorFunc(func(request *http.Request) (wspubsub.Identity, error) {
+			return identity, nil
+		})
+
+		hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+		hub.OnReceive(func(cid wspubsub.UUID, message wspubsub.Message) {
+			t.Error("Unexpected call of: receive_handler")
+		})
+
+		response := httptest.NewRecorder()
+		hub.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, response.Result().StatusCode)
+
+		return hub, &receiveHandler
+	}
+
+	t.Run("Credentials refreshed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		identity := wspubsub.Identity{ID: "user", ExpiresAt: time.Now().Add(300 * time.Millisecond)}
+		refreshedIdentity := wspubsub.Identity{ID: "user", ExpiresAt: time.Now().Add(time.Hour)}
+
+		hubOptions := wspubsub.NewHubOptions()
+		hubOptions.Reauthentication.Advance = 200 * time.Millisecond
+		hubOptions.Reauthentication.Validator = wspubsub.TokenValidatorFunc(func(token string) (wspubsub.Identity, error) {
+			require.Equal(t, "fresh", token)
+
+			return refreshedIdentity, nil
+		})
+
+		requested := make(chan struct{})
+		hub, receiveHandler := newHub(ctrl, hubOptions, identity, func(clientStore *mock.MockWebsocketClientStore, client *mock.MockWebsocketClient) {
+			client.
+				EXPECT().
+				Send(gomock.Eq(hubOptions.Reauthentication.RequestMessage(identity))).
+				Times(1).
+				DoAndReturn(func(message wspubsub.Message) error {
+					close(requested)
+
+					return nil
+				})
+		})
+
+		select {
+		case <-requested:
+		case <-time.After(time.Second):
+			t.Fatal("Reauthentication is not requested")
+		}
+
+		(*receiveHandler)(clientID, wspubsub.NewTextMessageFromString(`{"type":"reauth","token":"fresh"}`))
+
+		id, err := hub.Identity(clientID)
+		require.NoError(t, err)
+		require.Equal(t, refreshedIdentity, id)
+
+		// Wait for the initial expiry time to make sure the client is not disconnected
+		time.Sleep(300 * time.Millisecond)
+	})
+
+	t.Run("Credentials expired", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		identity := wspubsub.Identity{ID: "user", ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+
+		disconnected := make(chan struct{})
+		newHub(ctrl, wspubsub.NewHubOptions(), identity, func(clientStore *mock.MockWebsocketClientStore, client *mock.MockWebsocketClient) {
+			clientStore.
+				EXPECT().
+				Unset(gomock.Eq(clientID)).
+				Times(1)
+
+			client.
+				EXPECT().
+				CloseWithCode(gomock.Eq(wspubsub.CloseCodeCredentialsExpired), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(code wspubsub.CloseCode, reason string) error {
+					close(disconnected)
+
+					return nil
+				})
+		})
+
+		select {
+		case <-disconnected:
+		case <-time.After(time.Second):
+			t.Fatal("Client is not disconnected")
+		}
+	})
+
+	t.Run("Credentials expired before connect", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		identity := wspubsub.Identity{ID: "user", ExpiresAt: time.Now()}
+
+		disconnected := make(chan struct{})
+		newHub(ctrl, wspubsub.NewHubOptions(), identity, func(clientStore *mock.MockWebsocketClientStore, client *mock.MockWebsocketClient) {
+			clientStore.
+				EXPECT().
+				Unset(gomock.Eq(clientID)).
+				Times(1)
+
+			client.
+				EXPECT().
+				CloseWithCode(gomock.Eq(wspubsub.CloseCodeCredentialsExpired), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(code wspubsub.CloseCode, reason string) error {
+					close(disconnected)
+
+					return nil
+				})
+		})
+
+		select {
+		case <-disconnected:
+		case <-time.After(time.Second):
+			t.Fatal("Client is not disconnected")
+		}
+	})
+
+	t.Run("Client disconnected while reauthenticating", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		identity := wspubsub.Identity{ID: "user", ExpiresAt: time.Now().Add(time.Hour)}
+		refreshedIdentity := wspubsub.Identity{ID: "user", ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+
+		var hub *wspubsub.Hub
+
+		hubOptions := wspubsub.NewHubOptions()
+		hubOptions.Reauthentication.Validator = wspubsub.TokenValidatorFunc(func(token string) (wspubsub.Identity, error) {
+			require.NoError(t, hub.Disconnect(clientID))
+
+			return refreshedIdentity, nil
+		})
+
+		hub, _ = newHub(ctrl, hubOptions, identity, func(clientStore *mock.MockWebsocketClientStore, client *mock.MockWebsocketClient) {
+			clientStore.
+				EXPECT().
+				Unset(gomock.Eq(clientID)).
+				Times(1)
+
+			client.
+				EXPECT().
+				Close().
+				Times(1)
+		})
+
+		err := hub.Reauthenticate(clientID, "fresh")
+		_, ok := wspubsub.IsClientNotFoundError(err)
+		require.True(t, ok)
+
+		// Wait for the refreshed expiry time to make sure the client is not closed again
+		time.Sleep(100 * time.Millisecond)
+	})
+}
+
+func TestHub_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+	metrics := mock.NewMockMetricsCollector(ctrl)
+
+	message := wspubsub.NewTextMessageFromString("TEST")
+	channels := []string{"X", "Y"}
+
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(clientID)
+
+	// *** Connection
+	clientFactory.
+		EXPECT().
+		Create().
+		Times(1).
+		Return(client)
+
+	client.
+		EXPECT().
+		OnReceive(gomock.Any()).
+		Times(1)
+
+	client.
+		EXPECT().
+		OnError(gomock.Any()).
+		Times(1)
+
+	clientStore.
+		EXPECT().
+		Set(gomock.Eq(client)).
+		Times(1)
+
+	client.
+		EXPECT().
+		Connect(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	metrics.
+		EXPECT().
+		ClientConnected().
+		Times(1)
+
+	// *** Subscription
+	clientStore.
+		EXPECT().
+		SetChannels(gomock.Eq(clientID), gomock.Eq("X"), gomock.Eq("Y")).
+		Times(1)
+
+	clientStore.
+		EXPECT().
+		Count(gomock.Eq("X")).
+		Times(2).
+		Return(1)
+
+	clientStore.
+		EXPECT().
+		Count(gomock.Eq("Y")).
+		Times(2).
+		Return(0)
+
+	metrics.
+		EXPECT().
+		ChannelSubscribers(gomock.Eq("X"), gomock.Eq(1)).
+		Times(2)
+
+	metrics.
+		EXPECT().
+		ChannelSubscribers(gomock.Eq("Y"), gomock.Eq(0)).
+		Times(2)
+
+	// *** Publishing
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			return fn(client)
+		})
+
+	client.
+		EXPECT().
+		Send(gomock.Eq(message)).
+		Times(1)
+
+	metrics.
+		EXPECT().
+		MessagePublished(gomock.Eq(1), gomock.Eq(len(message.Payload)), gomock.Any()).
+		Times(1)
+
+	// *** Disconnection
+	clientStore.
+		EXPECT().
+		Get(gomock.Eq(clientID)).
+		Times(2).
+		Return(client, nil)
+
+	clientStore.
+		EXPECT().
+		Channels(gomock.Eq(clientID)).
+		Times(2).
+		Return(channels, nil)
+
+	clientStore.
+		EXPECT().
+		Unset(gomock.Eq(clientID)).
+		Times(2)
+
+	client.
+		EXPECT().
+		Close().
+		Times(2)
+
+	metrics.
+		EXPECT().
+		ClientDisconnected(gomock.Eq(wspubsub.DisconnectReasonClosed)).
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.Metrics = metrics
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	numDisconnected := 0
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		numDisconnected++
+	})
+
+	hub.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	err := hub.Subscribe(clientID, channels...)
+	require.NoError(t, err)
+
+	numClients, err := hub.Publish(message)
+	require.NoError(t, err)
+	require.Equal(t, 1, numClients)
+
+	err = hub.Disconnect(clientID)
+	require.NoError(t, err)
+
+	// The repeated disconnection isn't counted
+	err = hub.Disconnect(clientID)
+	require.NoError(t, err)
+	require.Equal(t, 1, numDisconnected)
+}
+
+func TestHub_Tracing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	message := wspubsub.NewTextMessageFromString(`{"now":1}`)
+	written := make(chan wspubsub.Message, 1)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response := httptest.NewRecorder()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	connection := mock.NewMockWebsocketConnection(ctrl)
+	upgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+
+	upgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(response), gomock.Eq(request)).
+		Return(connection, nil).
+		Times(1)
+
+	connection.
+		EXPECT().
+		Read().
+		AnyTimes().
+		Do(func() {
+			time.Sleep(time.Hour)
+		})
+
+	connection.
+		EXPECT().
+		Write(gomock.Any()).
+		Times(1).
+		Do(func(message wspubsub.Message) {
+			written <- message
+		})
+
+	clientOptions := wspubsub.NewClientOptions()
+	clientOptions.TracerProvider = tracerProvider
+	client := wspubsub.NewClient(clientOptions, clientID, upgrader, nil)
+
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Eq("X")).
+		Times(1).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			return fn(client)
+		})
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.TracerProvider = tracerProvider
+	hubOptions.MessagePropagator = wspubsub.NewJSONMessagePropagator(nil)
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	err := client.Connect(response, request)
+	require.NoError(t, err)
+
+	numClients, err := hub.Publish(message, "X")
+	require.NoError(t, err)
+	require.Equal(t, 1, numClients)
+
+	select {
+	case message := <-written:
+		require.Contains(t, string(message.Payload), `"traceparent"`)
+		require.Contains(t, string(message.Payload), `"now":1`)
+	case <-time.After(time.Second):
+		require.Fail(t, "message was not written")
+	}
+
+	// The write span is ended right after the write
+	time.Sleep(100 * time.Millisecond)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	publishSpan := spans["wspubsub.hub.publish"]
+	findSpan := spans["wspubsub.client_store.find"]
+	fanOutSpan := spans["wspubsub.hub.fan_out"]
+	sendSpan := spans["wspubsub.client.send"]
+	writeSpan := spans["wspubsub.connection.write"]
+
+	require.True(t, publishSpan.SpanContext.IsValid())
+	require.Equal(t, publishSpan.SpanContext.SpanID(), findSpan.Parent.SpanID())
+	require.Equal(t, publishSpan.SpanContext.SpanID(), fanOutSpan.Parent.SpanID())
+	require.False(t, findSpan.EndTime.After(fanOutSpan.StartTime))
+	require.Equal(t, fanOutSpan.SpanContext.SpanID(), sendSpan.Parent.SpanID())
+	require.Equal(t, sendSpan.SpanContext.SpanID(), writeSpan.Parent.SpanID())
+	require.Equal(t, publishSpan.SpanContext.TraceID(), writeSpan.SpanContext.TraceID())
+}
+
+func TestHub_MessagePropagator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jsonPropagator := wspubsub.NewJSONMessagePropagator(nil)
+	propagator := mock.NewMockMessagePropagator(ctrl)
+	propagator.
+		EXPECT().
+		Inject(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(jsonPropagator.Inject)
+
+	harnessOptions := wspubsubtest.NewHarnessOptions()
+	harnessOptions.HubOptions.TracerProvider = sdktrace.NewTracerProvider()
+	harnessOptions.HubOptions.MessagePropagator = propagator
+	harness := wspubsubtest.NewHarness(t, harnessOptions)
+	hub := harness.Hub()
+
+	clients := harness.ConnectN(2)
+	for _, client := range clients {
+		require.NoError(t, hub.Subscribe(client.ID(), "X"))
+	}
+
+	numClients, err := hub.Publish(wspubsub.NewTextMessageFromString(`{"now":1}`), "X")
+	require.NoError(t, err)
+	require.Equal(t, 2, numClients)
+
+	first := clients[0].Receive()
+	second := clients[1].Receive()
+	require.Contains(t, string(first.Payload), `"traceparent"`)
+	require.Equal(t, first.Payload, second.Payload)
+}
+
+func TestHub_Observer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	observer := mock.NewMockObserver(ctrl)
+
+	channels := []string{"X", "Y"}
+
+	clientStore.
+		EXPECT().
+		SetChannels(gomock.Eq(clientID), gomock.Eq("X"), gomock.Eq("Y")).
+		Times(1)
+
+	events := make([]wspubsub.OperationEvent, 0, 4)
+	recordEvent := func(event wspubsub.OperationEvent) {
+		events = append(events, event)
+	}
+
+	observer.
+		EXPECT().
+		OperationStarted(gomock.Any()).
+		Times(2).
+		Do(recordEvent)
+
+	observer.
+		EXPECT().
+		OperationFinished(gomock.Any()).
+		Times(2).
+		Do(recordEvent)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.Observer = observer
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	err := hub.Subscribe(clientID, channels...)
+	require.NoError(t, err)
+
+	err = hub.Subscribe(clientID)
+	require.Error(t, err)
+
+	require.Len(t, events, 4)
+	for _, event := range events {
+		require.Equal(t, "wspubsub.hub.subscribe", event.Name)
+		require.Equal(t, clientID, event.ClientID)
+	}
+
+	require.Equal(t, channels, events[0].Channels)
+	require.Zero(t, events[0].Duration)
+	require.NoError(t, events[1].Err)
+	require.Error(t, events[3].Err)
+	_, ok := wspubsub.IsHubSubscriptionChannelRequiredError(events[3].Err)
+	require.True(t, ok)
+}
+
+func TestHub_ChannelEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	logger.
+		EXPECT().
+		Info(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(clientID)
+
+	client.
+		EXPECT().
+		Close().
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	var created, emptied []string
+	hub.OnChannelCreated(func(channel string) {
+		created = append(created, channel)
+	})
+	hub.OnChannelEmptied(func(channel string) {
+		emptied = append(emptied, channel)
+	})
+
+	clientStore.Set(client)
+
+	err := hub.Subscribe(clientID, "X", "Y")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"X", "Y"}, created)
+
+	err = hub.Unsubscribe(clientID, "X")
+	require.NoError(t, err)
+	require.Equal(t, []string{"X"}, emptied)
 
-	hub.LogDebug(message)
-	hub.LogInfo(message)
-	hub.LogPrint(message)
-	hub.LogWarn(message)
-	hub.LogError(message)
-	hub.LogFatal(message)
-	hub.LogPanic(message)
-
-	hub.LogDebugln(message)
-	hub.LogInfoln(message)
-	hub.LogPrintln(message)
-	hub.LogWarnln(message)
-	hub.LogErrorln(message)
-	hub.LogFatalln(message)
-	hub.LogPanicln(message)
-
-	hub.LogDebugf(format, message)
-	hub.LogInfof(format, message)
-	hub.LogPrintf(format, message)
-	hub.LogWarnf(format, message)
-	hub.LogErrorf(format, message)
-	hub.LogFatalf(format, message)
-	hub.LogPanicf(format, message)
+	err = hub.Disconnect(clientID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"X", "Y"}, emptied)
+	require.Empty(t, hub.ListChannels())
 }
@@ -0,0 +1,37 @@
+package wspubsub
+
+import (
+	"context"
+	"time"
+)
+
+type identityContextKey struct{}
+
+// Identity represents an authenticated subject the client acts on behalf of.
+type Identity struct {
+	// Unique identifier of the subject (e.g. user ID)
+	ID string
+
+	// Arbitrary attributes of the subject (e.g. token claims)
+	Attributes map[string]interface{}
+
+	// Time when the credentials expire (zero means never)
+	ExpiresAt time.Time
+}
+
+// IsAnonymous checks whether the identity is empty.
+func (i Identity) IsAnonymous() bool {
+	return i.ID == ""
+}
+
+// IdentityFromContext returns the identity of the client passed to connect and receive handlers
+// (see Hub.OnConnectContext and Hub.OnReceiveContext).
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(Identity)
+
+	return identity, ok
+}
+
+func contextWithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
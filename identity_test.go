@@ -0,0 +1,19 @@
+package wspubsub_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentity_IsAnonymous(t *testing.T) {
+	require.True(t, wspubsub.Identity{}.IsAnonymous())
+	require.False(t, wspubsub.Identity{ID: "TEST"}.IsAnonymous())
+}
+
+func TestIdentityFromContext(t *testing.T) {
+	_, ok := wspubsub.IdentityFromContext(context.Background())
+	require.False(t, ok)
+}
@@ -0,0 +1,32 @@
+package wspubsub
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+var _ Codec = (*JSONCodec)(nil)
+
+// JSONCodec is an implementation of Codec which encodes values into text JSON messages.
+type JSONCodec struct{}
+
+// Encode marshals the value into a text message.
+func (c JSONCodec) Encode(v interface{}) (Message, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return Message{}, errors.WithStack(err)
+	}
+
+	return NewTextMessage(payload), nil
+}
+
+// Decode unmarshals a message payload into the value.
+func (c JSONCodec) Decode(message Message, v interface{}) error {
+	err := json.Unmarshal(message.Payload, v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
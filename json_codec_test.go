@@ -0,0 +1,32 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+type codecValue struct {
+	Name  string `json:"name" msgpack:"name"`
+	Count int    `json:"count" msgpack:"count"`
+}
+
+func TestJSONCodec(t *testing.T) {
+	codec := wspubsub.JSONCodec{}
+
+	message, err := codec.Encode(codecValue{Name: "TEST", Count: 1})
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.NewTextMessageFromString(`{"name":"TEST","count":1}`), message)
+
+	var v codecValue
+	err = codec.Decode(message, &v)
+	require.NoError(t, err)
+	require.Equal(t, codecValue{Name: "TEST", Count: 1}, v)
+
+	_, err = codec.Encode(make(chan int))
+	require.Error(t, err)
+
+	err = codec.Decode(wspubsub.NewTextMessageFromString("{"), &v)
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package wspubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var _ MessagePropagator = (*JSONMessagePropagator)(nil)
+
+// JSONMessagePropagator is an implementation of MessagePropagator.
+// It carries a trace context in top-level fields of JSON objects,
+// e.g. {"traceparent":"00-...-...-01","command":"PUBLISH"}.
+// Messages with a payload other than a JSON object are left untouched.
+type JSONMessagePropagator struct {
+	propagator propagation.TextMapPropagator
+}
+
+// Inject returns a copy of the message carrying the trace context of ctx.
+func (p *JSONMessagePropagator) Inject(ctx context.Context, message Message) Message {
+	if !p.isObject(message) {
+		return message
+	}
+
+	carrier := propagation.MapCarrier{}
+	p.propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return message
+	}
+
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(message.Payload, &fields)
+	if err != nil {
+		return message
+	}
+
+	for key, value := range carrier {
+		fields[key], _ = json.Marshal(value)
+	}
+
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return message
+	}
+
+	message.Payload = payload
+
+	return message
+}
+
+// Extract returns a copy of ctx with the trace context carried by the message.
+func (p *JSONMessagePropagator) Extract(ctx context.Context, message Message) context.Context {
+	if !p.isObject(message) || !p.hasFields(message) {
+		return ctx
+	}
+
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(message.Payload, &fields)
+	if err != nil {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{}
+	for _, key := range p.propagator.Fields() {
+		var value string
+		if json.Unmarshal(fields[key], &value) == nil {
+			carrier[key] = value
+		}
+	}
+
+	return p.propagator.Extract(ctx, carrier)
+}
+
+func (p *JSONMessagePropagator) isObject(message Message) bool {
+	if message.Type != MessageTypeText && message.Type != MessageTypeBinary {
+		return false
+	}
+
+	payload := bytes.TrimSpace(message.Payload)
+
+	return len(payload) > 0 && payload[0] == '{'
+}
+
+// Cheap check to avoid unmarshalling of messages without a trace context
+func (p *JSONMessagePropagator) hasFields(message Message) bool {
+	for _, key := range p.propagator.Fields() {
+		if bytes.Contains(message.Payload, []byte(`"`+key+`"`)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewJSONMessagePropagator initializes a new JSONMessagePropagator.
+// The W3C Trace Context format is used if the propagator is nil.
+func NewJSONMessagePropagator(propagator propagation.TextMapPropagator) *JSONMessagePropagator {
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+
+	return &JSONMessagePropagator{propagator: propagator}
+}
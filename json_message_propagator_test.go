@@ -0,0 +1,61 @@
+package wspubsub_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestJSONMessagePropagator(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	propagator := wspubsub.NewJSONMessagePropagator(nil)
+
+	t.Run("Injecting and extracting trace context", func(t *testing.T) {
+		message := propagator.Inject(ctx, wspubsub.NewTextMessageFromString(`{"now":1}`))
+		require.JSONEq(
+			t,
+			`{"now":1,"traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}`,
+			string(message.Payload),
+		)
+
+		extracted := trace.SpanContextFromContext(propagator.Extract(context.Background(), message))
+		require.Equal(t, traceID, extracted.TraceID())
+		require.Equal(t, spanID, extracted.SpanID())
+		require.True(t, extracted.IsRemote())
+	})
+
+	t.Run("Skipping messages without JSON object", func(t *testing.T) {
+		messages := []wspubsub.Message{
+			wspubsub.NewTextMessageFromString(`TEST`),
+			wspubsub.NewTextMessageFromString(`["traceparent"]`),
+			wspubsub.NewTextMessageFromString(`{"traceparent":`),
+			wspubsub.NewPingMessage(),
+		}
+
+		for _, message := range messages {
+			require.Equal(t, message, propagator.Inject(ctx, message))
+
+			extracted := trace.SpanContextFromContext(propagator.Extract(context.Background(), message))
+			require.False(t, extracted.IsValid())
+		}
+	})
+
+	t.Run("Skipping messages without trace context", func(t *testing.T) {
+		message := wspubsub.NewTextMessageFromString(`{"now":1}`)
+		require.Equal(t, message, propagator.Inject(context.Background(), message))
+
+		extracted := trace.SpanContextFromContext(propagator.Extract(context.Background(), message))
+		require.False(t, extracted.IsValid())
+	})
+}
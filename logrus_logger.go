@@ -1,6 +1,8 @@
 package wspubsub
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -32,112 +34,54 @@ var _ Logger = (*LogrusLogger)(nil)
 
 // LogrusLogger is an implementation of Logger.
 type LogrusLogger struct {
-	logger *logrus.Logger
-}
-
-// Debug logs a message at level Debug.
-func (l LogrusLogger) Debug(args ...interface{}) {
-	l.logger.Debug(args...)
-}
-
-// Info logs a message at level Info.
-func (l LogrusLogger) Info(args ...interface{}) {
-	l.logger.Info(args...)
-}
-
-// Print logs a message at level Info.
-func (l LogrusLogger) Print(args ...interface{}) {
-	l.logger.Print(args...)
-}
-
-// Warn logs a message at level Warn.
-func (l LogrusLogger) Warn(args ...interface{}) {
-	l.logger.Warn(args...)
-}
-
-// Error logs a message at level Error.
-func (l LogrusLogger) Error(args ...interface{}) {
-	l.logger.Error(args...)
-}
-
-// Fatal logs a message at level Fatal then the process will exit with status set to 1.
-func (l LogrusLogger) Fatal(args ...interface{}) {
-	l.logger.Fatal(args...)
-}
-
-// Panic logs a message at level Panic and panics.
-func (l LogrusLogger) Panic(args ...interface{}) {
-	l.logger.Panic(args...)
-}
-
-// Debugln is like LogDebug but adds a new line.
-func (l LogrusLogger) Debugln(args ...interface{}) {
-	l.logger.Debugln(args...)
-}
-
-// Infoln is like LogInfo but adds a new line.
-func (l LogrusLogger) Infoln(args ...interface{}) {
-	l.logger.Infoln(args...)
-}
-
-// Println is like LogPrint but adds a new line.
-func (l LogrusLogger) Println(args ...interface{}) {
-	l.logger.Println(args...)
+	entry *logrus.Entry
 }
 
-// Warnln is like LogWarn but adds a new line.
-func (l LogrusLogger) Warnln(args ...interface{}) {
-	l.logger.Warnln(args...)
+// Debug logs a message with fields at level Debug.
+func (l LogrusLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.entry.WithFields(logrusFields(keysAndValues)).Debug(msg)
 }
 
-// Errorln is like LogError but adds a new line.
-func (l LogrusLogger) Errorln(args ...interface{}) {
-	l.logger.Errorln(args...)
+// Info logs a message with fields at level Info.
+func (l LogrusLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.entry.WithFields(logrusFields(keysAndValues)).Info(msg)
 }
 
-// Fatalln is like LogFatal but adds a new line.
-func (l LogrusLogger) Fatalln(args ...interface{}) {
-	l.logger.Fatalln(args...)
+// Warn logs a message with fields at level Warn.
+func (l LogrusLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.entry.WithFields(logrusFields(keysAndValues)).Warn(msg)
 }
 
-// Panicln is like LogPanic but adds a new line.
-func (l LogrusLogger) Panicln(args ...interface{}) {
-	l.logger.Panicln(args...)
+// Error logs a message with fields at level Error.
+func (l LogrusLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.entry.WithFields(logrusFields(keysAndValues)).Error(msg)
 }
 
-// Debugf is like LogDebug but allows specifying a message format.
-func (l LogrusLogger) Debugf(format string, args ...interface{}) {
-	l.logger.Debugf(format, args...)
+// With returns a logger which adds the fields to every message.
+func (l LogrusLogger) With(keysAndValues ...interface{}) Logger {
+	return &LogrusLogger{entry: l.entry.WithFields(logrusFields(keysAndValues))}
 }
 
-// Infof is like LogInfo but allows specifying a message format.
-func (l LogrusLogger) Infof(format string, args ...interface{}) {
-	l.logger.Infof(format, args...)
-}
+// logrusFields converts alternating keys and values to fields.
+// A value without a key is stored under the "!BADKEY" key like slog does.
+func logrusFields(keysAndValues []interface{}) logrus.Fields {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields["!BADKEY"] = keysAndValues[i]
 
-// Printf is like LogPrint but allows specifying a message format.
-func (l LogrusLogger) Printf(format string, args ...interface{}) {
-	l.logger.Printf(format, args...)
-}
+			break
+		}
 
-// Warnf is like LogWarn but allows specifying a message format.
-func (l LogrusLogger) Warnf(format string, args ...interface{}) {
-	l.logger.Warnf(format, args...)
-}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
 
-// Errorf is like LogError but allows specifying a message format.
-func (l LogrusLogger) Errorf(format string, args ...interface{}) {
-	l.logger.Errorf(format, args...)
-}
-
-// Fatalf is like LogFatal but allows specifying a message format.
-func (l LogrusLogger) Fatalf(format string, args ...interface{}) {
-	l.logger.Fatalf(format, args...)
-}
+		fields[key] = keysAndValues[i+1]
+	}
 
-// Panicf is like LogPanic but allows specifying a message format.
-func (l LogrusLogger) Panicf(format string, args ...interface{}) {
-	l.logger.Panicf(format, args...)
+	return fields
 }
 
 // NewLogrusLogger initializes a new LogrusLogger.
@@ -153,5 +97,5 @@ func NewLogrusLogger(options LogrusLoggerOptions) *LogrusLogger {
 		logrusLogger.SetFormatter(&logrus.JSONFormatter{})
 	}
 
-	return &LogrusLogger{logger: logrusLogger}
+	return &LogrusLogger{entry: logrus.NewEntry(logrusLogger)}
 }
@@ -0,0 +1,32 @@
+package wspubsub_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogrusLogger(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	options := wspubsub.NewLogrusLoggerOptions()
+	options.Level = wspubsub.LogrusLevelDebug
+	options.Formatter = wspubsub.LogrusFormatterJSON
+	options.Output = output
+
+	logger := wspubsub.NewLogrusLogger(options).With(wspubsub.LogFieldRemoteAddr, "127.0.0.1:1234")
+	logger.Warn("Client subscribed", wspubsub.LogFieldClientID, clientID, wspubsub.LogFieldChannel, "X", "odd")
+
+	entry := map[string]interface{}{}
+	err := json.Unmarshal(output.Bytes(), &entry)
+	require.NoError(t, err)
+	require.Equal(t, "Client subscribed", entry["msg"])
+	require.Equal(t, "warning", entry["level"])
+	require.Equal(t, clientID.String(), entry["client_id"])
+	require.Equal(t, "X", entry["channel"])
+	require.Equal(t, "127.0.0.1:1234", entry["remote_addr"])
+	require.Equal(t, "odd", entry["!BADKEY"])
+}
@@ -0,0 +1,289 @@
+package wspubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ WebsocketConnection       = (*LongPollConnection)(nil)
+	_ WebsocketConnectionBinder = (*LongPollConnection)(nil)
+	_ session                   = (*LongPollConnection)(nil)
+)
+
+// LongPollConnection is an implementation of WebsocketConnection over HTTP long-polling.
+// Downstream messages are buffered until the client polls them.
+// Pings are not sent, since polls keep the session alive.
+//
+// The connection is closed if the client doesn't poll within the session timeout.
+// Messages written before closing (e.g. a close message) are still returned
+// by the next poll until the session timeout elapses.
+type LongPollConnection struct {
+	options      LongPollConnectionUpgraderOptions
+	sessions     *sessionRegistry
+	response     http.ResponseWriter
+	remoteAddr   string
+	clientID     UUID
+	token        string
+	outbound     chan Message
+	inbound      chan Message
+	polling      atomic.Bool
+	sessionTimer *time.Timer
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+type longPollMessage struct {
+	Type   string    `json:"type"`
+	Data   string    `json:"data,omitempty"`
+	Code   CloseCode `json:"code,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+type longPollResponse struct {
+	Messages []longPollMessage `json:"messages"`
+}
+
+// Bind registers the connection to receive polls and upstream messages of the client
+// and responds to the connection request with the session.
+func (c *LongPollConnection) Bind(clientID UUID) error {
+	c.clientID = clientID
+	c.sessionTimer = time.AfterFunc(c.options.SessionTimeout, c.expire)
+	c.sessions.register(clientID, c)
+
+	response := c.response
+	c.response = nil
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.WriteHeader(http.StatusOK)
+
+	err := json.NewEncoder(response).Encode(sessionInfo{ClientID: clientID, Token: c.token})
+	if err != nil {
+		return errors.WithStack(NewConnectionClosedError(err))
+	}
+
+	return nil
+}
+
+// Read returns the next upstream message.
+func (c *LongPollConnection) Read() (Message, error) {
+	select {
+	case message := <-c.inbound:
+		return message, nil
+	case <-c.closed:
+		return Message{}, errors.WithStack(NewConnectionClosedError(io.EOF))
+	}
+}
+
+// Write buffers a message until the next poll.
+// It blocks while the buffer is full, but not longer than the write timeout.
+func (c *LongPollConnection) Write(message Message) (err error) {
+	op := startOperation(
+		c.options.Observer,
+		OperationEvent{Name: "wspubsub.long_poll_connection.write", RemoteAddr: c.remoteAddr, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	if message.Type == MessageTypePing {
+		return nil
+	}
+
+	if c.options.Metrics != nil {
+		now := time.Now()
+		defer func() {
+			c.options.Metrics.ConnectionWritten("long_polling", time.Since(now))
+		}()
+	}
+
+	select {
+	case <-c.closed:
+		return errors.WithStack(NewConnectionClosedError(io.EOF))
+	default:
+	}
+
+	timer := time.NewTimer(c.options.WriteTimeout)
+	defer timer.Stop()
+
+	select {
+	case c.outbound <- message:
+		return nil
+	case <-c.closed:
+		return errors.WithStack(NewConnectionClosedError(io.EOF))
+	case <-timer.C:
+		return errors.New("wspubsub: long-polling outbound buffer is full")
+	}
+}
+
+// Close closes the connection.
+// The session stays registered until buffered messages are polled or the session timeout elapses.
+func (c *LongPollConnection) Close() (err error) {
+	op := startOperation(
+		c.options.Observer,
+		OperationEvent{Name: "wspubsub.long_poll_connection.close", RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		if len(c.outbound) == 0 {
+			c.sessions.unregister(c.clientID, c)
+		}
+	})
+
+	return nil
+}
+
+// RTT always returns zero since long-polling has no pongs.
+func (c *LongPollConnection) RTT() time.Duration {
+	return 0
+}
+
+func (c *LongPollConnection) sessionToken() string {
+	return c.token
+}
+
+// deliver passes an upstream message to the reader.
+func (c *LongPollConnection) deliver(message Message) bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+	}
+
+	select {
+	case c.inbound <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// poll waits for buffered messages and responds with them.
+// Only one poll of a session may be in progress.
+func (c *LongPollConnection) poll(w http.ResponseWriter, r *http.Request) {
+	if !c.polling.CompareAndSwap(false, true) {
+		http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+
+		return
+	}
+
+	c.sessionTimer.Stop()
+	defer func() {
+		c.polling.Store(false)
+		c.sessionTimer.Reset(c.options.SessionTimeout)
+	}()
+
+	messages := c.wait(r.Context())
+	if len(messages) == 0 && c.isClosed() {
+		c.sessions.unregister(c.clientID, c)
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+
+		return
+	}
+
+	response := longPollResponse{Messages: make([]longPollMessage, 0, len(messages))}
+	for _, message := range messages {
+		response.Messages = append(response.Messages, newLongPollMessage(message))
+	}
+
+	// Buffered messages are delivered, so the closed session isn't needed anymore
+	if c.isClosed() && len(c.outbound) == 0 {
+		c.sessions.unregister(c.clientID, c)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// wait returns buffered messages or waits for the first one until the poll timeout elapses.
+func (c *LongPollConnection) wait(ctx context.Context) []Message {
+	messages := c.drain(nil)
+	if len(messages) > 0 || c.isClosed() {
+		return messages
+	}
+
+	timer := time.NewTimer(c.options.PollTimeout)
+	defer timer.Stop()
+
+	select {
+	case message := <-c.outbound:
+		return c.drain(append(messages, message))
+	case <-c.closed:
+		return c.drain(messages)
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	return messages
+}
+
+func (c *LongPollConnection) drain(messages []Message) []Message {
+	for len(messages) < c.options.MaxBatchSize {
+		select {
+		case message := <-c.outbound:
+			messages = append(messages, message)
+		default:
+			return messages
+		}
+	}
+
+	return messages
+}
+
+// expire closes the session which wasn't polled in time.
+func (c *LongPollConnection) expire() {
+	_ = c.Close()
+	c.sessions.unregister(c.clientID, c)
+}
+
+func (c *LongPollConnection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func newLongPollMessage(message Message) longPollMessage {
+	switch message.Type {
+	case MessageTypeBinary:
+		return longPollMessage{Type: "binary", Data: base64.StdEncoding.EncodeToString(message.Payload)}
+	case MessageTypeClose:
+		code, reason := parseClosePayload(message.Payload)
+
+		return longPollMessage{Type: "close", Code: code, Reason: reason}
+	default:
+		return longPollMessage{Type: "text", Data: string(message.Payload)}
+	}
+}
+
+func newLongPollConnection(
+	w http.ResponseWriter,
+	remoteAddr string,
+	sessions *sessionRegistry,
+	token string,
+	options LongPollConnectionUpgraderOptions,
+) *LongPollConnection {
+	return &LongPollConnection{
+		options:    options,
+		sessions:   sessions,
+		response:   w,
+		remoteAddr: remoteAddr,
+		token:      token,
+		outbound:   make(chan Message, options.OutboundBufferSize),
+		inbound:    make(chan Message, options.InboundBufferSize),
+		closed:     make(chan struct{}),
+	}
+}
@@ -0,0 +1,83 @@
+package wspubsub
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+var _ WebsocketConnectionUpgrader = (*LongPollConnectionUpgrader)(nil)
+
+// LongPollConnectionUpgrader is an implementation of WebsocketConnectionUpgrader
+// which serves clients over HTTP long-polling, when neither WebSocket nor SSE are available.
+// The connection request responds with the session:
+//
+//	{"client_id":"<client_id>","token":"<token>"}
+//
+// Downstream messages are polled by GET requests to PollHandler:
+//
+//	GET /poll?client_id=<client_id>
+//	X-Wspubsub-Token: <token> (see SessionTokenHeader)
+//
+// A poll is held until a message is available or the poll timeout elapses and responds with a batch like:
+//
+//	{"messages":[{"type":"text","data":"..."},{"type":"binary","data":"<base64>"},{"type":"close","code":1001,"reason":"..."}]}
+//
+// The batch is empty if the poll timed out. The 410 status code means the session is closed.
+// Upstream messages are sent by POST requests to MessageHandler like in SSEConnectionUpgrader.
+type LongPollConnectionUpgrader struct {
+	options  LongPollConnectionUpgraderOptions
+	sessions sessionRegistry
+}
+
+// Upgrade starts a session.
+func (u *LongPollConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.long_poll_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		return nil, errors.Errorf("wspubsub: invalid method of long-polling request: %s", r.Method)
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return newLongPollConnection(w, r.RemoteAddr, &u.sessions, token, u.options), nil
+}
+
+// PollHandler returns a handler of polls.
+func (u *LongPollConnectionUpgrader) PollHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		s, ok := u.sessions.lookup(w, r)
+		if !ok {
+			return
+		}
+
+		s.(*LongPollConnection).poll(w, r)
+	})
+}
+
+// MessageHandler returns a handler of upstream messages.
+// It responds with 202 status code if the message is accepted.
+func (u *LongPollConnectionUpgrader) MessageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.sessions.serveMessage(w, r, u.options.MaxMessageSize)
+	})
+}
+
+// NewLongPollConnectionUpgrader initializes a new LongPollConnectionUpgrader.
+func NewLongPollConnectionUpgrader(options LongPollConnectionUpgraderOptions) *LongPollConnectionUpgrader {
+	return &LongPollConnectionUpgrader{options: options}
+}
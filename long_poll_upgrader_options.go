@@ -0,0 +1,50 @@
+package wspubsub
+
+import (
+	"time"
+)
+
+// LongPollConnectionUpgraderOptions represents configuration of the LongPollConnectionUpgrader.
+type LongPollConnectionUpgraderOptions struct {
+	// Max duration of holding a poll request until a message is available
+	PollTimeout time.Duration
+
+	// Max duration between polls.
+	// A session without a poll in progress is closed when it elapses.
+	SessionTimeout time.Duration
+
+	// Max duration of waiting for a room in the outbound buffer
+	WriteTimeout time.Duration
+
+	// Number of downstream messages buffered between polls
+	OutboundBufferSize int
+
+	// Number of upstream messages buffered until the client reads them.
+	// Exceeding it causes rejecting of a message with 429 status code.
+	InboundBufferSize int
+
+	// Max number of messages returned by a poll
+	MaxBatchSize int
+
+	// Max size of an upstream message in bytes
+	MaxMessageSize int64
+
+	Metrics  MetricsCollector
+	Observer Observer
+}
+
+// NewLongPollConnectionUpgraderOptions initializes a new LongPollConnectionUpgraderOptions.
+// nolint: gomnd
+func NewLongPollConnectionUpgraderOptions() LongPollConnectionUpgraderOptions {
+	options := LongPollConnectionUpgraderOptions{
+		PollTimeout:        25 * time.Second,
+		SessionTimeout:     30 * time.Second,
+		WriteTimeout:       10 * time.Second,
+		OutboundBufferSize: 256,
+		InboundBufferSize:  256,
+		MaxBatchSize:       100,
+		MaxMessageSize:     1 * 1024 * 1024,
+	}
+
+	return options
+}
@@ -0,0 +1,21 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLongPollConnectionUpgraderOptions(t *testing.T) {
+	options := wspubsub.NewLongPollConnectionUpgraderOptions()
+	require.NotZero(t, options.PollTimeout)
+	require.Greater(t, options.SessionTimeout, options.PollTimeout)
+	require.NotZero(t, options.WriteTimeout)
+	require.NotZero(t, options.OutboundBufferSize)
+	require.NotZero(t, options.InboundBufferSize)
+	require.NotZero(t, options.MaxBatchSize)
+	require.NotZero(t, options.MaxMessageSize)
+	require.Nil(t, options.Metrics)
+	require.Nil(t, options.Observer)
+}
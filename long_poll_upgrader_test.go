@@ -0,0 +1,306 @@
+package wspubsub_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+type longPollMessage struct {
+	Type   string `json:"type"`
+	Data   string `json:"data"`
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type longPollSession struct {
+	ClientID wspubsub.UUID `json:"client_id"`
+	Token    string        `json:"token"`
+}
+
+func TestLongPollConnectionUpgrader(t *testing.T) {
+	options := wspubsub.NewLongPollConnectionUpgraderOptions()
+	options.PollTimeout = 100 * time.Millisecond
+	upgrader := wspubsub.NewLongPollConnectionUpgrader(options)
+	hub, server, connected := newLongPollTestServer(t, upgrader)
+
+	received := make(chan wspubsub.Message, 1)
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+	})
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	session := openLongPollSession(t, server.URL)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+	require.Equal(t, 1, hub.Count())
+
+	t.Run("Poll timeout", func(t *testing.T) {
+		status, messages := pollLongPollMessages(t, server.URL, session)
+		require.Equal(t, http.StatusOK, status)
+		require.Empty(t, messages)
+	})
+
+	t.Run("Poll buffered messages", func(t *testing.T) {
+		err := hub.Subscribe(session.ClientID, "X")
+		require.NoError(t, err)
+
+		_, err = hub.Publish(wspubsub.NewTextMessageFromString("hello"), "X")
+		require.NoError(t, err)
+		err = hub.Send(session.ClientID, wspubsub.NewBinaryMessage([]byte{1, 2, 3}))
+		require.NoError(t, err)
+
+		var messages []longPollMessage
+		require.Eventually(t, func() bool {
+			_, batch := pollLongPollMessages(t, server.URL, session)
+			messages = append(messages, batch...)
+
+			return len(messages) == 2
+		}, time.Second, time.Millisecond)
+
+		require.Equal(t, longPollMessage{Type: "text", Data: "hello"}, messages[0])
+		require.Equal(t, "binary", messages[1].Type)
+
+		payload, err := base64.StdEncoding.DecodeString(messages[1].Data)
+		require.NoError(t, err)
+		require.Equal(t, []byte{1, 2, 3}, payload)
+	})
+
+	t.Run("Wait for message", func(t *testing.T) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_ = hub.Send(session.ClientID, wspubsub.NewTextMessageFromString("later"))
+		}()
+
+		status, messages := pollLongPollMessages(t, server.URL, session)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []longPollMessage{{Type: "text", Data: "later"}}, messages)
+	})
+
+	t.Run("Reject concurrent poll", func(t *testing.T) {
+		statuses := make(chan int, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				status, _ := pollLongPollMessages(t, server.URL, session)
+				statuses <- status
+			}()
+		}
+
+		actual := []int{<-statuses, <-statuses}
+		require.ElementsMatch(t, []int{http.StatusOK, http.StatusConflict}, actual)
+	})
+
+	t.Run("Receive message", func(t *testing.T) {
+		status := postLongPollMessage(t, server.URL, session, "text/plain", "hello")
+		require.Equal(t, http.StatusAccepted, status)
+		require.Equal(t, wspubsub.NewTextMessageFromString("hello"), receiveMessage(t, received))
+	})
+
+	t.Run("Reject invalid token", func(t *testing.T) {
+		status := postLongPollMessage(t, server.URL, longPollSession{ClientID: session.ClientID}, "text/plain", "hello")
+		require.Equal(t, http.StatusForbidden, status)
+
+		status, _ = pollLongPollMessages(t, server.URL, longPollSession{ClientID: session.ClientID})
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Reject unknown client", func(t *testing.T) {
+		status, _ := pollLongPollMessages(t, server.URL, longPollSession{ClientID: wspubsub.UUID{1}, Token: session.Token})
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Reject invalid method", func(t *testing.T) {
+		response, err := http.Post(server.URL+"/poll?client_id="+session.ClientID.String(), "text/plain", nil)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+	})
+
+	t.Run("Disconnect with code", func(t *testing.T) {
+		err := hub.DisconnectWithCode(session.ClientID, wspubsub.CloseCodeGoingAway, "bye")
+		require.NoError(t, err)
+		require.Equal(t, session.ClientID, receiveClientID(t, disconnected))
+
+		status, messages := pollLongPollMessages(t, server.URL, session)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []longPollMessage{{Type: "close", Code: 1001, Reason: "bye"}}, messages)
+
+		status, _ = pollLongPollMessages(t, server.URL, session)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestLongPollConnectionUpgrader_SessionTimeout(t *testing.T) {
+	options := wspubsub.NewLongPollConnectionUpgraderOptions()
+	options.SessionTimeout = 50 * time.Millisecond
+	upgrader := wspubsub.NewLongPollConnectionUpgrader(options)
+	hub, server, connected := newLongPollTestServer(t, upgrader)
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	session := openLongPollSession(t, server.URL)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+	require.Equal(t, session.ClientID, receiveClientID(t, disconnected))
+	require.Equal(t, 0, hub.Count())
+
+	status, _ := pollLongPollMessages(t, server.URL, session)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestLongPollConnectionUpgrader_WriteTimeout(t *testing.T) {
+	options := wspubsub.NewLongPollConnectionUpgraderOptions()
+	options.OutboundBufferSize = 1
+	options.WriteTimeout = 10 * time.Millisecond
+	upgrader := wspubsub.NewLongPollConnectionUpgrader(options)
+	hub, server, connected := newLongPollTestServer(t, upgrader)
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	session := openLongPollSession(t, server.URL)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+
+	err := hub.Send(session.ClientID, wspubsub.NewTextMessageFromString("1"))
+	require.NoError(t, err)
+	err = hub.Send(session.ClientID, wspubsub.NewTextMessageFromString("2"))
+	require.NoError(t, err)
+	require.Equal(t, session.ClientID, receiveClientID(t, disconnected))
+
+	// The buffered message is still delivered to the closed session
+	status, messages := pollLongPollMessages(t, server.URL, session)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []longPollMessage{{Type: "text", Data: "1"}}, messages)
+}
+
+func TestLongPollConnectionUpgrader_InvalidMethod(t *testing.T) {
+	upgrader := wspubsub.NewLongPollConnectionUpgrader(wspubsub.NewLongPollConnectionUpgraderOptions())
+	hub, server, _ := newLongPollTestServer(t, upgrader)
+
+	request, err := http.NewRequest(http.MethodPut, server.URL, nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	require.Equal(t, 0, hub.Count())
+}
+
+// newLongPollTestServer serves the hub with sessions at "/", polls at "/poll" and upstream messages at "/messages".
+// IDs of connected clients are sent to the returned channel.
+func newLongPollTestServer(
+	t *testing.T,
+	upgrader *wspubsub.LongPollConnectionUpgrader,
+) (*wspubsub.Hub, *httptest.Server, chan wspubsub.UUID) {
+	t.Helper()
+
+	logger := wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := wspubsub.NewClientFactory(wspubsub.NewClientOptions(), wspubsub.SatoriUUIDGenerator{}, upgrader, nil)
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	connected := make(chan wspubsub.UUID, 1)
+	hub.OnConnect(func(clientID wspubsub.UUID) {
+		connected <- clientID
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/", hub)
+	mux.Handle("/poll", upgrader.PollHandler())
+	mux.Handle("/messages", upgrader.MessageHandler())
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		_ = hub.Close()
+		server.Close()
+	})
+
+	return hub, server, connected
+}
+
+func openLongPollSession(t *testing.T, url string) longPollSession {
+	t.Helper()
+
+	response, err := http.Post(url, "text/plain", nil)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	session := longPollSession{}
+	err = json.NewDecoder(response.Body).Decode(&session)
+	require.NoError(t, err)
+	require.NotEmpty(t, session.Token)
+
+	return session
+}
+
+// pollLongPollMessages can be called from a non-test goroutine, so it doesn't stop the test on errors.
+func pollLongPollMessages(t *testing.T, url string, session longPollSession) (int, []longPollMessage) {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, url+"/poll?client_id="+session.ClientID.String(), nil)
+	if err != nil {
+		t.Error(err)
+
+		return 0, nil
+	}
+
+	request.Header.Set(wspubsub.SessionTokenHeader, session.Token)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Error(err)
+
+		return 0, nil
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return response.StatusCode, nil
+	}
+
+	batch := struct {
+		Messages []longPollMessage `json:"messages"`
+	}{}
+
+	err = json.NewDecoder(response.Body).Decode(&batch)
+	if err != nil {
+		t.Error(err)
+	}
+
+	return response.StatusCode, batch.Messages
+}
+
+func postLongPollMessage(t *testing.T, url string, session longPollSession, contentType, body string) int {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodPost, url+"/messages?client_id="+session.ClientID.String(), strings.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set(wspubsub.SessionTokenHeader, session.Token)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	return response.StatusCode
+}
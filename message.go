@@ -1,18 +1,62 @@
 package wspubsub
 
+import (
+	"encoding/binary"
+	"time"
+)
+
 // MessageType enumerates possible message types.
 type MessageType byte
 
 const (
 	MessageTypeText   MessageType = 1
 	MessageTypeBinary MessageType = 2
+	MessageTypeClose  MessageType = 8
 	MessageTypePing   MessageType = 9
 )
 
+// CloseCode enumerates status codes sent within a close message (see RFC 6455, section 7.4).
+type CloseCode uint16
+
+const (
+	CloseCodeNormalClosure   CloseCode = 1000
+	CloseCodeGoingAway       CloseCode = 1001
+	CloseCodePolicyViolation CloseCode = 1008
+	CloseCodeInternalError   CloseCode = 1011
+
+	// CloseCodeCredentialsExpired is sent when a client didn't refresh its credentials in time.
+	CloseCodeCredentialsExpired CloseCode = 4001
+)
+
 // Message represents a data type to send over a WebSocket connection.
 type Message struct {
 	Type    MessageType
 	Payload []byte
+
+	// Refers to the latest message of a conflated channel (see Client.SendConflated)
+	conflationKey string
+
+	// Time after which the message isn't written to a connection (zero means never)
+	expiresAt time.Time
+}
+
+// ExpiresAt returns the time after which the message is dropped instead of being written
+// to a connection (zero if the message doesn't expire).
+func (m Message) ExpiresAt() time.Time {
+	return m.expiresAt
+}
+
+// WithExpiry returns a copy of the message which expires at the time.
+// Expired messages waiting in a client send buffer are dropped (see ClientOptions.MessageTTL).
+func (m Message) WithExpiry(expiresAt time.Time) Message {
+	m.expiresAt = expiresAt
+
+	return m
+}
+
+// WithTTL returns a copy of the message which expires after the duration from now.
+func (m Message) WithTTL(ttl time.Duration) Message {
+	return m.WithExpiry(time.Now().Add(ttl))
 }
 
 // NewTextMessage initializes a new text Message from bytes.
@@ -39,3 +83,21 @@ func NewBinaryMessageFromString(payload string) Message {
 func NewPingMessage() Message {
 	return Message{Type: MessageTypePing}
 }
+
+// NewCloseMessage initializes a new close Message with status code and reason.
+func NewCloseMessage(code CloseCode, reason string) Message {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+
+	return Message{Type: MessageTypeClose, Payload: payload}
+}
+
+// parseClosePayload returns the status code and reason of a close message payload.
+func parseClosePayload(payload []byte) (CloseCode, string) {
+	if len(payload) < 2 {
+		return CloseCodeNormalClosure, ""
+	}
+
+	return CloseCode(binary.BigEndian.Uint16(payload)), string(payload[2:])
+}
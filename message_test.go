@@ -2,6 +2,7 @@ package wspubsub_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kpeu3i/wspubsub"
 	"github.com/stretchr/testify/require"
@@ -30,3 +31,23 @@ func TestNewBinaryMessage(t *testing.T) {
 	require.Equal(t, wspubsub.MessageTypeBinary, message.Type)
 	require.Equal(t, []byte(s), message.Payload)
 }
+
+func TestNewCloseMessage(t *testing.T) {
+	message := wspubsub.NewCloseMessage(wspubsub.CloseCodePolicyViolation, "TEST")
+	require.Equal(t, wspubsub.MessageTypeClose, message.Type)
+	require.Equal(t, []byte{0x03, 0xf0, 'T', 'E', 'S', 'T'}, message.Payload)
+}
+
+func TestMessage_Expiry(t *testing.T) {
+	message := wspubsub.NewTextMessageFromString("TEST")
+	require.True(t, message.ExpiresAt().IsZero())
+
+	expiresAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	messageWithExpiry := message.WithExpiry(expiresAt)
+	require.Equal(t, expiresAt, messageWithExpiry.ExpiresAt())
+	require.Equal(t, message.Payload, messageWithExpiry.Payload)
+	require.True(t, message.ExpiresAt().IsZero())
+
+	messageWithTTL := message.WithTTL(time.Minute)
+	require.WithinDuration(t, time.Now().Add(time.Minute), messageWithTTL.ExpiresAt(), time.Second)
+}
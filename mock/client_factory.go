@@ -7,6 +7,7 @@ package mock
 import (
 	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	wspubsub "github.com/kpeu3i/wspubsub"
@@ -116,6 +117,80 @@ func (mr *MockWebsocketConnectionMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockWebsocketConnection)(nil).Close))
 }
 
+// MockWebsocketConnectionBinder is a mock of WebsocketConnectionBinder interface
+type MockWebsocketConnectionBinder struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebsocketConnectionBinderMockRecorder
+}
+
+// MockWebsocketConnectionBinderMockRecorder is the mock recorder for MockWebsocketConnectionBinder
+type MockWebsocketConnectionBinderMockRecorder struct {
+	mock *MockWebsocketConnectionBinder
+}
+
+// NewMockWebsocketConnectionBinder creates a new mock instance
+func NewMockWebsocketConnectionBinder(ctrl *gomock.Controller) *MockWebsocketConnectionBinder {
+	mock := &MockWebsocketConnectionBinder{ctrl: ctrl}
+	mock.recorder = &MockWebsocketConnectionBinderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebsocketConnectionBinder) EXPECT() *MockWebsocketConnectionBinderMockRecorder {
+	return m.recorder
+}
+
+// Bind mocks base method
+func (m *MockWebsocketConnectionBinder) Bind(clientID wspubsub.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Bind", clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Bind indicates an expected call of Bind
+func (mr *MockWebsocketConnectionBinderMockRecorder) Bind(clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bind", reflect.TypeOf((*MockWebsocketConnectionBinder)(nil).Bind), clientID)
+}
+
+// MockWebsocketConnectionRTTMeter is a mock of WebsocketConnectionRTTMeter interface
+type MockWebsocketConnectionRTTMeter struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebsocketConnectionRTTMeterMockRecorder
+}
+
+// MockWebsocketConnectionRTTMeterMockRecorder is the mock recorder for MockWebsocketConnectionRTTMeter
+type MockWebsocketConnectionRTTMeterMockRecorder struct {
+	mock *MockWebsocketConnectionRTTMeter
+}
+
+// NewMockWebsocketConnectionRTTMeter creates a new mock instance
+func NewMockWebsocketConnectionRTTMeter(ctrl *gomock.Controller) *MockWebsocketConnectionRTTMeter {
+	mock := &MockWebsocketConnectionRTTMeter{ctrl: ctrl}
+	mock.recorder = &MockWebsocketConnectionRTTMeterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebsocketConnectionRTTMeter) EXPECT() *MockWebsocketConnectionRTTMeterMockRecorder {
+	return m.recorder
+}
+
+// RTT mocks base method
+func (m *MockWebsocketConnectionRTTMeter) RTT() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RTT")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// RTT indicates an expected call of RTT
+func (mr *MockWebsocketConnectionRTTMeterMockRecorder) RTT() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RTT", reflect.TypeOf((*MockWebsocketConnectionRTTMeter)(nil).RTT))
+}
+
 // MockUUIDGenerator is a mock of UUIDGenerator interface
 type MockUUIDGenerator struct {
 	ctrl     *gomock.Controller
@@ -5,8 +5,10 @@
 package mock
 
 import (
+	context "context"
 	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	wspubsub "github.com/kpeu3i/wspubsub"
@@ -115,6 +117,62 @@ func (mr *MockWebsocketClientMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockWebsocketClient)(nil).Close))
 }
 
+// CloseWithCode mocks base method
+func (m *MockWebsocketClient) CloseWithCode(code wspubsub.CloseCode, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseWithCode", code, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseWithCode indicates an expected call of CloseWithCode
+func (mr *MockWebsocketClientMockRecorder) CloseWithCode(code, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseWithCode", reflect.TypeOf((*MockWebsocketClient)(nil).CloseWithCode), code, reason)
+}
+
+// QueueDepth mocks base method
+func (m *MockWebsocketClient) QueueDepth() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueueDepth")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// QueueDepth indicates an expected call of QueueDepth
+func (mr *MockWebsocketClientMockRecorder) QueueDepth() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueDepth", reflect.TypeOf((*MockWebsocketClient)(nil).QueueDepth))
+}
+
+// RTT mocks base method
+func (m *MockWebsocketClient) RTT() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RTT")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// RTT indicates an expected call of RTT
+func (mr *MockWebsocketClientMockRecorder) RTT() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RTT", reflect.TypeOf((*MockWebsocketClient)(nil).RTT))
+}
+
+// Stats mocks base method
+func (m *MockWebsocketClient) Stats() wspubsub.ClientStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(wspubsub.ClientStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats
+func (mr *MockWebsocketClientMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockWebsocketClient)(nil).Stats))
+}
+
 // MockWebsocketClientStore is a mock of WebsocketClientStore interface
 type MockWebsocketClientStore struct {
 	ctrl     *gomock.Controller
@@ -197,6 +255,20 @@ func (mr *MockWebsocketClientStoreMockRecorder) Count(channels ...interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockWebsocketClientStore)(nil).Count), channels...)
 }
 
+// CountByChannel mocks base method
+func (m *MockWebsocketClientStore) CountByChannel() map[string]int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByChannel")
+	ret0, _ := ret[0].(map[string]int)
+	return ret0
+}
+
+// CountByChannel indicates an expected call of CountByChannel
+func (mr *MockWebsocketClientStoreMockRecorder) CountByChannel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByChannel", reflect.TypeOf((*MockWebsocketClientStore)(nil).CountByChannel))
+}
+
 // Find mocks base method
 func (m *MockWebsocketClientStore) Find(fn wspubsub.IterateFunc, channels ...string) error {
 	m.ctrl.T.Helper()
@@ -284,6 +356,30 @@ func (mr *MockWebsocketClientStoreMockRecorder) UnsetChannels(clientID interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsetChannels", reflect.TypeOf((*MockWebsocketClientStore)(nil).UnsetChannels), varargs...)
 }
 
+// OnChannelCreated mocks base method
+func (m *MockWebsocketClientStore) OnChannelCreated(handler wspubsub.ChannelHandler) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnChannelCreated", handler)
+}
+
+// OnChannelCreated indicates an expected call of OnChannelCreated
+func (mr *MockWebsocketClientStoreMockRecorder) OnChannelCreated(handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnChannelCreated", reflect.TypeOf((*MockWebsocketClientStore)(nil).OnChannelCreated), handler)
+}
+
+// OnChannelEmptied mocks base method
+func (m *MockWebsocketClientStore) OnChannelEmptied(handler wspubsub.ChannelHandler) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnChannelEmptied", handler)
+}
+
+// OnChannelEmptied indicates an expected call of OnChannelEmptied
+func (mr *MockWebsocketClientStoreMockRecorder) OnChannelEmptied(handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnChannelEmptied", reflect.TypeOf((*MockWebsocketClientStore)(nil).OnChannelEmptied), handler)
+}
+
 // MockWebsocketClientFactory is a mock of WebsocketClientFactory interface
 type MockWebsocketClientFactory struct {
 	ctrl     *gomock.Controller
@@ -321,368 +417,496 @@ func (mr *MockWebsocketClientFactoryMockRecorder) Create() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebsocketClientFactory)(nil).Create))
 }
 
-// MockLogger is a mock of Logger interface
-type MockLogger struct {
+// MockAuthenticator is a mock of Authenticator interface
+type MockAuthenticator struct {
 	ctrl     *gomock.Controller
-	recorder *MockLoggerMockRecorder
+	recorder *MockAuthenticatorMockRecorder
 }
 
-// MockLoggerMockRecorder is the mock recorder for MockLogger
-type MockLoggerMockRecorder struct {
-	mock *MockLogger
+// MockAuthenticatorMockRecorder is the mock recorder for MockAuthenticator
+type MockAuthenticatorMockRecorder struct {
+	mock *MockAuthenticator
 }
 
-// NewMockLogger creates a new mock instance
-func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
-	mock := &MockLogger{ctrl: ctrl}
-	mock.recorder = &MockLoggerMockRecorder{mock}
+// NewMockAuthenticator creates a new mock instance
+func NewMockAuthenticator(ctrl *gomock.Controller) *MockAuthenticator {
+	mock := &MockAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockAuthenticatorMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use
-func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
+func (m *MockAuthenticator) EXPECT() *MockAuthenticatorMockRecorder {
 	return m.recorder
 }
 
-// Debug mocks base method
-func (m *MockLogger) Debug(args ...interface{}) {
+// Authenticate mocks base method
+func (m *MockAuthenticator) Authenticate(request *http.Request) (wspubsub.Identity, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Debug", varargs...)
+	ret := m.ctrl.Call(m, "Authenticate", request)
+	ret0, _ := ret[0].(wspubsub.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Debug indicates an expected call of Debug
-func (mr *MockLoggerMockRecorder) Debug(args ...interface{}) *gomock.Call {
+// Authenticate indicates an expected call of Authenticate
+func (mr *MockAuthenticatorMockRecorder) Authenticate(request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockLogger)(nil).Debug), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthenticator)(nil).Authenticate), request)
 }
 
-// Info mocks base method
-func (m *MockLogger) Info(args ...interface{}) {
+// MockAuthorizer is a mock of Authorizer interface
+type MockAuthorizer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizerMockRecorder
+}
+
+// MockAuthorizerMockRecorder is the mock recorder for MockAuthorizer
+type MockAuthorizerMockRecorder struct {
+	mock *MockAuthorizer
+}
+
+// NewMockAuthorizer creates a new mock instance
+func NewMockAuthorizer(ctrl *gomock.Controller) *MockAuthorizer {
+	mock := &MockAuthorizer{ctrl: ctrl}
+	mock.recorder = &MockAuthorizerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAuthorizer) EXPECT() *MockAuthorizerMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method
+func (m *MockAuthorizer) Authorize(identity wspubsub.Identity, channels ...string) error {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
+	varargs := []interface{}{identity}
+	for _, a := range channels {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Info", varargs...)
+	ret := m.ctrl.Call(m, "Authorize", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// Info indicates an expected call of Info
-func (mr *MockLoggerMockRecorder) Info(args ...interface{}) *gomock.Call {
+// Authorize indicates an expected call of Authorize
+func (mr *MockAuthorizerMockRecorder) Authorize(identity interface{}, channels ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogger)(nil).Info), args...)
+	varargs := append([]interface{}{identity}, channels...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockAuthorizer)(nil).Authorize), varargs...)
+}
+
+// MockMetricsCollector is a mock of MetricsCollector interface
+type MockMetricsCollector struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsCollectorMockRecorder
+}
+
+// MockMetricsCollectorMockRecorder is the mock recorder for MockMetricsCollector
+type MockMetricsCollectorMockRecorder struct {
+	mock *MockMetricsCollector
+}
+
+// NewMockMetricsCollector creates a new mock instance
+func NewMockMetricsCollector(ctrl *gomock.Controller) *MockMetricsCollector {
+	mock := &MockMetricsCollector{ctrl: ctrl}
+	mock.recorder = &MockMetricsCollectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMetricsCollector) EXPECT() *MockMetricsCollectorMockRecorder {
+	return m.recorder
 }
 
-// Print mocks base method
-func (m *MockLogger) Print(args ...interface{}) {
+// ClientConnected mocks base method
+func (m *MockMetricsCollector) ClientConnected() {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Print", varargs...)
+	m.ctrl.Call(m, "ClientConnected")
 }
 
-// Print indicates an expected call of Print
-func (mr *MockLoggerMockRecorder) Print(args ...interface{}) *gomock.Call {
+// ClientConnected indicates an expected call of ClientConnected
+func (mr *MockMetricsCollectorMockRecorder) ClientConnected() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Print", reflect.TypeOf((*MockLogger)(nil).Print), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientConnected", reflect.TypeOf((*MockMetricsCollector)(nil).ClientConnected))
 }
 
-// Warn mocks base method
-func (m *MockLogger) Warn(args ...interface{}) {
+// ClientDisconnected mocks base method
+func (m *MockMetricsCollector) ClientDisconnected(reason wspubsub.DisconnectReason) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Warn", varargs...)
+	m.ctrl.Call(m, "ClientDisconnected", reason)
 }
 
-// Warn indicates an expected call of Warn
-func (mr *MockLoggerMockRecorder) Warn(args ...interface{}) *gomock.Call {
+// ClientDisconnected indicates an expected call of ClientDisconnected
+func (mr *MockMetricsCollectorMockRecorder) ClientDisconnected(reason interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warn", reflect.TypeOf((*MockLogger)(nil).Warn), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDisconnected", reflect.TypeOf((*MockMetricsCollector)(nil).ClientDisconnected), reason)
 }
 
-// Error mocks base method
-func (m *MockLogger) Error(args ...interface{}) {
+// ChannelSubscribers mocks base method
+func (m *MockMetricsCollector) ChannelSubscribers(channel string, count int) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Error", varargs...)
+	m.ctrl.Call(m, "ChannelSubscribers", channel, count)
 }
 
-// Error indicates an expected call of Error
-func (mr *MockLoggerMockRecorder) Error(args ...interface{}) *gomock.Call {
+// ChannelSubscribers indicates an expected call of ChannelSubscribers
+func (mr *MockMetricsCollectorMockRecorder) ChannelSubscribers(channel, count interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockLogger)(nil).Error), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChannelSubscribers", reflect.TypeOf((*MockMetricsCollector)(nil).ChannelSubscribers), channel, count)
 }
 
-// Fatal mocks base method
-func (m *MockLogger) Fatal(args ...interface{}) {
+// MessagePublished mocks base method
+func (m *MockMetricsCollector) MessagePublished(numClients, size int, duration time.Duration) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Fatal", varargs...)
+	m.ctrl.Call(m, "MessagePublished", numClients, size, duration)
 }
 
-// Fatal indicates an expected call of Fatal
-func (mr *MockLoggerMockRecorder) Fatal(args ...interface{}) *gomock.Call {
+// MessagePublished indicates an expected call of MessagePublished
+func (mr *MockMetricsCollectorMockRecorder) MessagePublished(numClients, size, duration interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fatal", reflect.TypeOf((*MockLogger)(nil).Fatal), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessagePublished", reflect.TypeOf((*MockMetricsCollector)(nil).MessagePublished), numClients, size, duration)
 }
 
-// Panic mocks base method
-func (m *MockLogger) Panic(args ...interface{}) {
+// MessageSent mocks base method
+func (m *MockMetricsCollector) MessageSent(size int) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Panic", varargs...)
+	m.ctrl.Call(m, "MessageSent", size)
 }
 
-// Panic indicates an expected call of Panic
-func (mr *MockLoggerMockRecorder) Panic(args ...interface{}) *gomock.Call {
+// MessageSent indicates an expected call of MessageSent
+func (mr *MockMetricsCollectorMockRecorder) MessageSent(size interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Panic", reflect.TypeOf((*MockLogger)(nil).Panic), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageSent", reflect.TypeOf((*MockMetricsCollector)(nil).MessageSent), size)
 }
 
-// Debugln mocks base method
-func (m *MockLogger) Debugln(args ...interface{}) {
+// MessageReceived mocks base method
+func (m *MockMetricsCollector) MessageReceived(size int) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Debugln", varargs...)
+	m.ctrl.Call(m, "MessageReceived", size)
 }
 
-// Debugln indicates an expected call of Debugln
-func (mr *MockLoggerMockRecorder) Debugln(args ...interface{}) *gomock.Call {
+// MessageReceived indicates an expected call of MessageReceived
+func (mr *MockMetricsCollectorMockRecorder) MessageReceived(size interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debugln", reflect.TypeOf((*MockLogger)(nil).Debugln), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageReceived", reflect.TypeOf((*MockMetricsCollector)(nil).MessageReceived), size)
 }
 
-// Infoln mocks base method
-func (m *MockLogger) Infoln(args ...interface{}) {
+// MessageDropped mocks base method
+func (m *MockMetricsCollector) MessageDropped(reason wspubsub.DropReason) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Infoln", varargs...)
+	m.ctrl.Call(m, "MessageDropped", reason)
 }
 
-// Infoln indicates an expected call of Infoln
-func (mr *MockLoggerMockRecorder) Infoln(args ...interface{}) *gomock.Call {
+// MessageDropped indicates an expected call of MessageDropped
+func (mr *MockMetricsCollectorMockRecorder) MessageDropped(reason interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Infoln", reflect.TypeOf((*MockLogger)(nil).Infoln), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MessageDropped", reflect.TypeOf((*MockMetricsCollector)(nil).MessageDropped), reason)
 }
 
-// Println mocks base method
-func (m *MockLogger) Println(args ...interface{}) {
+// ConnectionWritten mocks base method
+func (m *MockMetricsCollector) ConnectionWritten(backend string, duration time.Duration) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Println", varargs...)
+	m.ctrl.Call(m, "ConnectionWritten", backend, duration)
 }
 
-// Println indicates an expected call of Println
-func (mr *MockLoggerMockRecorder) Println(args ...interface{}) *gomock.Call {
+// ConnectionWritten indicates an expected call of ConnectionWritten
+func (mr *MockMetricsCollectorMockRecorder) ConnectionWritten(backend, duration interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Println", reflect.TypeOf((*MockLogger)(nil).Println), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionWritten", reflect.TypeOf((*MockMetricsCollector)(nil).ConnectionWritten), backend, duration)
+}
+
+// MockObserver is a mock of Observer interface
+type MockObserver struct {
+	ctrl     *gomock.Controller
+	recorder *MockObserverMockRecorder
+}
+
+// MockObserverMockRecorder is the mock recorder for MockObserver
+type MockObserverMockRecorder struct {
+	mock *MockObserver
+}
+
+// NewMockObserver creates a new mock instance
+func NewMockObserver(ctrl *gomock.Controller) *MockObserver {
+	mock := &MockObserver{ctrl: ctrl}
+	mock.recorder = &MockObserverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockObserver) EXPECT() *MockObserverMockRecorder {
+	return m.recorder
 }
 
-// Warnln mocks base method
-func (m *MockLogger) Warnln(args ...interface{}) {
+// OperationStarted mocks base method
+func (m *MockObserver) OperationStarted(event wspubsub.OperationEvent) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Warnln", varargs...)
+	m.ctrl.Call(m, "OperationStarted", event)
 }
 
-// Warnln indicates an expected call of Warnln
-func (mr *MockLoggerMockRecorder) Warnln(args ...interface{}) *gomock.Call {
+// OperationStarted indicates an expected call of OperationStarted
+func (mr *MockObserverMockRecorder) OperationStarted(event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnln", reflect.TypeOf((*MockLogger)(nil).Warnln), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OperationStarted", reflect.TypeOf((*MockObserver)(nil).OperationStarted), event)
 }
 
-// Errorln mocks base method
-func (m *MockLogger) Errorln(args ...interface{}) {
+// OperationFinished mocks base method
+func (m *MockObserver) OperationFinished(event wspubsub.OperationEvent) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Errorln", varargs...)
+	m.ctrl.Call(m, "OperationFinished", event)
 }
 
-// Errorln indicates an expected call of Errorln
-func (mr *MockLoggerMockRecorder) Errorln(args ...interface{}) *gomock.Call {
+// OperationFinished indicates an expected call of OperationFinished
+func (mr *MockObserverMockRecorder) OperationFinished(event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Errorln", reflect.TypeOf((*MockLogger)(nil).Errorln), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OperationFinished", reflect.TypeOf((*MockObserver)(nil).OperationFinished), event)
+}
+
+// MockMessagePropagator is a mock of MessagePropagator interface
+type MockMessagePropagator struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessagePropagatorMockRecorder
+}
+
+// MockMessagePropagatorMockRecorder is the mock recorder for MockMessagePropagator
+type MockMessagePropagatorMockRecorder struct {
+	mock *MockMessagePropagator
+}
+
+// NewMockMessagePropagator creates a new mock instance
+func NewMockMessagePropagator(ctrl *gomock.Controller) *MockMessagePropagator {
+	mock := &MockMessagePropagator{ctrl: ctrl}
+	mock.recorder = &MockMessagePropagatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMessagePropagator) EXPECT() *MockMessagePropagatorMockRecorder {
+	return m.recorder
 }
 
-// Fatalln mocks base method
-func (m *MockLogger) Fatalln(args ...interface{}) {
+// Inject mocks base method
+func (m *MockMessagePropagator) Inject(ctx context.Context, message wspubsub.Message) wspubsub.Message {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Fatalln", varargs...)
+	ret := m.ctrl.Call(m, "Inject", ctx, message)
+	ret0, _ := ret[0].(wspubsub.Message)
+	return ret0
 }
 
-// Fatalln indicates an expected call of Fatalln
-func (mr *MockLoggerMockRecorder) Fatalln(args ...interface{}) *gomock.Call {
+// Inject indicates an expected call of Inject
+func (mr *MockMessagePropagatorMockRecorder) Inject(ctx, message interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fatalln", reflect.TypeOf((*MockLogger)(nil).Fatalln), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inject", reflect.TypeOf((*MockMessagePropagator)(nil).Inject), ctx, message)
 }
 
-// Panicln mocks base method
-func (m *MockLogger) Panicln(args ...interface{}) {
+// Extract mocks base method
+func (m *MockMessagePropagator) Extract(ctx context.Context, message wspubsub.Message) context.Context {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Panicln", varargs...)
+	ret := m.ctrl.Call(m, "Extract", ctx, message)
+	ret0, _ := ret[0].(context.Context)
+	return ret0
 }
 
-// Panicln indicates an expected call of Panicln
-func (mr *MockLoggerMockRecorder) Panicln(args ...interface{}) *gomock.Call {
+// Extract indicates an expected call of Extract
+func (mr *MockMessagePropagatorMockRecorder) Extract(ctx, message interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Panicln", reflect.TypeOf((*MockLogger)(nil).Panicln), args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extract", reflect.TypeOf((*MockMessagePropagator)(nil).Extract), ctx, message)
+}
+
+// MockClock is a mock of Clock interface
+type MockClock struct {
+	ctrl     *gomock.Controller
+	recorder *MockClockMockRecorder
 }
 
-// Debugf mocks base method
-func (m *MockLogger) Debugf(format string, args ...interface{}) {
+// MockClockMockRecorder is the mock recorder for MockClock
+type MockClockMockRecorder struct {
+	mock *MockClock
+}
+
+// NewMockClock creates a new mock instance
+func NewMockClock(ctrl *gomock.Controller) *MockClock {
+	mock := &MockClock{ctrl: ctrl}
+	mock.recorder = &MockClockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClock) EXPECT() *MockClockMockRecorder {
+	return m.recorder
+}
+
+// Now mocks base method
+func (m *MockClock) Now() time.Time {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Debugf", varargs...)
+	ret := m.ctrl.Call(m, "Now")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
 }
 
-// Debugf indicates an expected call of Debugf
-func (mr *MockLoggerMockRecorder) Debugf(format interface{}, args ...interface{}) *gomock.Call {
+// Now indicates an expected call of Now
+func (mr *MockClockMockRecorder) Now() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debugf", reflect.TypeOf((*MockLogger)(nil).Debugf), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*MockClock)(nil).Now))
 }
 
-// Infof mocks base method
-func (m *MockLogger) Infof(format string, args ...interface{}) {
+// AfterFunc mocks base method
+func (m *MockClock) AfterFunc(d time.Duration, f func()) wspubsub.ClockTimer {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	m.ctrl.Call(m, "Infof", varargs...)
+	ret := m.ctrl.Call(m, "AfterFunc", d, f)
+	ret0, _ := ret[0].(wspubsub.ClockTimer)
+	return ret0
+}
+
+// AfterFunc indicates an expected call of AfterFunc
+func (mr *MockClockMockRecorder) AfterFunc(d, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterFunc", reflect.TypeOf((*MockClock)(nil).AfterFunc), d, f)
+}
+
+// MockClockTimer is a mock of ClockTimer interface
+type MockClockTimer struct {
+	ctrl     *gomock.Controller
+	recorder *MockClockTimerMockRecorder
+}
+
+// MockClockTimerMockRecorder is the mock recorder for MockClockTimer
+type MockClockTimerMockRecorder struct {
+	mock *MockClockTimer
+}
+
+// NewMockClockTimer creates a new mock instance
+func NewMockClockTimer(ctrl *gomock.Controller) *MockClockTimer {
+	mock := &MockClockTimer{ctrl: ctrl}
+	mock.recorder = &MockClockTimerMockRecorder{mock}
+	return mock
 }
 
-// Infof indicates an expected call of Infof
-func (mr *MockLoggerMockRecorder) Infof(format interface{}, args ...interface{}) *gomock.Call {
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClockTimer) EXPECT() *MockClockTimerMockRecorder {
+	return m.recorder
+}
+
+// Stop mocks base method
+func (m *MockClockTimer) Stop() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockClockTimerMockRecorder) Stop() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Infof", reflect.TypeOf((*MockLogger)(nil).Infof), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockClockTimer)(nil).Stop))
+}
+
+// MockLogger is a mock of Logger interface
+type MockLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoggerMockRecorder
+}
+
+// MockLoggerMockRecorder is the mock recorder for MockLogger
+type MockLoggerMockRecorder struct {
+	mock *MockLogger
+}
+
+// NewMockLogger creates a new mock instance
+func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
+	mock := &MockLogger{ctrl: ctrl}
+	mock.recorder = &MockLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
+	return m.recorder
 }
 
-// Printf mocks base method
-func (m *MockLogger) Printf(format string, args ...interface{}) {
+// Debug mocks base method
+func (m *MockLogger) Debug(msg string, keysAndValues ...interface{}) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
+	varargs := []interface{}{msg}
+	for _, a := range keysAndValues {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Printf", varargs...)
+	m.ctrl.Call(m, "Debug", varargs...)
 }
 
-// Printf indicates an expected call of Printf
-func (mr *MockLoggerMockRecorder) Printf(format interface{}, args ...interface{}) *gomock.Call {
+// Debug indicates an expected call of Debug
+func (mr *MockLoggerMockRecorder) Debug(msg interface{}, keysAndValues ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Printf", reflect.TypeOf((*MockLogger)(nil).Printf), varargs...)
+	varargs := append([]interface{}{msg}, keysAndValues...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockLogger)(nil).Debug), varargs...)
 }
 
-// Warnf mocks base method
-func (m *MockLogger) Warnf(format string, args ...interface{}) {
+// Info mocks base method
+func (m *MockLogger) Info(msg string, keysAndValues ...interface{}) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
+	varargs := []interface{}{msg}
+	for _, a := range keysAndValues {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Warnf", varargs...)
+	m.ctrl.Call(m, "Info", varargs...)
 }
 
-// Warnf indicates an expected call of Warnf
-func (mr *MockLoggerMockRecorder) Warnf(format interface{}, args ...interface{}) *gomock.Call {
+// Info indicates an expected call of Info
+func (mr *MockLoggerMockRecorder) Info(msg interface{}, keysAndValues ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockLogger)(nil).Warnf), varargs...)
+	varargs := append([]interface{}{msg}, keysAndValues...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogger)(nil).Info), varargs...)
 }
 
-// Errorf mocks base method
-func (m *MockLogger) Errorf(format string, args ...interface{}) {
+// Warn mocks base method
+func (m *MockLogger) Warn(msg string, keysAndValues ...interface{}) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
+	varargs := []interface{}{msg}
+	for _, a := range keysAndValues {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Errorf", varargs...)
+	m.ctrl.Call(m, "Warn", varargs...)
 }
 
-// Errorf indicates an expected call of Errorf
-func (mr *MockLoggerMockRecorder) Errorf(format interface{}, args ...interface{}) *gomock.Call {
+// Warn indicates an expected call of Warn
+func (mr *MockLoggerMockRecorder) Warn(msg interface{}, keysAndValues ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Errorf", reflect.TypeOf((*MockLogger)(nil).Errorf), varargs...)
+	varargs := append([]interface{}{msg}, keysAndValues...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warn", reflect.TypeOf((*MockLogger)(nil).Warn), varargs...)
 }
 
-// Fatalf mocks base method
-func (m *MockLogger) Fatalf(format string, args ...interface{}) {
+// Error mocks base method
+func (m *MockLogger) Error(msg string, keysAndValues ...interface{}) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
+	varargs := []interface{}{msg}
+	for _, a := range keysAndValues {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Fatalf", varargs...)
+	m.ctrl.Call(m, "Error", varargs...)
 }
 
-// Fatalf indicates an expected call of Fatalf
-func (mr *MockLoggerMockRecorder) Fatalf(format interface{}, args ...interface{}) *gomock.Call {
+// Error indicates an expected call of Error
+func (mr *MockLoggerMockRecorder) Error(msg interface{}, keysAndValues ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fatalf", reflect.TypeOf((*MockLogger)(nil).Fatalf), varargs...)
+	varargs := append([]interface{}{msg}, keysAndValues...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockLogger)(nil).Error), varargs...)
 }
 
-// Panicf mocks base method
-func (m *MockLogger) Panicf(format string, args ...interface{}) {
+// With mocks base method
+func (m *MockLogger) With(keysAndValues ...interface{}) wspubsub.Logger {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{format}
-	for _, a := range args {
+	varargs := []interface{}{}
+	for _, a := range keysAndValues {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Panicf", varargs...)
+	ret := m.ctrl.Call(m, "With", varargs...)
+	ret0, _ := ret[0].(wspubsub.Logger)
+	return ret0
 }
 
-// Panicf indicates an expected call of Panicf
-func (mr *MockLoggerMockRecorder) Panicf(format interface{}, args ...interface{}) *gomock.Call {
+// With indicates an expected call of With
+func (mr *MockLoggerMockRecorder) With(keysAndValues ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{format}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Panicf", reflect.TypeOf((*MockLogger)(nil).Panicf), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "With", reflect.TypeOf((*MockLogger)(nil).With), keysAndValues...)
 }
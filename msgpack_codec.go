@@ -0,0 +1,31 @@
+package wspubsub
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var _ Codec = (*MessagePackCodec)(nil)
+
+// MessagePackCodec is an implementation of Codec which encodes values into binary MessagePack messages.
+type MessagePackCodec struct{}
+
+// Encode marshals the value into a binary message.
+func (c MessagePackCodec) Encode(v interface{}) (Message, error) {
+	payload, err := msgpack.Marshal(v)
+	if err != nil {
+		return Message{}, errors.WithStack(err)
+	}
+
+	return NewBinaryMessage(payload), nil
+}
+
+// Decode unmarshals a message payload into the value.
+func (c MessagePackCodec) Decode(message Message, v interface{}) error {
+	err := msgpack.Unmarshal(message.Payload, v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
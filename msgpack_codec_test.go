@@ -0,0 +1,28 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagePackCodec(t *testing.T) {
+	codec := wspubsub.MessagePackCodec{}
+
+	message, err := codec.Encode(codecValue{Name: "TEST", Count: 1})
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.MessageTypeBinary, message.Type)
+	require.NotEmpty(t, message.Payload)
+
+	var v codecValue
+	err = codec.Decode(message, &v)
+	require.NoError(t, err)
+	require.Equal(t, codecValue{Name: "TEST", Count: 1}, v)
+
+	_, err = codec.Encode(make(chan int))
+	require.Error(t, err)
+
+	err = codec.Decode(wspubsub.NewBinaryMessageFromString("\xc1"), &v)
+	require.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package wspubsub
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var _ WebsocketConnectionUpgrader = (*MultiConnectionUpgrader)(nil)
+
+// UpgraderRule binds an upgrader to requests matched by the function.
+type UpgraderRule struct {
+	Match    func(r *http.Request) bool
+	Upgrader WebsocketConnectionUpgrader
+}
+
+// MultiConnectionUpgrader is an implementation of WebsocketConnectionUpgrader
+// which serves several transports by the same hub.
+// A request is upgraded by the first upgrader whose rule matches it,
+// so a rule matching any request (e.g. of LongPollConnectionUpgrader) should be the last one.
+type MultiConnectionUpgrader struct {
+	rules []UpgraderRule
+}
+
+// Upgrade upgrades the request by the first matched upgrader.
+func (u *MultiConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (WebsocketConnection, error) {
+	for _, rule := range u.rules {
+		if rule.Match(r) {
+			return rule.Upgrader.Upgrade(w, r)
+		}
+	}
+
+	return nil, errors.New("wspubsub: no upgrader matches the request")
+}
+
+// IsWebsocketRequest reports whether the request asks for the WebSocket protocol.
+func IsWebsocketRequest(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// IsSSERequest reports whether the request accepts an event stream.
+func IsSSERequest(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Accept", "text/event-stream")
+}
+
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			// Skip parameters like "text/event-stream;q=0.9"
+			if i := strings.IndexByte(part, ';'); i >= 0 {
+				part = part[:i]
+			}
+
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NewMultiConnectionUpgrader initializes a new MultiConnectionUpgrader.
+func NewMultiConnectionUpgrader(rules ...UpgraderRule) *MultiConnectionUpgrader {
+	return &MultiConnectionUpgrader{rules: rules}
+}
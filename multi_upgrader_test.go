@@ -0,0 +1,84 @@
+package wspubsub_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiConnectionUpgrader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	websocketRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	websocketRequest.Header.Set("Connection", "keep-alive, Upgrade")
+	websocketRequest.Header.Set("Upgrade", "websocket")
+	websocketResponse := httptest.NewRecorder()
+
+	sseRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	sseRequest.Header.Set("Accept", "text/html, text/event-stream;q=0.9")
+	sseResponse := httptest.NewRecorder()
+
+	unknownRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	unknownResponse := httptest.NewRecorder()
+
+	websocketConnection := mock.NewMockWebsocketConnection(ctrl)
+	websocketUpgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	websocketUpgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(websocketResponse), gomock.Eq(websocketRequest)).
+		Times(1).
+		Return(websocketConnection, nil)
+
+	sseConnection := mock.NewMockWebsocketConnection(ctrl)
+	sseUpgrader := mock.NewMockWebsocketConnectionUpgrader(ctrl)
+	sseUpgrader.
+		EXPECT().
+		Upgrade(gomock.Eq(sseResponse), gomock.Eq(sseRequest)).
+		Times(1).
+		Return(sseConnection, nil)
+
+	upgrader := wspubsub.NewMultiConnectionUpgrader(
+		wspubsub.UpgraderRule{Match: wspubsub.IsWebsocketRequest, Upgrader: websocketUpgrader},
+		wspubsub.UpgraderRule{Match: wspubsub.IsSSERequest, Upgrader: sseUpgrader},
+	)
+
+	connection, err := upgrader.Upgrade(websocketResponse, websocketRequest)
+	require.NoError(t, err)
+	require.Equal(t, websocketConnection, connection)
+
+	connection, err = upgrader.Upgrade(sseResponse, sseRequest)
+	require.NoError(t, err)
+	require.Equal(t, sseConnection, connection)
+
+	connection, err = upgrader.Upgrade(unknownResponse, unknownRequest)
+	require.Error(t, err)
+	require.Nil(t, connection)
+}
+
+func TestIsWebsocketRequest(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.False(t, wspubsub.IsWebsocketRequest(request))
+
+	request.Header.Set("Upgrade", "WebSocket")
+	require.False(t, wspubsub.IsWebsocketRequest(request))
+
+	request.Header.Set("Connection", "Upgrade")
+	require.True(t, wspubsub.IsWebsocketRequest(request))
+}
+
+func TestIsSSERequest(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.False(t, wspubsub.IsSSERequest(request))
+
+	request.Header.Set("Accept", "text/html")
+	require.False(t, wspubsub.IsSSERequest(request))
+
+	request.Header.Add("Accept", "text/event-stream")
+	require.True(t, wspubsub.IsSSERequest(request))
+}
@@ -0,0 +1,36 @@
+package wspubsub
+
+// NamespacePolicy represents a policy of channels whose names start with a namespace prefix
+// (see Hub.RegisterNamespace).
+type NamespacePolicy struct {
+	// Grants access to channels on subscription (nil means the hub authorizer).
+	Authorizer Authorizer
+
+	// Number of the last published messages kept per channel (zero disables history).
+	// The history of a channel is dropped once its last subscriber leaves.
+	HistorySize int
+
+	// Allows to list subscribers of channels (see Hub.Presence).
+	Presence bool
+
+	// Keeps only the latest undelivered message of a channel in a client send buffer,
+	// so slow clients skip outdated messages instead of overflowing.
+	Conflation bool
+
+	// Number of subscribers of a single channel (zero means unlimited).
+	MaxSubscribers int
+
+	// Allows clients to publish to channels (see Hub.PublishFromClient).
+	ClientPublish bool
+}
+
+// NewNamespacePolicy initializes a new NamespacePolicy.
+// Channels which don't belong to any namespace follow this policy.
+func NewNamespacePolicy() NamespacePolicy {
+	policy := NamespacePolicy{
+		Presence:      true,
+		ClientPublish: true,
+	}
+
+	return policy
+}
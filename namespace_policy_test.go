@@ -0,0 +1,18 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNamespacePolicy(t *testing.T) {
+	policy := wspubsub.NewNamespacePolicy()
+	require.Nil(t, policy.Authorizer)
+	require.Zero(t, policy.HistorySize)
+	require.True(t, policy.Presence)
+	require.False(t, policy.Conflation)
+	require.Zero(t, policy.MaxSubscribers)
+	require.True(t, policy.ClientPublish)
+}
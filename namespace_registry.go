@@ -0,0 +1,61 @@
+package wspubsub
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type namespace struct {
+	prefix string
+	policy NamespacePolicy
+}
+
+// namespaceRegistry resolves policies of channels by the longest matching namespace prefix.
+type namespaceRegistry struct {
+	mu         sync.RWMutex
+	namespaces []namespace
+}
+
+// Register adds a namespace or replaces the policy of the existing one.
+func (r *namespaceRegistry) Register(prefix string, policy NamespacePolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.namespaces {
+		if r.namespaces[i].prefix == prefix {
+			r.namespaces[i].policy = policy
+
+			return
+		}
+	}
+
+	r.namespaces = append(r.namespaces, namespace{prefix: prefix, policy: policy})
+
+	// Longer prefixes go first, so nested namespaces override their parents
+	sort.SliceStable(r.namespaces, func(i, j int) bool {
+		return len(r.namespaces[i].prefix) > len(r.namespaces[j].prefix)
+	})
+}
+
+// Lookup returns the namespace prefix and the policy of the channel.
+func (r *namespaceRegistry) Lookup(channel string) (string, NamespacePolicy) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, ns := range r.namespaces {
+		if strings.HasPrefix(channel, ns.prefix) {
+			return ns.prefix, ns.policy
+		}
+	}
+
+	return "", NewNamespacePolicy()
+}
+
+// IsEmpty checks if there are no registered namespaces.
+func (r *namespaceRegistry) IsEmpty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.namespaces) == 0
+}
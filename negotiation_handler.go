@@ -0,0 +1,95 @@
+package wspubsub
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Transports which can be offered by NegotiationHandler.
+const (
+	TransportWebsocket   = "websocket"
+	TransportSSE         = "sse"
+	TransportLongPolling = "long_polling"
+)
+
+// TransportEndpoint describes URLs of a transport offered to clients.
+type TransportEndpoint struct {
+	Transport string `json:"transport"`
+
+	// URL of the connection request
+	URL string `json:"url"`
+
+	// URL of upstream messages (SSE, long-polling)
+	MessageURL string `json:"message_url,omitempty"`
+
+	// URL of polls (long-polling)
+	PollURL string `json:"poll_url,omitempty"`
+}
+
+type negotiationResponse struct {
+	Transports []TransportEndpoint `json:"transports"`
+}
+
+// NegotiationHandler tells clients which transports are available.
+// It responds with the transports in order of preference: WebSocket, SSE, long-polling:
+//
+//	{"transports":[{"transport":"websocket","url":"/ws"},{"transport":"sse","url":"/sse","message_url":"/sse/messages"}]}
+//
+// Clients should try them in order and fall back to the next one if connecting fails.
+// Transports listed in the "exclude" query parameter are omitted,
+// e.g. GET /negotiate?exclude=websocket,sse
+type NegotiationHandler struct {
+	endpoints []TransportEndpoint
+}
+
+// ServeHTTP responds with the available transports.
+func (h *NegotiationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	excluded := map[string]bool{}
+	for _, transport := range strings.Split(r.URL.Query().Get("exclude"), ",") {
+		excluded[strings.TrimSpace(transport)] = true
+	}
+
+	response := negotiationResponse{Transports: []TransportEndpoint{}}
+	for _, endpoint := range h.endpoints {
+		if !excluded[endpoint.Transport] {
+			response.Transports = append(response.Transports, endpoint)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func transportRank(transport string) int {
+	switch transport {
+	case TransportWebsocket:
+		return 0
+	case TransportSSE:
+		return 1
+	case TransportLongPolling:
+		return 2
+	default:
+		// nolint: gomnd
+		return 3
+	}
+}
+
+// NewNegotiationHandler initializes a new NegotiationHandler offering the endpoints.
+func NewNegotiationHandler(endpoints ...TransportEndpoint) *NegotiationHandler {
+	sorted := append([]TransportEndpoint(nil), endpoints...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return transportRank(sorted[i].Transport) < transportRank(sorted[j].Transport)
+	})
+
+	return &NegotiationHandler{endpoints: sorted}
+}
@@ -0,0 +1,62 @@
+package wspubsub_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiationHandler(t *testing.T) {
+	handler := wspubsub.NewNegotiationHandler(
+		wspubsub.TransportEndpoint{
+			Transport:  wspubsub.TransportLongPolling,
+			URL:        "/poll/connect",
+			PollURL:    "/poll",
+			MessageURL: "/poll/messages",
+		},
+		wspubsub.TransportEndpoint{Transport: wspubsub.TransportSSE, URL: "/sse", MessageURL: "/sse/messages"},
+		wspubsub.TransportEndpoint{Transport: wspubsub.TransportWebsocket, URL: "/ws"},
+	)
+
+	t.Run("All transports", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/negotiate", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, "application/json", response.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"transports":[
+			{"transport":"websocket","url":"/ws"},
+			{"transport":"sse","url":"/sse","message_url":"/sse/messages"},
+			{"transport":"long_polling","url":"/poll/connect","poll_url":"/poll","message_url":"/poll/messages"}
+		]}`, response.Body.String())
+	})
+
+	t.Run("Exclude transports", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/negotiate?exclude=websocket,sse", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		require.Equal(t, http.StatusOK, response.Code)
+		require.JSONEq(t, `{"transports":[
+			{"transport":"long_polling","url":"/poll/connect","poll_url":"/poll","message_url":"/poll/messages"}
+		]}`, response.Body.String())
+
+		request = httptest.NewRequest(http.MethodGet, "/negotiate?exclude=websocket,sse,long_polling", nil)
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		require.JSONEq(t, `{"transports":[]}`, response.Body.String())
+	})
+
+	t.Run("Invalid method", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/negotiate", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		require.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	})
+}
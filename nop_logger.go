@@ -0,0 +1,28 @@
+package wspubsub
+
+var _ Logger = nopLogger{}
+
+// nopLogger discards all messages.
+// It's used by components which were given no logger.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+
+func (nopLogger) Info(string, ...interface{}) {}
+
+func (nopLogger) Warn(string, ...interface{}) {}
+
+func (nopLogger) Error(string, ...interface{}) {}
+
+func (l nopLogger) With(...interface{}) Logger {
+	return l
+}
+
+// loggerOrNop returns the logger or a logger discarding messages if it's nil.
+func loggerOrNop(logger Logger) Logger {
+	if logger == nil {
+		return nopLogger{}
+	}
+
+	return logger
+}
@@ -0,0 +1,100 @@
+package wspubsub
+
+import (
+	"time"
+)
+
+// OperationEvent represents an operation observed by an Observer.
+type OperationEvent struct {
+	// Name of the operation, e.g. "wspubsub.hub.publish"
+	Name string
+
+	// Client the operation is performed on (zero if the operation isn't related to a client)
+	ClientID UUID
+
+	// Channels the operation is performed on
+	Channels []string
+
+	// Address of the remote peer of the connection (empty if the operation isn't related to a connection)
+	RemoteAddr string
+
+	// Size of the message payload in bytes
+	Size int
+
+	// Number of clients affected by the operation (set on finish)
+	Count int
+
+	// Execution time of the operation (set on finish)
+	Duration time.Duration
+
+	// Outcome of the operation (set on finish)
+	Err error
+}
+
+var _ Observer = (*MultiObserver)(nil)
+
+// MultiObserver is an implementation of Observer which notifies several observers.
+type MultiObserver struct {
+	observers []Observer
+}
+
+// OperationStarted notifies observers in the order they were specified.
+func (o *MultiObserver) OperationStarted(event OperationEvent) {
+	for _, observer := range o.observers {
+		observer.OperationStarted(event)
+	}
+}
+
+// OperationFinished notifies observers in the order they were specified.
+func (o *MultiObserver) OperationFinished(event OperationEvent) {
+	for _, observer := range o.observers {
+		observer.OperationFinished(event)
+	}
+}
+
+// NewMultiObserver initializes a new MultiObserver.
+func NewMultiObserver(observers ...Observer) *MultiObserver {
+	return &MultiObserver{observers: observers}
+}
+
+// operation reports an observed operation.
+// All methods are no-op for a nil operation, so callers don't check whether observing is enabled.
+type operation struct {
+	observer Observer
+	event    OperationEvent
+	start    time.Time
+}
+
+// startOperation returns nil if the observer is nil.
+func startOperation(observer Observer, event OperationEvent) *operation {
+	if observer == nil {
+		return nil
+	}
+
+	observer.OperationStarted(event)
+
+	return &operation{observer: observer, event: event, start: time.Now()}
+}
+
+func (o *operation) setCount(count int) {
+	if o == nil {
+		return
+	}
+
+	o.event.Count = count
+}
+
+// end reports the outcome of the operation.
+// The error is passed by a pointer to let deferred calls see a named result of the operation.
+func (o *operation) end(err *error) {
+	if o == nil {
+		return
+	}
+
+	o.event.Duration = time.Since(o.start)
+	if err != nil {
+		o.event.Err = *err
+	}
+
+	o.observer.OperationFinished(o.event)
+}
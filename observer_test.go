@@ -0,0 +1,30 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/mock"
+)
+
+func TestMultiObserver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	observer1 := mock.NewMockObserver(ctrl)
+	observer2 := mock.NewMockObserver(ctrl)
+
+	event := wspubsub.OperationEvent{Name: "wspubsub.hub.publish", Channels: []string{"X"}}
+
+	gomock.InOrder(
+		observer1.EXPECT().OperationStarted(gomock.Eq(event)).Times(1),
+		observer2.EXPECT().OperationStarted(gomock.Eq(event)).Times(1),
+		observer1.EXPECT().OperationFinished(gomock.Eq(event)).Times(1),
+		observer2.EXPECT().OperationFinished(gomock.Eq(event)).Times(1),
+	)
+
+	observer := wspubsub.NewMultiObserver(observer1, observer2)
+	observer.OperationStarted(event)
+	observer.OperationFinished(event)
+}
@@ -0,0 +1,258 @@
+package wspubsub
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	_ MetricsCollector     = (*PrometheusMetricsCollector)(nil)
+	_ prometheus.Collector = (*PrometheusMetricsCollector)(nil)
+)
+
+// PrometheusMetricsCollector is an implementation of MetricsCollector
+// which exposes metrics in the Prometheus format.
+type PrometheusMetricsCollector struct {
+	options            PrometheusMetricsCollectorOptions
+	registry           *prometheus.Registry
+	connects           prometheus.Counter
+	disconnects        *prometheus.CounterVec
+	activeConnections  prometheus.Gauge
+	messagesPublished  prometheus.Counter
+	bytesPublished     prometheus.Counter
+	publishRecipients  prometheus.Counter
+	publishDuration    prometheus.Histogram
+	messagesSent       prometheus.Counter
+	bytesSent          prometheus.Counter
+	messagesReceived   prometheus.Counter
+	bytesReceived      prometheus.Counter
+	messagesDropped    *prometheus.CounterVec
+	writeDuration      *prometheus.HistogramVec
+	subscribersDesc    *prometheus.Desc
+	subscribersMu      sync.Mutex
+	channelSubscribers map[string]int
+}
+
+// ClientConnected counts a connected client.
+func (c *PrometheusMetricsCollector) ClientConnected() {
+	c.connects.Inc()
+	c.activeConnections.Inc()
+}
+
+// ClientDisconnected counts a disconnected client.
+func (c *PrometheusMetricsCollector) ClientDisconnected(reason DisconnectReason) {
+	c.disconnects.WithLabelValues(string(reason)).Inc()
+	c.activeConnections.Dec()
+}
+
+// ChannelSubscribers updates the number of clients subscribed to the channel.
+func (c *PrometheusMetricsCollector) ChannelSubscribers(channel string, count int) {
+	c.subscribersMu.Lock()
+	defer c.subscribersMu.Unlock()
+
+	if count > 0 {
+		c.channelSubscribers[channel] = count
+	} else {
+		delete(c.channelSubscribers, channel)
+	}
+}
+
+// MessagePublished counts a published message and observes the fan-out latency.
+func (c *PrometheusMetricsCollector) MessagePublished(numClients int, size int, duration time.Duration) {
+	c.messagesPublished.Inc()
+	c.bytesPublished.Add(float64(size))
+	c.publishRecipients.Add(float64(numClients))
+	c.publishDuration.Observe(duration.Seconds())
+}
+
+// MessageSent counts a message written to a client connection.
+func (c *PrometheusMetricsCollector) MessageSent(size int) {
+	c.messagesSent.Inc()
+	c.bytesSent.Add(float64(size))
+}
+
+// MessageReceived counts a message read from a client connection.
+func (c *PrometheusMetricsCollector) MessageReceived(size int) {
+	c.messagesReceived.Inc()
+	c.bytesReceived.Add(float64(size))
+}
+
+// MessageDropped counts a dropped message.
+func (c *PrometheusMetricsCollector) MessageDropped(reason DropReason) {
+	c.messagesDropped.WithLabelValues(string(reason)).Inc()
+}
+
+// ConnectionWritten observes the write latency of the connection backend.
+func (c *PrometheusMetricsCollector) ConnectionWritten(backend string, duration time.Duration) {
+	c.writeDuration.WithLabelValues(backend).Observe(duration.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (c *PrometheusMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+
+	ch <- c.subscribersDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *PrometheusMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+
+	for _, channel := range c.topChannels() {
+		ch <- prometheus.MustNewConstMetric(
+			c.subscribersDesc,
+			prometheus.GaugeValue,
+			float64(channel.count),
+			channel.name,
+		)
+	}
+}
+
+// Handler returns an HTTP handler serving the collected metrics.
+func (c *PrometheusMetricsCollector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+func (c *PrometheusMetricsCollector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.connects,
+		c.disconnects,
+		c.activeConnections,
+		c.messagesPublished,
+		c.bytesPublished,
+		c.publishRecipients,
+		c.publishDuration,
+		c.messagesSent,
+		c.bytesSent,
+		c.messagesReceived,
+		c.bytesReceived,
+		c.messagesDropped,
+		c.writeDuration,
+	}
+}
+
+type channelSubscribers struct {
+	name  string
+	count int
+}
+
+func (c *PrometheusMetricsCollector) topChannels() []channelSubscribers {
+	c.subscribersMu.Lock()
+	channels := make([]channelSubscribers, 0, len(c.channelSubscribers))
+	for name, count := range c.channelSubscribers {
+		channels = append(channels, channelSubscribers{name: name, count: count})
+	}
+	c.subscribersMu.Unlock()
+
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].count == channels[j].count {
+			return channels[i].name < channels[j].name
+		}
+
+		return channels[i].count > channels[j].count
+	})
+
+	if len(channels) > c.options.TopChannels {
+		channels = channels[:c.options.TopChannels]
+	}
+
+	return channels
+}
+
+// NewPrometheusMetricsCollector initializes a new PrometheusMetricsCollector.
+// The collector is registered in its own registry served by Handler,
+// it can also be registered in any other prometheus.Registerer.
+func NewPrometheusMetricsCollector(options PrometheusMetricsCollectorOptions) *PrometheusMetricsCollector {
+	namespace := options.Namespace
+	collector := &PrometheusMetricsCollector{
+		options:  options,
+		registry: prometheus.NewRegistry(),
+		connects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "connects_total",
+			Help:      "Total number of connected clients.",
+		}),
+		disconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "disconnects_total",
+			Help:      "Total number of disconnected clients by reason.",
+		}, []string{"reason"}),
+		activeConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_connections",
+			Help:      "Number of currently connected clients.",
+		}),
+		messagesPublished: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_published_total",
+			Help:      "Total number of published messages.",
+		}),
+		bytesPublished: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "published_bytes_total",
+			Help:      "Total size of published messages in bytes.",
+		}),
+		publishRecipients: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "publish_recipients_total",
+			Help:      "Total number of clients published messages were enqueued to.",
+		}),
+		publishDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "publish_duration_seconds",
+			Help:      "Fan-out latency of publishing a message.",
+			Buckets:   options.PublishDurationBuckets,
+		}),
+		messagesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_sent_total",
+			Help:      "Total number of messages written to client connections.",
+		}),
+		bytesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sent_bytes_total",
+			Help:      "Total size of messages written to client connections in bytes.",
+		}),
+		messagesReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_received_total",
+			Help:      "Total number of messages read from client connections.",
+		}),
+		bytesReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "received_bytes_total",
+			Help:      "Total size of messages read from client connections in bytes.",
+		}),
+		messagesDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_dropped_total",
+			Help:      "Total number of dropped messages by reason.",
+		}, []string{"reason"}),
+		writeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "connection_write_duration_seconds",
+			Help:      "Latency of writing a message to a connection by backend.",
+			Buckets:   options.WriteDurationBuckets,
+		}, []string{"backend"}),
+		subscribersDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "channel_subscribers"),
+			"Number of clients subscribed to the most popular channels.",
+			[]string{"channel"},
+			nil,
+		),
+		channelSubscribers: make(map[string]int),
+	}
+
+	collector.registry.MustRegister(collector)
+
+	return collector
+}
@@ -0,0 +1,65 @@
+package wspubsub_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusMetricsCollector(t *testing.T) {
+	options := wspubsub.NewPrometheusMetricsCollectorOptions()
+	options.TopChannels = 2
+	collector := wspubsub.NewPrometheusMetricsCollector(options)
+
+	collector.ClientConnected()
+	collector.ClientConnected()
+	collector.ClientDisconnected(wspubsub.DisconnectReasonOverflow)
+	collector.ChannelSubscribers("X", 3)
+	collector.ChannelSubscribers("Y", 1)
+	collector.ChannelSubscribers("Z", 2)
+	collector.ChannelSubscribers("W", 5)
+	collector.ChannelSubscribers("W", 0)
+	collector.MessagePublished(2, 10, time.Millisecond)
+	collector.MessageSent(10)
+	collector.MessageReceived(7)
+	collector.MessageDropped(wspubsub.DropReasonOverflow)
+	collector.ConnectionWritten("gorilla", time.Millisecond)
+
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	collector.Handler().ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	expectedLines := []string{
+		`wspubsub_connects_total 2`,
+		`wspubsub_disconnects_total{reason="overflow"} 1`,
+		`wspubsub_active_connections 1`,
+		`wspubsub_channel_subscribers{channel="X"} 3`,
+		`wspubsub_channel_subscribers{channel="Z"} 2`,
+		`wspubsub_messages_published_total 1`,
+		`wspubsub_published_bytes_total 10`,
+		`wspubsub_publish_recipients_total 2`,
+		`wspubsub_publish_duration_seconds_count 1`,
+		`wspubsub_messages_sent_total 1`,
+		`wspubsub_sent_bytes_total 10`,
+		`wspubsub_messages_received_total 1`,
+		`wspubsub_received_bytes_total 7`,
+		`wspubsub_messages_dropped_total{reason="overflow"} 1`,
+		`wspubsub_connection_write_duration_seconds_count{backend="gorilla"} 1`,
+	}
+
+	for _, line := range expectedLines {
+		require.Contains(t, string(body), line)
+	}
+
+	require.NotContains(t, string(body), `channel="Y"`)
+	require.NotContains(t, string(body), `channel="W"`)
+}
@@ -0,0 +1,34 @@
+package wspubsub
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusMetricsCollectorOptions represents configuration of the PrometheusMetricsCollector.
+type PrometheusMetricsCollectorOptions struct {
+	// Prefix of all metric names
+	Namespace string
+
+	// Number of the most subscribed channels exposed by the subscribers gauge.
+	// Exposing of all channels could cause high cardinality.
+	TopChannels int
+
+	// Buckets (in seconds) of the publish fan-out latency histogram
+	PublishDurationBuckets []float64
+
+	// Buckets (in seconds) of the connection write latency histogram
+	WriteDurationBuckets []float64
+}
+
+// NewPrometheusMetricsCollectorOptions initializes a new PrometheusMetricsCollectorOptions.
+// nolint: gomnd
+func NewPrometheusMetricsCollectorOptions() PrometheusMetricsCollectorOptions {
+	options := PrometheusMetricsCollectorOptions{
+		Namespace:              "wspubsub",
+		TopChannels:            10,
+		PublishDurationBuckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+		WriteDurationBuckets:   prometheus.ExponentialBuckets(0.0001, 2, 16),
+	}
+
+	return options
+}
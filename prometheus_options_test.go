@@ -0,0 +1,16 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrometheusMetricsCollectorOptions(t *testing.T) {
+	options := wspubsub.NewPrometheusMetricsCollectorOptions()
+	require.NotZero(t, options.Namespace)
+	require.NotZero(t, options.TopChannels)
+	require.NotEmpty(t, options.PublishDurationBuckets)
+	require.NotEmpty(t, options.WriteDurationBuckets)
+}
@@ -0,0 +1,70 @@
+package wspubsub
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ Codec = (*ProtobufCodec)(nil)
+
+// ProtobufCodec is an implementation of Codec which encodes protocol buffers into binary messages.
+// Values must implement proto.Message.
+type ProtobufCodec struct{}
+
+// Encode marshals the protocol buffer into a binary message.
+func (c ProtobufCodec) Encode(v interface{}) (Message, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return Message{}, errors.Errorf("wspubsub: %T doesn't implement proto.Message", v)
+	}
+
+	payload, err := proto.Marshal(m)
+	if err != nil {
+		return Message{}, errors.WithStack(err)
+	}
+
+	return NewBinaryMessage(payload), nil
+}
+
+// Decode unmarshals a message payload into the protocol buffer.
+// A pointer to a nil protocol buffer pointer is also accepted,
+// a new protocol buffer is allocated in that case.
+func (c ProtobufCodec) Decode(message Message, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		m, ok = allocateProtoMessage(v)
+		if !ok {
+			return errors.Errorf("wspubsub: %T doesn't implement proto.Message", v)
+		}
+	}
+
+	err := proto.Unmarshal(message.Payload, m)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// allocateProtoMessage allocates a protocol buffer which v (like **T) points to.
+func allocateProtoMessage(v interface{}) (proto.Message, bool) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Ptr {
+		return nil, false
+	}
+
+	elem := value.Elem()
+	if elem.IsNil() {
+		if _, ok := reflect.New(elem.Type().Elem()).Interface().(proto.Message); !ok {
+			return nil, false
+		}
+
+		elem.Set(reflect.New(elem.Type().Elem()))
+	}
+
+	m, ok := elem.Interface().(proto.Message)
+
+	return m, ok
+}
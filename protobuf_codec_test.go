@@ -0,0 +1,50 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtobufCodec(t *testing.T) {
+	codec := wspubsub.ProtobufCodec{}
+
+	message, err := codec.Encode(wrapperspb.String("TEST"))
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.MessageTypeBinary, message.Type)
+
+	t.Run("Decode into a message", func(t *testing.T) {
+		v := &wrapperspb.StringValue{}
+		err := codec.Decode(message, v)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(wrapperspb.String("TEST"), v))
+	})
+
+	t.Run("Decode into a nil pointer", func(t *testing.T) {
+		var v *wrapperspb.StringValue
+		err := codec.Decode(message, &v)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(wrapperspb.String("TEST"), v))
+	})
+
+	t.Run("Not a protocol buffer", func(t *testing.T) {
+		_, err := codec.Encode("TEST")
+		require.Error(t, err)
+
+		var s string
+		err = codec.Decode(message, &s)
+		require.Error(t, err)
+
+		var p *string
+		err = codec.Decode(message, &p)
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		err := codec.Decode(wspubsub.NewBinaryMessageFromString("\xff"), &wrapperspb.StringValue{})
+		require.Error(t, err)
+	})
+}
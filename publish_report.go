@@ -0,0 +1,24 @@
+package wspubsub
+
+// PublishReport represents the delivery of a published message to the subscribed clients.
+type PublishReport struct {
+	// Clients which got the message enqueued to their send buffer
+	Enqueued []UUID
+
+	// Clients whose send buffer was full, so the message was dropped for them
+	Overflowed []UUID
+
+	// Clients which were disconnected before the message reached them
+	Gone []UUID
+
+	// Clients which failed to take the message for other reasons
+	Failed []UUID
+
+	// Clients which weren't visited because the publishing context was done
+	Skipped []UUID
+}
+
+// Delivered reports whether every subscribed client got the message enqueued.
+func (r PublishReport) Delivered() bool {
+	return len(r.Overflowed) == 0 && len(r.Gone) == 0 && len(r.Failed) == 0 && len(r.Skipped) == 0
+}
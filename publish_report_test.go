@@ -0,0 +1,24 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishReport_Delivered(t *testing.T) {
+	report := wspubsub.PublishReport{}
+	require.True(t, report.Delivered())
+
+	report.Enqueued = []wspubsub.UUID{wspubsubtest.SequentialUUID(1)}
+	require.True(t, report.Delivered())
+
+	report.Skipped = []wspubsub.UUID{wspubsubtest.SequentialUUID(2)}
+	require.False(t, report.Delivered())
+
+	report.Skipped = nil
+	report.Failed = []wspubsub.UUID{wspubsubtest.SequentialUUID(3)}
+	require.False(t, report.Delivered())
+}
@@ -0,0 +1,116 @@
+package wspubsub
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// remoteIPExtractor determines an IP address of the client who sent the request.
+// Forwarding headers are taken into account only when the request came
+// through one of the trusted proxies, otherwise they could be easily spoofed.
+type remoteIPExtractor struct {
+	trustedProxies []*net.IPNet
+}
+
+// Extract returns the client IP address of the request.
+func (e *remoteIPExtractor) Extract(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	// Forwarding headers of an untrusted peer are ignored
+	if !e.isTrusted(ip) {
+		return ip.String()
+	}
+
+	// X-Forwarded-For contains a list of addresses where each proxy appends
+	// the address it received the request from, so walk it from the right
+	// and pick the first one that isn't a trusted proxy.
+	// A proxy could append its own header line instead of extending the first one,
+	// so all the lines are joined in order.
+	forwardedFor := request.Header.Values("X-Forwarded-For")
+	if len(forwardedFor) > 0 {
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			forwardedIP := net.ParseIP(strings.TrimSpace(hops[i]))
+			if forwardedIP == nil {
+				// Hops beyond an unparseable one can't be trusted,
+				// so the nearest trusted proxy is the best guess
+				return ip.String()
+			}
+
+			ip = forwardedIP
+			if !e.isTrusted(ip) {
+				return ip.String()
+			}
+		}
+
+		// Every hop is a trusted proxy, so the farthest one is the best guess.
+		// X-Real-IP isn't used here since the client could send it along with the chain.
+		return ip.String()
+	}
+
+	// Only the trusted peer itself could set X-Real-IP
+	if realIP := net.ParseIP(strings.TrimSpace(request.Header.Get("X-Real-IP"))); realIP != nil {
+		return realIP.String()
+	}
+
+	return ip.String()
+}
+
+func (e *remoteIPExtractor) isTrusted(ip net.IP) bool {
+	for _, network := range e.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newRemoteIPExtractor initializes a new remoteIPExtractor.
+// Proxies could be specified either as a single IP address or as a network in CIDR notation.
+// Invalid values are returned separately, so the caller is able to report them.
+func newRemoteIPExtractor(trustedProxies []string) (*remoteIPExtractor, []string) {
+	extractor := &remoteIPExtractor{trustedProxies: make([]*net.IPNet, 0, len(trustedProxies))}
+
+	var invalid []string
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				invalid = append(invalid, proxy)
+
+				continue
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			extractor.trustedProxies = append(extractor.trustedProxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			invalid = append(invalid, proxy)
+
+			continue
+		}
+
+		extractor.trustedProxies = append(extractor.trustedProxies, network)
+	}
+
+	return extractor, invalid
+}
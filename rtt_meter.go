@@ -0,0 +1,33 @@
+package wspubsub
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// rttMeter measures a round-trip time between a ping and the following pong.
+type rttMeter struct {
+	pingSentAt atomic.Int64
+	rtt        atomic.Int64
+}
+
+// PingSent remembers the time a ping was written.
+func (m *rttMeter) PingSent(now time.Time) {
+	m.pingSentAt.Store(now.UnixNano())
+}
+
+// PongReceived updates the round-trip time of the last ping.
+// Unsolicited pongs are ignored.
+func (m *rttMeter) PongReceived(now time.Time) {
+	pingSentAt := m.pingSentAt.Swap(0)
+	if pingSentAt == 0 {
+		return
+	}
+
+	m.rtt.Store(now.UnixNano() - pingSentAt)
+}
+
+// RTT returns the last measured round-trip time (zero if it wasn't measured yet).
+func (m *rttMeter) RTT() time.Duration {
+	return time.Duration(m.rtt.Load())
+}
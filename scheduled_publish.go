@@ -0,0 +1,18 @@
+package wspubsub
+
+import "time"
+
+// ScheduledPublish is a handle of delayed or periodic publishing (see Hub.PublishAt and Hub.PublishEvery).
+type ScheduledPublish struct {
+	scheduler *scheduler
+	run       func(at time.Time) (time.Time, bool)
+	repeated  bool
+	timer     ClockTimer
+}
+
+// Cancel cancels publishing which hasn't happened yet.
+// It returns false if a delayed message was already published or publishing was cancelled before.
+// Periodic publishing in progress is finished, but the next one doesn't happen.
+func (p *ScheduledPublish) Cancel() bool {
+	return p.scheduler.Cancel(p)
+}
@@ -0,0 +1,105 @@
+package wspubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// scheduler runs delayed and periodic publishing using the clock.
+type scheduler struct {
+	clock  Clock
+	mu     sync.Mutex
+	jobs   map[*ScheduledPublish]struct{}
+	closed bool
+}
+
+// Schedule runs the function at the time.
+// The function returns the time of the next run if it should be repeated.
+func (s *scheduler) Schedule(
+	at time.Time,
+	repeated bool,
+	run func(at time.Time) (time.Time, bool),
+) (*ScheduledPublish, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, NewHubClosedError()
+	}
+
+	job := &ScheduledPublish{scheduler: s, run: run, repeated: repeated}
+	s.jobs[job] = struct{}{}
+	s.start(job, at)
+
+	return job, nil
+}
+
+// Cancel removes the job, it returns false if the job isn't pending.
+func (s *scheduler) Cancel(job *ScheduledPublish) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[job]; !ok {
+		return false
+	}
+
+	delete(s.jobs, job)
+	job.timer.Stop()
+
+	return true
+}
+
+// Close cancels all pending jobs and rejects new ones.
+func (s *scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for job := range s.jobs {
+		job.timer.Stop()
+		delete(s.jobs, job)
+	}
+}
+
+// start arms the timer of the job, the lock must be held.
+func (s *scheduler) start(job *ScheduledPublish, at time.Time) {
+	job.timer = s.clock.AfterFunc(at.Sub(s.clock.Now()), func() {
+		s.fire(job, at)
+	})
+}
+
+func (s *scheduler) fire(job *ScheduledPublish, at time.Time) {
+	s.mu.Lock()
+	if _, ok := s.jobs[job]; !ok {
+		s.mu.Unlock()
+
+		return
+	}
+
+	// A delayed job is taken before running, so it can't be cancelled anymore
+	if !job.repeated {
+		delete(s.jobs, job)
+	}
+	s.mu.Unlock()
+
+	next, ok := job.run(at)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, pending := s.jobs[job]; !pending {
+		return
+	}
+
+	if !ok {
+		delete(s.jobs, job)
+
+		return
+	}
+
+	s.start(job, next)
+}
+
+func newScheduler(clock Clock) *scheduler {
+	return &scheduler{clock: clock, jobs: make(map[*ScheduledPublish]struct{})}
+}
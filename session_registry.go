@@ -0,0 +1,121 @@
+package wspubsub
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SessionTokenHeader is a header carrying the session token in requests of HTTP transports (SSE, long-polling).
+const SessionTokenHeader = "X-Wspubsub-Token"
+
+// session is a connection of an HTTP transport
+// which receives upstream messages by separate requests.
+type session interface {
+	sessionToken() string
+	deliver(message Message) bool
+}
+
+// sessionInfo is sent to the client when a session starts.
+type sessionInfo struct {
+	ClientID UUID   `json:"client_id"`
+	Token    string `json:"token"`
+}
+
+// sessionRegistry routes requests of HTTP transports to sessions by client ID.
+type sessionRegistry struct {
+	sessions sync.Map
+}
+
+func (r *sessionRegistry) register(clientID UUID, s session) {
+	r.sessions.Store(clientID, s)
+}
+
+func (r *sessionRegistry) unregister(clientID UUID, s session) {
+	r.sessions.CompareAndDelete(clientID, s)
+}
+
+// lookup returns a session of the request identified by
+// the client_id query parameter and the token header.
+// It responds with an error status if the session isn't found.
+func (r *sessionRegistry) lookup(w http.ResponseWriter, request *http.Request) (session, bool) {
+	clientID, err := ParseUUID(request.URL.Query().Get("client_id"))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return nil, false
+	}
+
+	value, ok := r.sessions.Load(clientID)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+
+		return nil, false
+	}
+
+	s := value.(session)
+
+	token := request.Header.Get(SessionTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.sessionToken())) != 1 {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+		return nil, false
+	}
+
+	return s, true
+}
+
+// serveMessage delivers an upstream message sent by POST request.
+// A body with the application/octet-stream content type is received as a binary message,
+// any other body is received as a text message.
+func (r *sessionRegistry) serveMessage(w http.ResponseWriter, request *http.Request, maxMessageSize int64) {
+	if request.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	s, ok := r.lookup(w, request)
+	if !ok {
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, request.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	message := NewTextMessage(payload)
+	if strings.HasPrefix(request.Header.Get("Content-Type"), "application/octet-stream") {
+		message = NewBinaryMessage(payload)
+	}
+
+	if !s.deliver(message) {
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func newSessionToken() (string, error) {
+	// nolint: gomnd
+	token := make([]byte, 16)
+
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return hex.EncodeToString(token), nil
+}
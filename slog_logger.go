@@ -0,0 +1,47 @@
+package wspubsub
+
+import (
+	"log/slog"
+)
+
+var _ Logger = (*SlogLogger)(nil)
+
+// SlogLogger is an implementation of Logger based on log/slog.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// Debug logs a message with fields at level Debug.
+func (l SlogLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.Debug(msg, keysAndValues...)
+}
+
+// Info logs a message with fields at level Info.
+func (l SlogLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.Info(msg, keysAndValues...)
+}
+
+// Warn logs a message with fields at level Warn.
+func (l SlogLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.logger.Warn(msg, keysAndValues...)
+}
+
+// Error logs a message with fields at level Error.
+func (l SlogLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.Error(msg, keysAndValues...)
+}
+
+// With returns a logger which adds the fields to every message.
+func (l SlogLogger) With(keysAndValues ...interface{}) Logger {
+	return &SlogLogger{logger: l.logger.With(keysAndValues...)}
+}
+
+// NewSlogLogger initializes a new SlogLogger.
+// The default slog logger is used if the logger is nil.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &SlogLogger{logger: logger}
+}
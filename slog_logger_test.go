@@ -0,0 +1,32 @@
+package wspubsub_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	output := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	logger := wspubsub.NewSlogLogger(slog.New(handler)).With(wspubsub.LogFieldRemoteAddr, "127.0.0.1:1234")
+	logger.Warn("Client subscribed", wspubsub.LogFieldClientID, clientID, wspubsub.LogFieldChannel, "X")
+
+	entry := map[string]interface{}{}
+	err := json.Unmarshal(output.Bytes(), &entry)
+	require.NoError(t, err)
+	require.Equal(t, "Client subscribed", entry["msg"])
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, clientID.String(), entry["client_id"])
+	require.Equal(t, "X", entry["channel"])
+	require.Equal(t, "127.0.0.1:1234", entry["remote_addr"])
+}
+
+func TestNewSlogLogger_Default(t *testing.T) {
+	require.NotNil(t, wspubsub.NewSlogLogger(nil))
+}
@@ -0,0 +1,44 @@
+package wspubsub
+
+var _ Observer = (*SlowOperationObserver)(nil)
+
+// SlowOperationObserver is an implementation of Observer
+// which logs a warning about operations exceeding the time limit.
+type SlowOperationObserver struct {
+	options SlowOperationObserverOptions
+	logger  Logger
+}
+
+// OperationStarted does nothing.
+func (o *SlowOperationObserver) OperationStarted(event OperationEvent) {}
+
+// OperationFinished logs a warning if the operation took longer than the threshold.
+func (o *SlowOperationObserver) OperationFinished(event OperationEvent) {
+	if event.Duration <= o.options.Threshold {
+		return
+	}
+
+	keysAndValues := []interface{}{"operation", event.Name, "took", event.Duration}
+	if event.ClientID != (UUID{}) {
+		keysAndValues = append(keysAndValues, LogFieldClientID, event.ClientID)
+	}
+
+	if event.RemoteAddr != "" {
+		keysAndValues = append(keysAndValues, LogFieldRemoteAddr, event.RemoteAddr)
+	}
+
+	if len(event.Channels) > 0 {
+		keysAndValues = append(keysAndValues, LogFieldChannels, event.Channels)
+	}
+
+	if event.Err != nil {
+		keysAndValues = append(keysAndValues, LogFieldError, event.Err)
+	}
+
+	o.logger.Warn("Slow operation", keysAndValues...)
+}
+
+// NewSlowOperationObserver initializes a new SlowOperationObserver.
+func NewSlowOperationObserver(options SlowOperationObserverOptions, logger Logger) *SlowOperationObserver {
+	return &SlowOperationObserver{options: options, logger: logger}
+}
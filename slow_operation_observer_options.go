@@ -0,0 +1,22 @@
+package wspubsub
+
+import (
+	"time"
+)
+
+// SlowOperationObserverOptions represents configuration of the SlowOperationObserver.
+type SlowOperationObserverOptions struct {
+	// Operation execution time limit.
+	// Exceeding this time limit will cause a new warn log message.
+	Threshold time.Duration
+}
+
+// NewSlowOperationObserverOptions initializes a new SlowOperationObserverOptions.
+// nolint: gomnd
+func NewSlowOperationObserverOptions() SlowOperationObserverOptions {
+	options := SlowOperationObserverOptions{
+		Threshold: 1 * time.Millisecond,
+	}
+
+	return options
+}
@@ -0,0 +1,14 @@
+package wspubsub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSlowOperationObserverOptions(t *testing.T) {
+	options := wspubsub.NewSlowOperationObserverOptions()
+	require.Equal(t, 1*time.Millisecond, options.Threshold)
+}
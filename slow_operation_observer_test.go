@@ -0,0 +1,68 @@
+package wspubsub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/mock"
+	"github.com/pkg/errors"
+)
+
+func TestSlowOperationObserver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+
+	err := errors.New("oops")
+
+	logger.
+		EXPECT().
+		Warn(
+			gomock.Eq("Slow operation"),
+			gomock.Eq("operation"), gomock.Eq("wspubsub.hub.subscribe"),
+			gomock.Eq("took"), gomock.Eq(2*time.Millisecond),
+			gomock.Eq(wspubsub.LogFieldClientID), gomock.Eq(clientID),
+			gomock.Eq(wspubsub.LogFieldChannels), gomock.Eq([]string{"X"}),
+			gomock.Eq(wspubsub.LogFieldError), gomock.Eq(err),
+		).
+		Times(1)
+
+	logger.
+		EXPECT().
+		Warn(
+			gomock.Eq("Slow operation"),
+			gomock.Eq("operation"), gomock.Eq("wspubsub.client.send"),
+			gomock.Eq("took"), gomock.Eq(2*time.Millisecond),
+			gomock.Eq(wspubsub.LogFieldClientID), gomock.Eq(clientID),
+			gomock.Eq(wspubsub.LogFieldRemoteAddr), gomock.Eq("127.0.0.1:1234"),
+		).
+		Times(1)
+
+	options := wspubsub.NewSlowOperationObserverOptions()
+	observer := wspubsub.NewSlowOperationObserver(options, logger)
+
+	event := wspubsub.OperationEvent{
+		Name:     "wspubsub.hub.subscribe",
+		ClientID: clientID,
+		Channels: []string{"X"},
+	}
+
+	observer.OperationStarted(event)
+
+	event.Duration = options.Threshold
+	observer.OperationFinished(event)
+
+	event.Duration = 2 * time.Millisecond
+	event.Err = err
+	observer.OperationFinished(event)
+
+	observer.OperationFinished(wspubsub.OperationEvent{
+		Name:       "wspubsub.client.send",
+		ClientID:   clientID,
+		RemoteAddr: "127.0.0.1:1234",
+		Duration:   2 * time.Millisecond,
+	})
+}
@@ -0,0 +1,280 @@
+package wspubsub
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ WebsocketConnection       = (*SSEConnection)(nil)
+	_ WebsocketConnectionBinder = (*SSEConnection)(nil)
+	_ session                   = (*SSEConnection)(nil)
+)
+
+// SSEConnection is an implementation of WebsocketConnection over Server-Sent Events.
+// Messages are written as events of a text/event-stream response:
+//   - text messages as default events
+//   - binary messages as "binary" events encoded in base64
+//   - close messages as "close" events like {"code":1001,"reason":"..."}
+//   - pings as comments
+//
+// The first "session" event carries the client ID and the token which are required
+// to send upstream messages (see SSEConnectionUpgrader.MessageHandler).
+type SSEConnection struct {
+	conn         net.Conn
+	rw           *bufio.ReadWriter
+	body         io.WriteCloser
+	sessions     *sessionRegistry
+	metrics      MetricsCollector
+	writeTimeout time.Duration
+	observer     Observer
+	remoteAddr   string
+	clientID     UUID
+	token        string
+	inbound      chan Message
+	closed       chan struct{}
+	closeOnce    sync.Once
+	mu           sync.Mutex
+}
+
+// Bind registers the connection to receive upstream messages of the client
+// and sends the session event.
+func (c *SSEConnection) Bind(clientID UUID) error {
+	c.clientID = clientID
+
+	payload, err := json.Marshal(sessionInfo{ClientID: clientID, Token: c.token})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.sessions.register(clientID, c)
+
+	err = c.writeEvent(formatSSEEvent("session", payload))
+	if err != nil {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// Read returns the next upstream message.
+func (c *SSEConnection) Read() (Message, error) {
+	select {
+	case message := <-c.inbound:
+		return message, nil
+	case <-c.closed:
+		return Message{}, errors.WithStack(NewConnectionClosedError(io.EOF))
+	}
+}
+
+// Write writes a message as an event.
+func (c *SSEConnection) Write(message Message) (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.sse_connection.write", RemoteAddr: c.remoteAddr, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	if c.metrics != nil {
+		now := time.Now()
+		defer func() {
+			c.metrics.ConnectionWritten("sse", time.Since(now))
+		}()
+	}
+
+	var event []byte
+	switch message.Type {
+	case MessageTypePing:
+		event = []byte(": ping\n\n")
+	case MessageTypeClose:
+		event = formatSSEEvent("close", formatSSEClosePayload(message.Payload))
+	case MessageTypeBinary:
+		payload := make([]byte, base64.StdEncoding.EncodedLen(len(message.Payload)))
+		base64.StdEncoding.Encode(payload, message.Payload)
+		event = formatSSEEvent("binary", payload)
+	default:
+		event = formatSSEEvent("", message.Payload)
+	}
+
+	err = c.writeEvent(event)
+	if err != nil {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// Close finishes the event stream and closes the connection.
+// The connection may be already closed by the client, so closing is idempotent.
+func (c *SSEConnection) Close() (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.sse_connection.close", RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		close(c.closed)
+		c.sessions.unregister(c.clientID, c)
+
+		// Terminate the chunked body, so the client doesn't treat the stream as broken
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+		_ = c.body.Close()
+		_, _ = c.rw.WriteString("\r\n")
+		_ = c.rw.Flush()
+
+		err = c.conn.Close()
+	})
+
+	if err != nil {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// RTT always returns zero since SSE has no pongs.
+func (c *SSEConnection) RTT() time.Duration {
+	return 0
+}
+
+func (c *SSEConnection) sessionToken() string {
+	return c.token
+}
+
+// deliver passes an upstream message to the reader.
+func (c *SSEConnection) deliver(message Message) bool {
+	select {
+	case c.inbound <- message:
+		return true
+	case <-c.closed:
+		return false
+	default:
+		return false
+	}
+}
+
+// watch detects the client closed the stream,
+// since nothing else is expected to be read from the connection.
+func (c *SSEConnection) watch() {
+	_, _ = io.Copy(io.Discard, c.rw)
+	_ = c.Close()
+}
+
+func (c *SSEConnection) writeEvent(event []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.closed:
+		return NewConnectionClosedError(io.EOF)
+	default:
+	}
+
+	err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.body.Write(event)
+	if err != nil {
+		return err
+	}
+
+	return c.rw.Flush()
+}
+
+func (c *SSEConnection) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := IsConnectionClosedError(err); ok {
+		return errors.WithStack(err)
+	}
+
+	select {
+	case <-c.closed:
+		return errors.WithStack(NewConnectionClosedError(err))
+	default:
+	}
+
+	if strings.Contains(err.Error(), "use of closed network connection") {
+		return errors.WithStack(NewConnectionClosedError(err))
+	}
+
+	if _, ok := err.(*net.OpError); ok {
+		return errors.WithStack(NewConnectionClosedError(err))
+	}
+
+	return errors.WithStack(err)
+}
+
+// formatSSEEvent splits data into lines, since a line break ends a data field.
+func formatSSEEvent(event string, data []byte) []byte {
+	buf := bytes.Buffer{}
+	if event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(event)
+		buf.WriteByte('\n')
+	}
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
+
+func formatSSEClosePayload(payload []byte) []byte {
+	v := struct {
+		Code   CloseCode `json:"code"`
+		Reason string    `json:"reason"`
+	}{}
+
+	v.Code, v.Reason = parseClosePayload(payload)
+	data, _ := json.Marshal(v)
+
+	return data
+}
+
+func newSSEConnection(
+	conn net.Conn,
+	rw *bufio.ReadWriter,
+	sessions *sessionRegistry,
+	token string,
+	options SSEConnectionUpgraderOptions,
+) *SSEConnection {
+	return &SSEConnection{
+		conn:         conn,
+		rw:           rw,
+		body:         httputil.NewChunkedWriter(rw),
+		sessions:     sessions,
+		metrics:      options.Metrics,
+		writeTimeout: options.WriteTimeout,
+		observer:     options.Observer,
+		remoteAddr:   conn.RemoteAddr().String(),
+		token:        token,
+		inbound:      make(chan Message, options.InboundBufferSize),
+		closed:       make(chan struct{}),
+	}
+}
@@ -0,0 +1,97 @@
+package wspubsub
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ WebsocketConnectionUpgrader = (*SSEConnectionUpgrader)(nil)
+
+// SSEConnectionUpgrader is an implementation of WebsocketConnectionUpgrader
+// which serves clients over Server-Sent Events, e.g. behind proxies blocking WebSocket.
+// Downstream messages are streamed as events of the connection upgrade response.
+// Upstream messages are sent by POST requests to MessageHandler:
+//
+//	POST /messages?client_id=<client_id>
+//	X-Wspubsub-Token: <token> (see SessionTokenHeader)
+//
+// The client ID and the token are sent in the first "session" event of the stream.
+// A body with the application/octet-stream content type is received as a binary message,
+// any other body is received as a text message.
+// The event stream requires HTTP/1.1, since the upgrader hijacks the connection.
+type SSEConnectionUpgrader struct {
+	options  SSEConnectionUpgraderOptions
+	sessions sessionRegistry
+}
+
+// Upgrade starts an event stream.
+func (u *SSEConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.sse_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
+
+	if r.Method != http.MethodGet {
+		return nil, errors.Errorf("wspubsub: invalid method of event stream request: %s", r.Method)
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("wspubsub: response doesn't support hijacking")
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	header := http.Header{}
+	for key, values := range u.options.Header {
+		header[key] = values
+	}
+
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("Transfer-Encoding", "chunked")
+	// Disable buffering of nginx
+	header.Set("X-Accel-Buffering", "no")
+
+	_, _ = rw.WriteString("HTTP/1.1 200 OK\r\n")
+	_ = header.Write(rw)
+	_, _ = rw.WriteString("\r\n")
+
+	_ = conn.SetWriteDeadline(time.Now().Add(u.options.WriteTimeout))
+	err = rw.Flush()
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, errors.WithStack(err)
+	}
+
+	connection := newSSEConnection(conn, rw, &u.sessions, token, u.options)
+	go connection.watch()
+
+	return connection, nil
+}
+
+// MessageHandler returns a handler of upstream messages.
+// It responds with 202 status code if the message is accepted.
+func (u *SSEConnectionUpgrader) MessageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.sessions.serveMessage(w, r, u.options.MaxMessageSize)
+	})
+}
+
+// NewSSEConnectionUpgrader initializes a new SSEConnectionUpgrader.
+func NewSSEConnectionUpgrader(options SSEConnectionUpgraderOptions) *SSEConnectionUpgrader {
+	return &SSEConnectionUpgrader{options: options}
+}
@@ -0,0 +1,37 @@
+package wspubsub
+
+import (
+	"net/http"
+	"time"
+)
+
+// SSEConnectionUpgraderOptions represents configuration of the SSEConnectionUpgrader.
+type SSEConnectionUpgraderOptions struct {
+	// Write timeout of an event
+	WriteTimeout time.Duration
+
+	// Max size of an upstream message in bytes
+	MaxMessageSize int64
+
+	// Number of upstream messages buffered until the client reads them.
+	// Exceeding it causes rejecting of a message with 429 status code.
+	InboundBufferSize int
+
+	// Additional headers of the event stream response (e.g. CORS headers)
+	Header http.Header
+
+	Metrics  MetricsCollector
+	Observer Observer
+}
+
+// NewSSEConnectionUpgraderOptions initializes a new SSEConnectionUpgraderOptions.
+// nolint: gomnd
+func NewSSEConnectionUpgraderOptions() SSEConnectionUpgraderOptions {
+	options := SSEConnectionUpgraderOptions{
+		WriteTimeout:      10 * time.Second,
+		MaxMessageSize:    1 * 1024 * 1024,
+		InboundBufferSize: 256,
+	}
+
+	return options
+}
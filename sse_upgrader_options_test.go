@@ -0,0 +1,18 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSSEConnectionUpgraderOptions(t *testing.T) {
+	options := wspubsub.NewSSEConnectionUpgraderOptions()
+	require.NotZero(t, options.WriteTimeout)
+	require.NotZero(t, options.MaxMessageSize)
+	require.NotZero(t, options.InboundBufferSize)
+	require.Nil(t, options.Header)
+	require.Nil(t, options.Metrics)
+	require.Nil(t, options.Observer)
+}
@@ -0,0 +1,329 @@
+package wspubsub_test
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+type sseSession struct {
+	ClientID wspubsub.UUID `json:"client_id"`
+	Token    string        `json:"token"`
+}
+
+func TestSSEConnectionUpgrader(t *testing.T) {
+	upgrader := wspubsub.NewSSEConnectionUpgrader(wspubsub.NewSSEConnectionUpgraderOptions())
+	hub, server, connected := newSSETestServer(t, upgrader)
+
+	received := make(chan wspubsub.Message, 1)
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+	})
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	response, events := openSSEStream(t, server.URL)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	require.Equal(t, "no-cache", response.Header.Get("Cache-Control"))
+
+	session := readSSESession(t, events)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+	require.Equal(t, 1, hub.Count())
+
+	t.Run("Receive text message", func(t *testing.T) {
+		status := postSSEMessage(t, server.URL, session.ClientID, session.Token, "text/plain", "hello")
+		require.Equal(t, http.StatusAccepted, status)
+		require.Equal(t, wspubsub.NewTextMessageFromString("hello"), receiveMessage(t, received))
+	})
+
+	t.Run("Receive binary message", func(t *testing.T) {
+		status := postSSEMessage(t, server.URL, session.ClientID, session.Token, "application/octet-stream", "\x01\x02")
+		require.Equal(t, http.StatusAccepted, status)
+		require.Equal(t, wspubsub.NewBinaryMessage([]byte{1, 2}), receiveMessage(t, received))
+	})
+
+	t.Run("Reject invalid token", func(t *testing.T) {
+		status := postSSEMessage(t, server.URL, session.ClientID, "invalid", "text/plain", "hello")
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Reject unknown client", func(t *testing.T) {
+		status := postSSEMessage(t, server.URL, wspubsub.UUID{1}, session.Token, "text/plain", "hello")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Reject invalid client ID", func(t *testing.T) {
+		response, err := http.Post(server.URL+"/messages?client_id=invalid", "text/plain", strings.NewReader("hello"))
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("Reject invalid method", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/messages?client_id=" + session.ClientID.String())
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+	})
+
+	t.Run("Publish text message", func(t *testing.T) {
+		err := hub.Subscribe(session.ClientID, "X")
+		require.NoError(t, err)
+
+		numClients, err := hub.Publish(wspubsub.NewTextMessageFromString("line 1\nline 2"), "X")
+		require.NoError(t, err)
+		require.Equal(t, 1, numClients)
+		require.Equal(t, sseEvent{Data: "line 1\nline 2"}, readSSEEvent(t, events))
+	})
+
+	t.Run("Send binary message", func(t *testing.T) {
+		err := hub.Send(session.ClientID, wspubsub.NewBinaryMessage([]byte{1, 2, 3}))
+		require.NoError(t, err)
+
+		event := readSSEEvent(t, events)
+		require.Equal(t, "binary", event.Event)
+
+		payload, err := base64.StdEncoding.DecodeString(event.Data)
+		require.NoError(t, err)
+		require.Equal(t, []byte{1, 2, 3}, payload)
+	})
+
+	t.Run("Disconnect with code", func(t *testing.T) {
+		err := hub.DisconnectWithCode(session.ClientID, wspubsub.CloseCodeGoingAway, "bye")
+		require.NoError(t, err)
+		require.Equal(t, sseEvent{Event: "close", Data: `{"code":1001,"reason":"bye"}`}, readSSEEvent(t, events))
+
+		_, err = events.ReadString('\n')
+		require.Equal(t, io.EOF, err)
+		require.Equal(t, session.ClientID, receiveClientID(t, disconnected))
+
+		status := postSSEMessage(t, server.URL, session.ClientID, session.Token, "text/plain", "hello")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestSSEConnectionUpgrader_ClientClose(t *testing.T) {
+	upgrader := wspubsub.NewSSEConnectionUpgrader(wspubsub.NewSSEConnectionUpgraderOptions())
+	hub, server, connected := newSSETestServer(t, upgrader)
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	response, events := openSSEStream(t, server.URL)
+	session := readSSESession(t, events)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+
+	err := response.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, session.ClientID, receiveClientID(t, disconnected))
+	require.Equal(t, 0, hub.Count())
+}
+
+func TestSSEConnectionUpgrader_InboundBufferOverflow(t *testing.T) {
+	options := wspubsub.NewSSEConnectionUpgraderOptions()
+	options.InboundBufferSize = 1
+	upgrader := wspubsub.NewSSEConnectionUpgrader(options)
+	hub, server, connected := newSSETestServer(t, upgrader)
+
+	release := make(chan struct{})
+	received := make(chan wspubsub.Message, 3)
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		received <- message
+		<-release
+	})
+
+	_, events := openSSEStream(t, server.URL)
+	session := readSSESession(t, events)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+
+	// The first message blocks the handler, the second one fills the buffer
+	status := postSSEMessage(t, server.URL, session.ClientID, session.Token, "text/plain", "1")
+	require.Equal(t, http.StatusAccepted, status)
+	receiveMessage(t, received)
+
+	status = postSSEMessage(t, server.URL, session.ClientID, session.Token, "text/plain", "2")
+	require.Equal(t, http.StatusAccepted, status)
+
+	status = postSSEMessage(t, server.URL, session.ClientID, session.Token, "text/plain", "3")
+	require.Equal(t, http.StatusTooManyRequests, status)
+
+	close(release)
+	require.Equal(t, wspubsub.NewTextMessageFromString("2"), receiveMessage(t, received))
+}
+
+func TestSSEConnectionUpgrader_MaxMessageSize(t *testing.T) {
+	options := wspubsub.NewSSEConnectionUpgraderOptions()
+	options.MaxMessageSize = 4
+	upgrader := wspubsub.NewSSEConnectionUpgrader(options)
+	_, server, connected := newSSETestServer(t, upgrader)
+
+	_, events := openSSEStream(t, server.URL)
+	session := readSSESession(t, events)
+	require.Equal(t, session.ClientID, receiveClientID(t, connected))
+
+	status := postSSEMessage(t, server.URL, session.ClientID, session.Token, "text/plain", "hello")
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+}
+
+func TestSSEConnectionUpgrader_InvalidMethod(t *testing.T) {
+	upgrader := wspubsub.NewSSEConnectionUpgrader(wspubsub.NewSSEConnectionUpgraderOptions())
+	hub, server, _ := newSSETestServer(t, upgrader)
+
+	response, err := http.Post(server.URL, "text/plain", nil)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	require.Equal(t, 0, hub.Count())
+}
+
+// newSSETestServer serves the hub with event streams at "/" and upstream messages at "/messages".
+// IDs of connected clients are sent to the returned channel.
+func newSSETestServer(
+	t *testing.T,
+	upgrader *wspubsub.SSEConnectionUpgrader,
+) (*wspubsub.Hub, *httptest.Server, chan wspubsub.UUID) {
+	t.Helper()
+
+	logger := wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := wspubsub.NewClientFactory(wspubsub.NewClientOptions(), wspubsub.SatoriUUIDGenerator{}, upgrader, nil)
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	connected := make(chan wspubsub.UUID, 1)
+	hub.OnConnect(func(clientID wspubsub.UUID) {
+		connected <- clientID
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/", hub)
+	mux.Handle("/messages", upgrader.MessageHandler())
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		_ = hub.Close()
+		server.Close()
+	})
+
+	return hub, server, connected
+}
+
+func openSSEStream(t *testing.T, url string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	request.Header.Set("Accept", "text/event-stream")
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = response.Body.Close()
+	})
+
+	return response, bufio.NewReader(response.Body)
+}
+
+func readSSESession(t *testing.T, events *bufio.Reader) sseSession {
+	t.Helper()
+
+	event := readSSEEvent(t, events)
+	require.Equal(t, "session", event.Event)
+
+	session := sseSession{}
+	err := json.Unmarshal([]byte(event.Data), &session)
+	require.NoError(t, err)
+	require.NotEmpty(t, session.Token)
+
+	return session
+}
+
+// readSSEEvent reads the next event skipping comments (pings).
+func readSSEEvent(t *testing.T, events *bufio.Reader) sseEvent {
+	t.Helper()
+
+	event := sseEvent{}
+	var data []string
+	for {
+		line, err := events.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if data == nil {
+				continue
+			}
+
+			event.Data = strings.Join(data, "\n")
+
+			return event
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func postSSEMessage(t *testing.T, url string, clientID wspubsub.UUID, token, contentType, body string) int {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodPost, url+"/messages?client_id="+clientID.String(), strings.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set(wspubsub.SessionTokenHeader, token)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	return response.StatusCode
+}
+
+func receiveMessage(t *testing.T, messages chan wspubsub.Message) wspubsub.Message {
+	t.Helper()
+
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		require.FailNow(t, "message isn't received")
+	}
+
+	return wspubsub.Message{}
+}
+
+func receiveClientID(t *testing.T, clientIDs chan wspubsub.UUID) wspubsub.UUID {
+	t.Helper()
+
+	select {
+	case clientID := <-clientIDs:
+		return clientID
+	case <-time.After(time.Second):
+		require.FailNow(t, "client ID isn't received")
+	}
+
+	return wspubsub.UUID{}
+}
@@ -0,0 +1,161 @@
+package wspubsub
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ WebsocketConnection         = (*StreamConnection)(nil)
+	_ WebsocketConnectionRTTMeter = (*StreamConnection)(nil)
+)
+
+// StreamConnection is an implementation of WebsocketConnection
+// over a raw TCP or Unix-domain connection with length-prefixed frames (see WriteStreamFrame).
+// Pings are answered by pongs by both sides.
+type StreamConnection struct {
+	conn           net.Conn
+	reader         *bufio.Reader
+	metrics        MetricsCollector
+	maxMessageSize int64
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	observer       Observer
+	remoteAddr     string
+	rtt            rttMeter
+	mu             sync.Mutex
+}
+
+// Read reads a text or binary message from the connection.
+func (c *StreamConnection) Read() (Message, error) {
+	for {
+		err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		if err != nil {
+			return Message{}, errors.WithStack(c.handleError(err))
+		}
+
+		message, err := ReadStreamFrame(c.reader, c.maxMessageSize)
+		if err != nil {
+			return Message{}, errors.WithStack(c.handleError(err))
+		}
+
+		switch message.Type {
+		case MessageTypeText, MessageTypeBinary:
+			return message, nil
+		case MessageTypePing:
+			err := c.write(Message{Type: StreamFramePong, Payload: message.Payload})
+			if err != nil {
+				return Message{}, errors.WithStack(c.handleError(err))
+			}
+		case StreamFramePong:
+			c.rtt.PongReceived(time.Now())
+		case MessageTypeClose:
+			return Message{}, errors.WithStack(NewConnectionClosedError(io.EOF))
+		}
+	}
+}
+
+// Write writes a message to the connection.
+func (c *StreamConnection) Write(message Message) (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.stream_connection.write", RemoteAddr: c.remoteAddr, Size: len(message.Payload)},
+	)
+	defer op.end(&err)
+
+	if c.metrics != nil {
+		now := time.Now()
+		defer func() {
+			c.metrics.ConnectionWritten("stream", time.Since(now))
+		}()
+	}
+
+	if message.Type == MessageTypePing {
+		c.rtt.PingSent(time.Now())
+	}
+
+	err = c.write(message)
+	if err != nil {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// Close closes the connection.
+func (c *StreamConnection) Close() (err error) {
+	op := startOperation(
+		c.observer,
+		OperationEvent{Name: "wspubsub.stream_connection.close", RemoteAddr: c.remoteAddr},
+	)
+	defer op.end(&err)
+
+	err = c.conn.Close()
+	if err != nil {
+		return errors.WithStack(c.handleError(err))
+	}
+
+	return nil
+}
+
+// RTT returns the round-trip time of the last answered ping.
+func (c *StreamConnection) RTT() time.Duration {
+	return c.rtt.RTT()
+}
+
+// write is safe for concurrent use, since pings of the client are answered by the reader.
+func (c *StreamConnection) write(message Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if err != nil {
+		return err
+	}
+
+	return WriteStreamFrame(c.conn, message)
+}
+
+func (c *StreamConnection) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := IsConnectionClosedError(err); ok {
+		return errors.WithStack(err)
+	}
+
+	cause := errors.Cause(err)
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF {
+		return errors.WithStack(NewConnectionClosedError(cause))
+	}
+
+	if strings.Contains(err.Error(), "use of closed network connection") {
+		return errors.WithStack(NewConnectionClosedError(err))
+	}
+
+	if _, ok := cause.(*net.OpError); ok {
+		return errors.WithStack(NewConnectionClosedError(cause))
+	}
+
+	return errors.WithStack(err)
+}
+
+func newStreamConnection(conn net.Conn, options StreamConnectionUpgraderOptions) *StreamConnection {
+	return &StreamConnection{
+		conn:           conn,
+		reader:         bufio.NewReader(conn),
+		metrics:        options.Metrics,
+		maxMessageSize: options.MaxMessageSize,
+		readTimeout:    options.ReadTimeout,
+		writeTimeout:   options.WriteTimeout,
+		observer:       options.Observer,
+		remoteAddr:     conn.RemoteAddr().String(),
+	}
+}
@@ -0,0 +1,60 @@
+package wspubsub
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// StreamFramePong is a type of the stream frame answering a ping.
+const StreamFramePong MessageType = 10
+
+const streamFrameHeaderSize = 5
+
+// WriteStreamFrame writes a message as a frame of the stream transport.
+// A frame consists of the message type (1 byte), the payload length (4 bytes, big-endian) and the payload:
+//
+//	+---------+-------------------+-----------------+
+//	| type: 1 | length: 4 (BE)    | payload: length |
+//	+---------+-------------------+-----------------+
+//
+// Types are the same as MessageType (1 - text, 2 - binary, 8 - close, 9 - ping) and 10 - pong.
+func WriteStreamFrame(w io.Writer, message Message) error {
+	frame := make([]byte, streamFrameHeaderSize+len(message.Payload))
+	frame[0] = byte(message.Type)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message.Payload)))
+	copy(frame[streamFrameHeaderSize:], message.Payload)
+
+	_, err := w.Write(frame)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// ReadStreamFrame reads a frame of the stream transport (see WriteStreamFrame).
+// Frames with a payload larger than maxSize bytes are rejected.
+func ReadStreamFrame(r io.Reader, maxSize int64) (Message, error) {
+	header := make([]byte, streamFrameHeaderSize)
+
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return Message{}, errors.WithStack(err)
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[1:]))
+	if size > maxSize {
+		return Message{}, errors.Errorf("wspubsub: stream frame is too large: size=%d, max_size=%d", size, maxSize)
+	}
+
+	payload := make([]byte, size)
+
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return Message{}, errors.WithStack(err)
+	}
+
+	return Message{Type: MessageType(header[0]), Payload: payload}, nil
+}
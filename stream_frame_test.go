@@ -0,0 +1,46 @@
+package wspubsub_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamFrame(t *testing.T) {
+	buf := bytes.Buffer{}
+
+	err := wspubsub.WriteStreamFrame(&buf, wspubsub.NewTextMessageFromString("hello"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o'}, buf.Bytes())
+
+	err = wspubsub.WriteStreamFrame(&buf, wspubsub.NewPingMessage())
+	require.NoError(t, err)
+
+	message, err := wspubsub.ReadStreamFrame(&buf, 5)
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.NewTextMessageFromString("hello"), message)
+
+	message, err = wspubsub.ReadStreamFrame(&buf, 5)
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.MessageTypePing, message.Type)
+	require.Empty(t, message.Payload)
+
+	_, err = wspubsub.ReadStreamFrame(&buf, 5)
+	require.Equal(t, io.EOF, errors.Cause(err))
+}
+
+func TestReadStreamFrame_Invalid(t *testing.T) {
+	_, err := wspubsub.ReadStreamFrame(bytes.NewReader([]byte{1, 0, 0, 0, 6, 'h', 'e', 'l', 'l', 'o', '!'}), 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "too large")
+
+	_, err = wspubsub.ReadStreamFrame(bytes.NewReader([]byte{1, 0, 0, 0, 5, 'h'}), 5)
+	require.Equal(t, io.ErrUnexpectedEOF, errors.Cause(err))
+
+	_, err = wspubsub.ReadStreamFrame(bytes.NewReader([]byte{1, 0}), 5)
+	require.Equal(t, io.ErrUnexpectedEOF, errors.Cause(err))
+}
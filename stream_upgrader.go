@@ -0,0 +1,177 @@
+package wspubsub
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+)
+
+var _ WebsocketConnectionUpgrader = (*StreamConnectionUpgrader)(nil)
+
+type streamConnKey struct{}
+
+// StreamConnectionUpgrader is an implementation of WebsocketConnectionUpgrader
+// which accepts raw TCP or Unix-domain connections (see StreamConnection),
+// e.g. for internal backend services.
+//
+// Accepted connections are passed to the hub as requests with the remote address,
+// so they are limited, authorized and stored as ordinary clients.
+// Since requests have no headers, an Authenticator of the hub rejects them:
+// the listener should be reachable by trusted services only.
+//
+//	upgrader := wspubsub.NewMultiConnectionUpgrader(
+//		wspubsub.UpgraderRule{Match: wspubsub.IsStreamRequest, Upgrader: streamUpgrader},
+//		wspubsub.UpgraderRule{Match: wspubsub.IsWebsocketRequest, Upgrader: gorillaUpgrader},
+//	)
+//	...
+//	go streamUpgrader.ListenAndServe("unix", "/run/hub.sock", hub)
+//
+// A rejected connection receives a close frame with the status text of the hub response.
+type StreamConnectionUpgrader struct {
+	options   StreamConnectionUpgraderOptions
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	closed    bool
+}
+
+// Upgrade takes the connection accepted by Serve.
+func (u *StreamConnectionUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (_ WebsocketConnection, err error) {
+	op := startOperation(
+		u.options.Observer,
+		OperationEvent{Name: "wspubsub.stream_upgrader.upgrade", RemoteAddr: r.RemoteAddr},
+	)
+	defer op.end(&err)
+
+	response, ok := w.(*streamResponseWriter)
+	if !ok || response.conn == nil {
+		return nil, errors.New("wspubsub: request isn't a stream connection")
+	}
+
+	connection := newStreamConnection(response.conn, u.options)
+	response.conn = nil
+
+	return connection, nil
+}
+
+// ListenAndServe listens on the network address ("tcp", "unix", etc.)
+// and passes accepted connections to the handler (usually the hub).
+func (u *StreamConnectionUpgrader) ListenAndServe(network, address string, handler http.Handler) error {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return u.Serve(listener, handler)
+}
+
+// Serve accepts connections on the listener and passes them to the handler (usually the hub).
+// It returns nil after the upgrader is closed.
+func (u *StreamConnectionUpgrader) Serve(listener net.Listener, handler http.Handler) error {
+	u.mu.Lock()
+	if u.closed {
+		u.mu.Unlock()
+		_ = listener.Close()
+
+		return nil
+	}
+
+	u.listeners[listener] = struct{}{}
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		delete(u.listeners, listener)
+		u.mu.Unlock()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			u.mu.Lock()
+			closed := u.closed
+			u.mu.Unlock()
+
+			if closed {
+				return nil
+			}
+
+			return errors.WithStack(err)
+		}
+
+		go u.serveConn(conn, handler)
+	}
+}
+
+// Close stops accepting connections.
+// Accepted connections are closed by the hub.
+func (u *StreamConnectionUpgrader) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.closed = true
+
+	var errList error
+	for listener := range u.listeners {
+		errList = multierr.Append(errList, listener.Close())
+	}
+
+	return errors.WithStack(errList)
+}
+
+func (u *StreamConnectionUpgrader) serveConn(conn net.Conn, handler http.Handler) {
+	ctx := context.WithValue(context.Background(), streamConnKey{}, true)
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", http.NoBody)
+	request.RemoteAddr = conn.RemoteAddr().String()
+
+	response := &streamResponseWriter{header: http.Header{}, status: http.StatusOK, conn: conn}
+	handler.ServeHTTP(response, request)
+
+	// The connection wasn't upgraded
+	if response.conn != nil {
+		code := CloseCodeInternalError
+		if response.status < http.StatusInternalServerError {
+			code = CloseCodePolicyViolation
+		}
+
+		_ = WriteStreamFrame(conn, NewCloseMessage(code, http.StatusText(response.status)))
+		_ = conn.Close()
+	}
+}
+
+// IsStreamRequest reports whether the request carries a connection accepted by StreamConnectionUpgrader.
+func IsStreamRequest(r *http.Request) bool {
+	v, _ := r.Context().Value(streamConnKey{}).(bool)
+
+	return v
+}
+
+// streamResponseWriter records the status of the hub response to a stream connection.
+type streamResponseWriter struct {
+	header http.Header
+	status int
+	conn   net.Conn
+}
+
+func (w *streamResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *streamResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *streamResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// NewStreamConnectionUpgrader initializes a new StreamConnectionUpgrader.
+func NewStreamConnectionUpgrader(options StreamConnectionUpgraderOptions) *StreamConnectionUpgrader {
+	return &StreamConnectionUpgrader{
+		options:   options,
+		listeners: make(map[net.Listener]struct{}),
+	}
+}
@@ -0,0 +1,26 @@
+package wspubsub
+
+import (
+	"time"
+)
+
+// StreamConnectionUpgraderOptions represents configuration of the StreamConnectionUpgrader.
+type StreamConnectionUpgraderOptions struct {
+	MaxMessageSize int64
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	Metrics        MetricsCollector
+	Observer       Observer
+}
+
+// NewStreamConnectionUpgraderOptions initializes a new StreamConnectionUpgraderOptions.
+// nolint: gomnd
+func NewStreamConnectionUpgraderOptions() StreamConnectionUpgraderOptions {
+	options := StreamConnectionUpgraderOptions{
+		MaxMessageSize: 1 * 1024 * 1024,
+		ReadTimeout:    60 * time.Second,
+		WriteTimeout:   10 * time.Second,
+	}
+
+	return options
+}
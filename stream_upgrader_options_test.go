@@ -0,0 +1,17 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStreamConnectionUpgraderOptions(t *testing.T) {
+	options := wspubsub.NewStreamConnectionUpgraderOptions()
+	require.NotZero(t, options.MaxMessageSize)
+	require.NotZero(t, options.ReadTimeout)
+	require.NotZero(t, options.WriteTimeout)
+	require.Nil(t, options.Metrics)
+	require.Nil(t, options.Observer)
+}
@@ -0,0 +1,272 @@
+package wspubsub_test
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamConnectionUpgrader(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		address func(t *testing.T) string
+	}{
+		{name: "TCP", network: "tcp", address: func(t *testing.T) string { return "127.0.0.1:0" }},
+		{name: "Unix", network: "unix", address: func(t *testing.T) string { return filepath.Join(t.TempDir(), "hub.sock") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrader := wspubsub.NewStreamConnectionUpgrader(wspubsub.NewStreamConnectionUpgraderOptions())
+
+			clientOptions := wspubsub.NewClientOptions()
+			clientOptions.PingInterval = 10 * time.Millisecond
+			hub, connected := newStreamTestHub(t, upgrader, wspubsub.NewHubOptions(), clientOptions)
+
+			received := make(chan wspubsub.Message, 1)
+			hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+				received <- message
+			})
+
+			disconnected := make(chan wspubsub.UUID, 1)
+			hub.OnDisconnect(func(clientID wspubsub.UUID) {
+				disconnected <- clientID
+			})
+
+			listener, err := net.Listen(tt.network, tt.address(t))
+			require.NoError(t, err)
+			served := serveStream(t, upgrader, listener, hub)
+
+			conn, err := net.Dial(tt.network, listener.Addr().String())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			clientID := receiveClientID(t, connected)
+			require.Equal(t, 1, hub.Count())
+
+			err = wspubsub.WriteStreamFrame(conn, wspubsub.NewTextMessageFromString("hello"))
+			require.NoError(t, err)
+			require.Equal(t, wspubsub.NewTextMessageFromString("hello"), receiveMessage(t, received))
+
+			err = hub.Subscribe(clientID, "X")
+			require.NoError(t, err)
+
+			numClients, err := hub.Publish(wspubsub.NewBinaryMessageFromString("news"), "X")
+			require.NoError(t, err)
+			require.Equal(t, 1, numClients)
+			require.Equal(t, wspubsub.NewBinaryMessageFromString("news"), readStreamMessage(t, conn))
+
+			// Ping the hub and answer its ping
+			err = wspubsub.WriteStreamFrame(conn, wspubsub.Message{Type: wspubsub.MessageTypePing, Payload: []byte("1")})
+			require.NoError(t, err)
+
+			pinged, ponged := false, false
+			for !pinged || !ponged {
+				message, err := wspubsub.ReadStreamFrame(conn, 1024)
+				require.NoError(t, err)
+
+				switch message.Type {
+				case wspubsub.MessageTypePing:
+					err = wspubsub.WriteStreamFrame(conn, wspubsub.Message{Type: wspubsub.StreamFramePong})
+					require.NoError(t, err)
+					pinged = true
+				case wspubsub.StreamFramePong:
+					require.Equal(t, []byte("1"), message.Payload)
+					ponged = true
+				}
+			}
+
+			require.Eventually(t, func() bool {
+				info, err := hub.ClientInfo(clientID)
+
+				return err == nil && info.RTT > 0
+			}, time.Second, time.Millisecond)
+
+			err = conn.Close()
+			require.NoError(t, err)
+			require.Equal(t, clientID, receiveClientID(t, disconnected))
+			require.Equal(t, 0, hub.Count())
+
+			err = upgrader.Close()
+			require.NoError(t, err)
+			require.NoError(t, <-served)
+		})
+	}
+}
+
+func TestStreamConnectionUpgrader_Disconnect(t *testing.T) {
+	options := wspubsub.NewStreamConnectionUpgraderOptions()
+	options.MaxMessageSize = 4
+	upgrader := wspubsub.NewStreamConnectionUpgrader(options)
+	hub, connected := newStreamTestHub(t, upgrader, wspubsub.NewHubOptions(), wspubsub.NewClientOptions())
+
+	disconnected := make(chan wspubsub.UUID, 1)
+	hub.OnDisconnect(func(clientID wspubsub.UUID) {
+		disconnected <- clientID
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveStream(t, upgrader, listener, hub)
+
+	t.Run("Disconnect with code", func(t *testing.T) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		clientID := receiveClientID(t, connected)
+
+		err = hub.DisconnectWithCode(clientID, wspubsub.CloseCodeGoingAway, "bye")
+		require.NoError(t, err)
+		require.Equal(t, clientID, receiveClientID(t, disconnected))
+		require.Equal(t, wspubsub.NewCloseMessage(wspubsub.CloseCodeGoingAway, "bye"), readStreamMessage(t, conn))
+
+		_, err = wspubsub.ReadStreamFrame(conn, 1024)
+		require.Equal(t, io.EOF, errors.Cause(err))
+	})
+
+	t.Run("Close frame", func(t *testing.T) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		clientID := receiveClientID(t, connected)
+
+		err = wspubsub.WriteStreamFrame(conn, wspubsub.NewCloseMessage(wspubsub.CloseCodeNormalClosure, ""))
+		require.NoError(t, err)
+		require.Equal(t, clientID, receiveClientID(t, disconnected))
+	})
+
+	t.Run("Too large frame", func(t *testing.T) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		clientID := receiveClientID(t, connected)
+
+		err = wspubsub.WriteStreamFrame(conn, wspubsub.NewTextMessageFromString("hello"))
+		require.NoError(t, err)
+		require.Equal(t, clientID, receiveClientID(t, disconnected))
+	})
+}
+
+func TestStreamConnectionUpgrader_Reject(t *testing.T) {
+	upgrader := wspubsub.NewStreamConnectionUpgrader(wspubsub.NewStreamConnectionUpgraderOptions())
+
+	hubOptions := wspubsub.NewHubOptions()
+	hubOptions.ConnectionLimits.PerIP = 1
+	hub, connected := newStreamTestHub(t, upgrader, hubOptions, wspubsub.NewClientOptions())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveStream(t, upgrader, listener, hub)
+
+	conn1, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn1.Close()
+	receiveClientID(t, connected)
+
+	conn2, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	message := readStreamMessage(t, conn2)
+	require.Equal(t, wspubsub.NewCloseMessage(wspubsub.CloseCodePolicyViolation, http.StatusText(http.StatusTooManyRequests)), message)
+	require.Equal(t, 1, hub.Count())
+}
+
+func TestStreamConnectionUpgrader_Upgrade(t *testing.T) {
+	upgrader := wspubsub.NewStreamConnectionUpgrader(wspubsub.NewStreamConnectionUpgraderOptions())
+
+	request, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	require.False(t, wspubsub.IsStreamRequest(request))
+
+	_, err = upgrader.Upgrade(nil, request)
+	require.Error(t, err)
+}
+
+func TestStreamConnectionUpgrader_ServeClosed(t *testing.T) {
+	upgrader := wspubsub.NewStreamConnectionUpgrader(wspubsub.NewStreamConnectionUpgraderOptions())
+	err := upgrader.Close()
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	err = upgrader.Serve(listener, http.NotFoundHandler())
+	require.NoError(t, err)
+
+	_, err = net.Dial("tcp", listener.Addr().String())
+	require.Error(t, err)
+}
+
+// newStreamTestHub initializes a hub with the upgrader.
+// IDs of connected clients are sent to the returned channel.
+func newStreamTestHub(
+	t *testing.T,
+	upgrader *wspubsub.StreamConnectionUpgrader,
+	hubOptions wspubsub.HubOptions,
+	clientOptions wspubsub.ClientOptions,
+) (*wspubsub.Hub, chan wspubsub.UUID) {
+	t.Helper()
+
+	logger := wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := wspubsub.NewClientFactory(clientOptions, wspubsub.SatoriUUIDGenerator{}, upgrader, nil)
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	connected := make(chan wspubsub.UUID, 1)
+	hub.OnConnect(func(clientID wspubsub.UUID) {
+		connected <- clientID
+	})
+
+	t.Cleanup(func() {
+		_ = upgrader.Close()
+		_ = hub.Close()
+	})
+
+	return hub, connected
+}
+
+func serveStream(
+	t *testing.T,
+	upgrader *wspubsub.StreamConnectionUpgrader,
+	listener net.Listener,
+	hub *wspubsub.Hub,
+) chan error {
+	t.Helper()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- upgrader.Serve(listener, hub)
+	}()
+
+	return served
+}
+
+// readStreamMessage reads the next message skipping pings.
+func readStreamMessage(t *testing.T, conn net.Conn) wspubsub.Message {
+	t.Helper()
+
+	err := conn.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	for {
+		message, err := wspubsub.ReadStreamFrame(conn, 1024)
+		require.NoError(t, err)
+
+		if message.Type != wspubsub.MessageTypePing {
+			return message
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package wspubsub
+
+import "time"
+
+var _ Clock = (*SystemClock)(nil)
+
+// SystemClock is an implementation of Clock backed by the time package.
+type SystemClock struct{}
+
+// Now returns the current local time.
+func (c SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc calls f in its own goroutine after the duration elapses.
+func (c SystemClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
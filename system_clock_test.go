@@ -0,0 +1,28 @@
+package wspubsub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemClock(t *testing.T) {
+	clock := wspubsub.SystemClock{}
+	require.WithinDuration(t, time.Now(), clock.Now(), time.Second)
+
+	fired := make(chan struct{})
+	clock.AfterFunc(time.Millisecond, func() {
+		close(fired)
+	})
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		require.Fail(t, "timer didn't fire")
+	}
+
+	timer := clock.AfterFunc(time.Hour, func() {})
+	require.True(t, timer.Stop())
+}
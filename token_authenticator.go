@@ -0,0 +1,82 @@
+package wspubsub
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var _ Authenticator = (*TokenAuthenticator)(nil)
+
+// TokenValidator is an interface responsible for validating a token
+// and resolving an identity it belongs to.
+type TokenValidator interface {
+	Validate(token string) (Identity, error)
+}
+
+// TokenValidatorFunc is an adapter to allow the use of ordinary functions as TokenValidator.
+type TokenValidatorFunc func(token string) (Identity, error)
+
+// Validate calls fn(token).
+func (fn TokenValidatorFunc) Validate(token string) (Identity, error) {
+	return fn(token)
+}
+
+// TokenAuthenticator is an implementation of Authenticator.
+// It extracts a token from the request and passes it to the validator.
+type TokenAuthenticator struct {
+	options   TokenAuthenticatorOptions
+	validator TokenValidator
+}
+
+// Authenticate authenticates the request using the token.
+// Validator errors are reported with 401 status code unless
+// the validator returns HubAuthenticationError itself.
+func (a *TokenAuthenticator) Authenticate(request *http.Request) (Identity, error) {
+	token := a.Token(request)
+	if token == "" {
+		return Identity{}, errors.WithStack(NewHubUnauthorizedError(errors.New("token is missing")))
+	}
+
+	identity, err := a.validator.Validate(token)
+	if err != nil {
+		if _, ok := IsHubAuthenticationError(err); ok {
+			return Identity{}, errors.WithStack(err)
+		}
+
+		return Identity{}, errors.WithStack(NewHubUnauthorizedError(err))
+	}
+
+	return identity, nil
+}
+
+// Token extracts a token from the request.
+func (a *TokenAuthenticator) Token(request *http.Request) string {
+	if a.options.IsBearerEnabled {
+		const prefix = "Bearer "
+
+		header := request.Header.Get("Authorization")
+		if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+			return strings.TrimSpace(header[len(prefix):])
+		}
+	}
+
+	if a.options.CookieName != "" {
+		cookie, err := request.Cookie(a.options.CookieName)
+		if err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+
+	if a.options.QueryParameter != "" {
+		return request.URL.Query().Get(a.options.QueryParameter)
+	}
+
+	return ""
+}
+
+// NewTokenAuthenticator initializes a new TokenAuthenticator.
+func NewTokenAuthenticator(options TokenAuthenticatorOptions, validator TokenValidator) *TokenAuthenticator {
+	return &TokenAuthenticator{options: options, validator: validator}
+}
@@ -0,0 +1,26 @@
+package wspubsub
+
+// TokenAuthenticatorOptions represents configuration of the TokenAuthenticator.
+// Sources of a token are checked in the following order: bearer header, cookie, query parameter.
+// An empty name disables the corresponding source.
+type TokenAuthenticatorOptions struct {
+	// Whether to look for a token in the Authorization header using the Bearer scheme
+	IsBearerEnabled bool
+
+	// Name of a cookie containing a token
+	CookieName string
+
+	// Name of a query parameter containing a token
+	QueryParameter string
+}
+
+// NewTokenAuthenticatorOptions initializes a new TokenAuthenticatorOptions.
+func NewTokenAuthenticatorOptions() TokenAuthenticatorOptions {
+	options := TokenAuthenticatorOptions{
+		IsBearerEnabled: true,
+		CookieName:      "token",
+		QueryParameter:  "token",
+	}
+
+	return options
+}
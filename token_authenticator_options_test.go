@@ -0,0 +1,15 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTokenAuthenticatorOptions(t *testing.T) {
+	options := wspubsub.NewTokenAuthenticatorOptions()
+	require.True(t, options.IsBearerEnabled)
+	require.NotEmpty(t, options.CookieName)
+	require.NotEmpty(t, options.QueryParameter)
+}
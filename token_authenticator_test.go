@@ -0,0 +1,76 @@
+package wspubsub_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAuthenticator_Authenticate(t *testing.T) {
+	token := "secret"
+	identity := wspubsub.Identity{ID: "user"}
+
+	validator := wspubsub.TokenValidatorFunc(func(t string) (wspubsub.Identity, error) {
+		switch t {
+		case token:
+			return identity, nil
+		case "banned":
+			return wspubsub.Identity{}, wspubsub.NewHubForbiddenError(errors.New("banned"))
+		}
+
+		return wspubsub.Identity{}, errors.New("invalid token")
+	})
+
+	authenticator := wspubsub.NewTokenAuthenticator(wspubsub.NewTokenAuthenticatorOptions(), validator)
+
+	t.Run("Bearer token", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		id, err := authenticator.Authenticate(request)
+		require.NoError(t, err)
+		require.Equal(t, identity, id)
+	})
+
+	t.Run("Cookie token", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.AddCookie(&http.Cookie{Name: "token", Value: token})
+		id, err := authenticator.Authenticate(request)
+		require.NoError(t, err)
+		require.Equal(t, identity, id)
+	})
+
+	t.Run("Query token", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/?token="+token, nil)
+		id, err := authenticator.Authenticate(request)
+		require.NoError(t, err)
+		require.Equal(t, identity, id)
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/", nil)
+		_, err := authenticator.Authenticate(request)
+		e, ok := wspubsub.IsHubAuthenticationError(err)
+		require.True(t, ok)
+		require.Equal(t, http.StatusUnauthorized, e.StatusCode)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/?token=invalid", nil)
+		_, err := authenticator.Authenticate(request)
+		e, ok := wspubsub.IsHubAuthenticationError(err)
+		require.True(t, ok)
+		require.Equal(t, http.StatusUnauthorized, e.StatusCode)
+	})
+
+	t.Run("Forbidden token", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/?token=banned", nil)
+		_, err := authenticator.Authenticate(request)
+		e, ok := wspubsub.IsHubAuthenticationError(err)
+		require.True(t, ok)
+		require.Equal(t, http.StatusForbidden, e.StatusCode)
+	})
+}
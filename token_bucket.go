@@ -0,0 +1,82 @@
+package wspubsub
+
+import (
+	"math"
+	"time"
+)
+
+// tokenBucket implements the token bucket algorithm.
+// It isn't safe for concurrent use and a nil bucket never limits anything.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Allow reports whether n tokens are available without taking them.
+func (b *tokenBucket) Allow(now time.Time, n int) bool {
+	if b == nil {
+		return true
+	}
+
+	b.refill(now)
+
+	return b.tokens >= b.cost(n)
+}
+
+// Take removes n tokens from the bucket.
+func (b *tokenBucket) Take(n int) {
+	if b == nil {
+		return
+	}
+
+	b.tokens -= b.cost(n)
+}
+
+// Reserve takes n tokens from the bucket even if they are not available yet
+// and returns how long the caller should wait before the reservation is fulfilled.
+func (b *tokenBucket) Reserve(now time.Time, n int) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.refill(now)
+	b.tokens -= b.cost(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+	b.last = now
+}
+
+// A single request can't be bigger than the burst,
+// otherwise it would never be allowed.
+func (b *tokenBucket) cost(n int) float64 {
+	return math.Min(float64(n), b.burst)
+}
+
+// newTokenBucket initializes a new full tokenBucket.
+// A non-positive rate disables limiting, so nil is returned.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	size := float64(burst)
+	if size < 1 {
+		size = math.Max(1, rate)
+	}
+
+	return &tokenBucket{rate: rate, burst: size, tokens: size, last: time.Now()}
+}
@@ -0,0 +1,54 @@
+package wspubsub
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Topic is a type-safe handle of a hub channel carrying values of type T.
+// Values are encoded with the topic codec, so publishers and consumers of the channel
+// agree on the payload type at compile time.
+type Topic[T any] struct {
+	hub     *Hub
+	channel string
+	codec   Codec
+}
+
+// Name returns the name of the underlying channel.
+func (t *Topic[T]) Name() string {
+	return t.channel
+}
+
+// Publish encodes the value once and publishes it to the topic subscribers.
+func (t *Topic[T]) Publish(v T) (int, error) {
+	message, err := t.codec.Encode(v)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return t.hub.Publish(message, t.channel)
+}
+
+// Subscribe subscribes a client to the topic.
+func (t *Topic[T]) Subscribe(clientID UUID) error {
+	return t.hub.Subscribe(clientID, t.channel)
+}
+
+// Unsubscribe unsubscribes a client from the topic.
+func (t *Topic[T]) Unsubscribe(clientID UUID) error {
+	return t.hub.Unsubscribe(clientID, t.channel)
+}
+
+// Count returns the number of the topic subscribers.
+func (t *Topic[T]) Count() int {
+	return t.hub.Count(t.channel)
+}
+
+// NewTopic initializes a new Topic of the hub channel.
+// Values are encoded with the codec (nil means the hub codec).
+func NewTopic[T any](hub *Hub, channel string, codec Codec) *Topic[T] {
+	if codec == nil {
+		codec = hub.options.Codec
+	}
+
+	return &Topic[T]{hub: hub, channel: channel, codec: codec}
+}
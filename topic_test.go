@@ -0,0 +1,111 @@
+package wspubsub_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestTopic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	client.
+		EXPECT().
+		ID().
+		AnyTimes().
+		Return(clientID)
+
+	client.
+		EXPECT().
+		Send(gomock.Eq(wspubsub.NewTextMessageFromString(`{"name":"TEST","count":1}`))).
+		Times(1)
+
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientStore.Set(client)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	topic := wspubsub.NewTopic[codecValue](hub, "X", nil)
+	require.Equal(t, "X", topic.Name())
+	require.Equal(t, 0, topic.Count())
+
+	err := topic.Subscribe(clientID)
+	require.NoError(t, err)
+	require.Equal(t, 1, topic.Count())
+
+	numClients, err := topic.Publish(codecValue{Name: "TEST", Count: 1})
+	require.NoError(t, err)
+	require.Equal(t, 1, numClients)
+
+	err = topic.Unsubscribe(clientID)
+	require.NoError(t, err)
+	require.Equal(t, 0, topic.Count())
+
+	numClients, err = topic.Publish(codecValue{Name: "TEST", Count: 2})
+	require.NoError(t, err)
+	require.Equal(t, 0, numClients)
+}
+
+func TestTopic_Codec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockLogger(ctrl)
+	logger.
+		EXPECT().
+		Debug(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	clientStore := mock.NewMockWebsocketClientStore(ctrl)
+	clientFactory := mock.NewMockWebsocketClientFactory(ctrl)
+	client := mock.NewMockWebsocketClient(ctrl)
+
+	message, err := wspubsub.ProtobufCodec{}.Encode(wrapperspb.String("TEST"))
+	require.NoError(t, err)
+
+	clientStore.
+		EXPECT().
+		Find(gomock.Any(), gomock.Eq("X")).
+		Times(1).
+		DoAndReturn(func(fn wspubsub.IterateFunc, channels ...string) error {
+			return fn(client)
+		})
+
+	client.
+		EXPECT().
+		Send(gomock.Eq(message)).
+		Times(1)
+
+	hubOptions := wspubsub.NewHubOptions()
+	hub := wspubsub.NewHub(hubOptions, clientStore, clientFactory, logger)
+
+	topic := wspubsub.NewTopic[*wrapperspb.StringValue](hub, "X", wspubsub.ProtobufCodec{})
+
+	t.Run("Publishing value success", func(t *testing.T) {
+		numClients, err := topic.Publish(wrapperspb.String("TEST"))
+		require.NoError(t, err)
+		require.Equal(t, 1, numClients)
+	})
+
+	t.Run("Publishing value error", func(t *testing.T) {
+		// Proto3 strings must be valid UTF-8
+		numClients, err := topic.Publish(wrapperspb.String("\xff"))
+		require.Error(t, err)
+		require.Equal(t, 0, numClients)
+	})
+}
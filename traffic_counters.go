@@ -0,0 +1,120 @@
+package wspubsub
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// trafficCounters counts messages of a client.
+// Counters of the parent (if any) are updated as well,
+// so the hub gets an aggregate without iterating clients.
+type trafficCounters struct {
+	parent           *trafficCounters
+	queueBytes       atomic.Int64
+	messagesSent     atomic.Uint64
+	bytesSent        atomic.Uint64
+	messagesReceived atomic.Uint64
+	bytesReceived    atomic.Uint64
+	messagesDropped  atomic.Uint64
+	bytesDropped     atomic.Uint64
+	writeErrors      atomic.Uint64
+	connectedAt      atomic.Int64
+	lastReadAt       atomic.Int64
+	lastWriteAt      atomic.Int64
+}
+
+// Enqueued adds a message to the size of the send buffer.
+// A negative size removes a message from the buffer.
+func (c *trafficCounters) Enqueued(size int) {
+	c.queueBytes.Add(int64(size))
+}
+
+// Sent counts a message written to the connection.
+func (c *trafficCounters) Sent(size int, now time.Time) {
+	c.messagesSent.Add(1)
+	c.bytesSent.Add(uint64(size))
+	c.Written(now)
+
+	if c.parent != nil {
+		c.parent.Sent(size, now)
+	}
+}
+
+// Written remembers the time of the last write to the connection.
+func (c *trafficCounters) Written(now time.Time) {
+	c.lastWriteAt.Store(now.UnixNano())
+}
+
+// Received counts a message read from the connection.
+func (c *trafficCounters) Received(size int, now time.Time) {
+	c.messagesReceived.Add(1)
+	c.bytesReceived.Add(uint64(size))
+	c.lastReadAt.Store(now.UnixNano())
+
+	if c.parent != nil {
+		c.parent.Received(size, now)
+	}
+}
+
+// Dropped counts a dropped message.
+func (c *trafficCounters) Dropped(size int) {
+	c.messagesDropped.Add(1)
+	c.bytesDropped.Add(uint64(size))
+
+	if c.parent != nil {
+		c.parent.Dropped(size)
+	}
+}
+
+// WriteFailed counts a failed write to the connection.
+func (c *trafficCounters) WriteFailed() {
+	c.writeErrors.Add(1)
+
+	if c.parent != nil {
+		c.parent.WriteFailed()
+	}
+}
+
+// Connected remembers the time of the connection.
+func (c *trafficCounters) Connected(now time.Time) {
+	c.connectedAt.Store(now.UnixNano())
+}
+
+// ClientStats returns a snapshot of the counters.
+func (c *trafficCounters) ClientStats() ClientStats {
+	return ClientStats{
+		QueueBytes:       c.queueBytes.Load(),
+		MessagesSent:     c.messagesSent.Load(),
+		BytesSent:        c.bytesSent.Load(),
+		MessagesReceived: c.messagesReceived.Load(),
+		BytesReceived:    c.bytesReceived.Load(),
+		MessagesDropped:  c.messagesDropped.Load(),
+		BytesDropped:     c.bytesDropped.Load(),
+		WriteErrors:      c.writeErrors.Load(),
+		ConnectedAt:      unixNanoTime(c.connectedAt.Load()),
+		LastReadAt:       unixNanoTime(c.lastReadAt.Load()),
+		LastWriteAt:      unixNanoTime(c.lastWriteAt.Load()),
+	}
+}
+
+// HubStats returns a snapshot of the aggregated counters.
+func (c *trafficCounters) HubStats() HubStats {
+	return HubStats{
+		MessagesSent:     c.messagesSent.Load(),
+		BytesSent:        c.bytesSent.Load(),
+		MessagesReceived: c.messagesReceived.Load(),
+		BytesReceived:    c.bytesReceived.Load(),
+		MessagesDropped:  c.messagesDropped.Load(),
+		BytesDropped:     c.bytesDropped.Load(),
+		WriteErrors:      c.writeErrors.Load(),
+	}
+}
+
+// unixNanoTime converts nanoseconds to the time (zero stays zero).
+func unixNanoTime(nsec int64) time.Time {
+	if nsec == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nsec)
+}
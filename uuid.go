@@ -1,6 +1,10 @@
 package wspubsub
 
-import "encoding/hex"
+import (
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
 
 // UUID represents a type compliant with specification described in RFC 4122.
 type UUID [16]byte
@@ -26,3 +30,40 @@ func (u UUID) String() string {
 func (u UUID) Bytes() []byte {
 	return u[:]
 }
+
+// MarshalText returns canonical text representation of UUID,
+// so structured loggers and JSON encoders don't render it as an array of bytes.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText parses canonical text representation of UUID.
+func (u *UUID) UnmarshalText(text []byte) error {
+	id, err := ParseUUID(string(text))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	*u = id
+
+	return nil
+}
+
+// ParseUUID parses canonical string representation of UUID,
+// e.g. "6ba7b810-9dad-11d1-80b4-00c04fd430c8".
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.Errorf("wspubsub: invalid UUID: %q", s)
+	}
+
+	src := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+
+	_, err := hex.Decode(u[:], src)
+	if err != nil {
+		return UUID{}, errors.Errorf("wspubsub: invalid UUID: %q", s)
+	}
+
+	return u, nil
+}
@@ -3,6 +3,7 @@ package wspubsub_test
 import (
 	"testing"
 
+	"github.com/kpeu3i/wspubsub"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,3 +14,28 @@ func TestUUID_Bytes(t *testing.T) {
 func TestUUID_String(t *testing.T) {
 	require.Equal(t, "01020304-0506-0708-090a-0b0c0d0e0f10", clientID.String())
 }
+
+func TestUUID_MarshalText(t *testing.T) {
+	text, err := clientID.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "01020304-0506-0708-090a-0b0c0d0e0f10", string(text))
+}
+
+func TestUUID_UnmarshalText(t *testing.T) {
+	var id wspubsub.UUID
+
+	err := id.UnmarshalText([]byte("01020304-0506-0708-090a-0b0c0d0e0f10"))
+	require.NoError(t, err)
+	require.Equal(t, clientID, id)
+}
+
+func TestParseUUID(t *testing.T) {
+	id, err := wspubsub.ParseUUID("01020304-0506-0708-090a-0b0c0d0e0f10")
+	require.NoError(t, err)
+	require.Equal(t, clientID, id)
+
+	for _, s := range []string{"", "01020304050607080900a0b0c0d0e0f10", "01020304-0506-0708-090a-0b0c0d0e0fzz"} {
+		_, err := wspubsub.ParseUUID(s)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,38 @@
+package wspubsubclient
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff represents an exponential backoff with jitter between reconnection attempts.
+type Backoff struct {
+	// Delay before the first reconnection attempt
+	Min time.Duration
+
+	// Max delay between reconnection attempts
+	Max time.Duration
+
+	// Multiplier of the delay after each failed attempt
+	Factor float64
+
+	// Fraction of the delay which is randomized (from 0 to 1),
+	// so clients disconnected at once don't reconnect at once
+	Jitter float64
+}
+
+// Delay returns a delay before the attempt (starting from zero).
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Min) * math.Pow(b.Factor, float64(attempt))
+	if delay > float64(b.Max) || math.IsInf(delay, 0) || math.IsNaN(delay) {
+		delay = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		// nolint: gosec
+		delay -= delay * b.Jitter * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,24 @@
+package wspubsubclient_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub/wspubsubclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := wspubsubclient.Backoff{Min: 100 * time.Millisecond, Max: time.Second, Factor: 2}
+	require.Equal(t, 100*time.Millisecond, backoff.Delay(0))
+	require.Equal(t, 200*time.Millisecond, backoff.Delay(1))
+	require.Equal(t, 800*time.Millisecond, backoff.Delay(3))
+	require.Equal(t, time.Second, backoff.Delay(4))
+	require.Equal(t, time.Second, backoff.Delay(10000))
+
+	backoff.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := backoff.Delay(1)
+		require.True(t, delay > 100*time.Millisecond && delay <= 200*time.Millisecond, delay)
+	}
+}
@@ -0,0 +1,532 @@
+package wspubsubclient
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+)
+
+type (
+	// MessageHandler called when the client receives a message.
+	MessageHandler func(message Message)
+
+	// ConnectHandler called when the client is connected (including reconnections).
+	ConnectHandler func()
+
+	// DisconnectHandler called when a connection is lost, the client reconnects after that.
+	DisconnectHandler func(err error)
+
+	// ErrorHandler called when an error occurred which doesn't cause a reconnection
+	// (e.g. a failed reconnection attempt).
+	ErrorHandler func(err error)
+)
+
+// nolint: gochecknoglobals
+var (
+	defaultMessageHandler    = MessageHandler(func(message Message) {})
+	defaultConnectHandler    = ConnectHandler(func() {})
+	defaultDisconnectHandler = DisconnectHandler(func(err error) {})
+	defaultErrorHandler      = ErrorHandler(func(err error) {})
+)
+
+// Client is a connection to a wspubsub hub which survives network failures.
+type Client struct {
+	options           ClientOptions
+	url               string
+	dialer            *websocket.Dialer
+	ctx               context.Context
+	cancel            context.CancelFunc
+	mu                sync.Mutex
+	conn              *websocket.Conn
+	channels          map[string]struct{}
+	offsets           map[string]string
+	isStarted         bool
+	isRunning         bool
+	isClosed          bool
+	writeMu           sync.Mutex
+	messageHandler    atomic.Value
+	connectHandler    atomic.Value
+	disconnectHandler atomic.Value
+	errorHandler      atomic.Value
+	numHandling       atomic.Int32
+	done              chan struct{}
+}
+
+// Connect dials the hub retrying with the backoff until the context is done.
+// Once connected, the client reconnects automatically until it is closed.
+func (c *Client) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+
+		return errors.WithStack(NewClientClosedError())
+	}
+
+	if c.isStarted {
+		c.mu.Unlock()
+
+		return errors.WithStack(NewClientRepeatConnectError())
+	}
+
+	c.isStarted = true
+	c.mu.Unlock()
+
+	// Closing the client cancels dialing
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(c.ctx, cancel)
+	defer stop()
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		c.mu.Lock()
+		c.isStarted = false
+		c.mu.Unlock()
+
+		return errors.WithStack(err)
+	}
+
+	if !c.connected(conn) {
+		return errors.WithStack(NewClientClosedError())
+	}
+
+	go c.run(conn)
+
+	return nil
+}
+
+// Subscribe subscribes the client to the channels.
+// Subscriptions are remembered and restored after a reconnection,
+// so it's allowed to subscribe before connecting.
+func (c *Client) Subscribe(channels ...string) error {
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+
+		return errors.WithStack(NewClientClosedError())
+	}
+
+	var offsets map[string]string
+	for _, channel := range channels {
+		c.channels[channel] = struct{}{}
+
+		if offset, ok := c.offsets[channel]; ok {
+			if offsets == nil {
+				offsets = make(map[string]string)
+			}
+
+			offsets[channel] = offset
+		}
+	}
+
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	return c.subscribe(conn, channels, offsets)
+}
+
+// Unsubscribe unsubscribes the client from the channels and forgets their offsets.
+// If channels were not specified then the client will be
+// unsubscribed from all channels.
+func (c *Client) Unsubscribe(channels ...string) error {
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+
+		return errors.WithStack(NewClientClosedError())
+	}
+
+	if len(channels) == 0 {
+		channels = c.sortedChannels()
+	}
+
+	for _, channel := range channels {
+		delete(c.channels, channel)
+		delete(c.offsets, channel)
+	}
+
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil || len(channels) == 0 {
+		return nil
+	}
+
+	message, err := c.options.Protocol.UnsubscribeMessage(channels)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return c.write(conn, message)
+}
+
+// Channels returns a list of channels the client is subscribed to.
+func (c *Client) Channels() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sortedChannels()
+}
+
+// Offset returns the last seen offset of the channel.
+func (c *Client) Offset(channel string) (string, bool) {
+	c.mu.Lock()
+	offset, ok := c.offsets[channel]
+	c.mu.Unlock()
+
+	return offset, ok
+}
+
+// Send writes a message to the hub.
+// ClientNotConnectedError is returned while the client is reconnecting.
+func (c *Client) Send(message wspubsub.Message) error {
+	c.mu.Lock()
+	conn := c.conn
+	isClosed := c.isClosed
+	c.mu.Unlock()
+
+	if isClosed {
+		return errors.WithStack(NewClientClosedError())
+	}
+
+	if conn == nil {
+		return errors.WithStack(NewClientNotConnectedError())
+	}
+
+	return c.write(conn, message)
+}
+
+// Close closes the connection and stops reconnecting.
+// It waits for the running handlers to return, unless it's called by a handler itself.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	c.isClosed = true
+	conn := c.conn
+	isRunning := c.isRunning
+	c.mu.Unlock()
+
+	c.cancel()
+
+	var err error
+	if conn != nil {
+		closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(c.options.WriteTimeout))
+		err = conn.Close()
+	}
+
+	// Handlers run in the goroutine closing done, so waiting for it inside a handler would deadlock
+	if isRunning && c.numHandling.Load() == 0 {
+		<-c.done
+	}
+
+	return errors.WithStack(err)
+}
+
+// OnMessage registers a handler for incoming messages.
+func (c *Client) OnMessage(handler MessageHandler) {
+	c.messageHandler.Store(handler)
+}
+
+// OnConnect registers a handler for connections and reconnections.
+func (c *Client) OnConnect(handler ConnectHandler) {
+	c.connectHandler.Store(handler)
+}
+
+// OnDisconnect registers a handler for lost connections.
+func (c *Client) OnDisconnect(handler DisconnectHandler) {
+	c.disconnectHandler.Store(handler)
+}
+
+// OnError registers a handler for errors which don't cause a reconnection.
+func (c *Client) OnError(handler ErrorHandler) {
+	c.errorHandler.Store(handler)
+}
+
+func (c *Client) run(conn *websocket.Conn) {
+	defer close(c.done)
+
+	for {
+		err := c.serve(conn)
+		_ = conn.Close()
+
+		c.mu.Lock()
+		c.conn = nil
+		isClosed := c.isClosed
+		c.mu.Unlock()
+
+		if isClosed {
+			return
+		}
+
+		disconnectHandler := c.disconnectHandler.Load().(DisconnectHandler)
+		c.handle(func() {
+			disconnectHandler(err)
+		})
+
+		conn, err = c.dial(c.ctx)
+		if err != nil {
+			return
+		}
+
+		if !c.connected(conn) {
+			return
+		}
+	}
+}
+
+// serve reads messages until the connection fails.
+func (c *Client) serve(conn *websocket.Conn) error {
+	quit := make(chan struct{})
+	defer close(quit)
+
+	go c.runPinger(conn, quit)
+
+	extendDeadline := func() error {
+		return conn.SetReadDeadline(time.Now().Add(c.options.ReadTimeout))
+	}
+
+	conn.SetPongHandler(func(string) error {
+		return extendDeadline()
+	})
+
+	conn.SetPingHandler(func(data string) error {
+		err := extendDeadline()
+		if err != nil {
+			return err
+		}
+
+		err = conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(c.options.WriteTimeout))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+
+		return err
+	})
+
+	for {
+		err := extendDeadline()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		messageType, payload, err := conn.ReadMessage()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		message := c.options.Protocol.Decode(wspubsub.Message{
+			Type:    wspubsub.MessageType(messageType),
+			Payload: payload,
+		})
+
+		c.trackOffset(message)
+
+		messageHandler := c.messageHandler.Load().(MessageHandler)
+		c.handle(func() {
+			messageHandler(message)
+		})
+	}
+}
+
+func (c *Client) runPinger(conn *websocket.Conn, quit chan struct{}) {
+	ticker := time.NewTicker(c.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.options.WriteTimeout))
+			if err != nil {
+				// The reader fails on the broken connection as well
+				return
+			}
+		}
+	}
+}
+
+// dial retries to connect with the backoff until the context is done.
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	errorHandler := c.errorHandler.Load().(ErrorHandler)
+	for attempt := 0; ; attempt++ {
+		conn, response, err := c.dialer.DialContext(ctx, c.url, c.options.Header)
+		if err == nil {
+			return conn, nil
+		}
+
+		if response != nil {
+			_ = response.Body.Close()
+		}
+
+		if c.ctx.Err() != nil {
+			return nil, errors.WithStack(NewClientClosedError())
+		}
+
+		c.handle(func() {
+			errorHandler(errors.WithStack(err))
+		})
+
+		timer := time.NewTimer(c.options.Backoff.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			if c.ctx.Err() != nil {
+				return nil, errors.WithStack(NewClientClosedError())
+			}
+
+			return nil, errors.WithStack(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// connected restores subscriptions of a new connection.
+// It returns false if the client was closed meanwhile.
+func (c *Client) connected(conn *websocket.Conn) bool {
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+		_ = conn.Close()
+
+		return false
+	}
+
+	c.conn = conn
+	c.isRunning = true
+	channels := c.sortedChannels()
+
+	var offsets map[string]string
+	if len(c.offsets) > 0 {
+		offsets = make(map[string]string, len(c.offsets))
+		for channel, offset := range c.offsets {
+			offsets[channel] = offset
+		}
+	}
+	c.mu.Unlock()
+
+	if len(channels) > 0 {
+		err := c.subscribe(conn, channels, offsets)
+		if err != nil {
+			errorHandler := c.errorHandler.Load().(ErrorHandler)
+			c.handle(func() {
+				errorHandler(err)
+			})
+		}
+	}
+
+	connectHandler := c.connectHandler.Load().(ConnectHandler)
+	c.handle(connectHandler)
+
+	return true
+}
+
+func (c *Client) subscribe(conn *websocket.Conn, channels []string, offsets map[string]string) error {
+	message, err := c.options.Protocol.SubscribeMessage(channels, offsets)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return c.write(conn, message)
+}
+
+func (c *Client) write(conn *websocket.Conn, message wspubsub.Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	err := conn.SetWriteDeadline(time.Now().Add(c.options.WriteTimeout))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = conn.WriteMessage(int(message.Type), message.Payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (c *Client) trackOffset(message Message) {
+	if message.Channel == "" || message.Offset == "" {
+		return
+	}
+
+	c.mu.Lock()
+	if _, ok := c.channels[message.Channel]; ok {
+		c.offsets[message.Channel] = message.Offset
+	}
+	c.mu.Unlock()
+}
+
+func (c *Client) reportError(err error) {
+	errorHandler := c.errorHandler.Load().(ErrorHandler)
+	c.handle(func() {
+		errorHandler(err)
+	})
+}
+
+func (c *Client) handle(handler func()) {
+	c.numHandling.Add(1)
+	defer c.numHandling.Add(-1)
+
+	handler()
+}
+
+// sortedChannels must be called holding mu.
+func (c *Client) sortedChannels() []string {
+	channels := make([]string, 0, len(c.channels))
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+
+	sort.Strings(channels)
+
+	return channels
+}
+
+// NewClient initializes a new Client of the hub listening the URL (e.g. "ws://localhost:8080/").
+func NewClient(options ClientOptions, url string) *Client {
+	dialer := options.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	client := &Client{
+		options:  options,
+		url:      url,
+		dialer:   dialer,
+		ctx:      ctx,
+		cancel:   cancel,
+		channels: make(map[string]struct{}),
+		offsets:  make(map[string]string),
+		done:     make(chan struct{}),
+	}
+
+	client.messageHandler.Store(defaultMessageHandler)
+	client.connectHandler.Store(defaultConnectHandler)
+	client.disconnectHandler.Store(defaultDisconnectHandler)
+	client.errorHandler.Store(defaultErrorHandler)
+
+	return client
+}
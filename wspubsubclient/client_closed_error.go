@@ -0,0 +1,25 @@
+package wspubsubclient
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ClientClosedError returned when using a closed client.
+type ClientClosedError struct{}
+
+// ClientClosedError implements an error interface.
+func (e *ClientClosedError) Error() string {
+	return "wspubsubclient: client is closed"
+}
+
+// NewClientClosedError initializes a new ClientClosedError.
+func NewClientClosedError() *ClientClosedError {
+	return &ClientClosedError{}
+}
+
+// IsClientClosedError checks if error type is ClientClosedError.
+func IsClientClosedError(err error) (*ClientClosedError, bool) {
+	v, ok := errors.Cause(err).(*ClientClosedError)
+
+	return v, ok
+}
@@ -0,0 +1,30 @@
+package wspubsubclient
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ClientDecodeError returned when a received message can't be decoded by a typed handler.
+type ClientDecodeError struct {
+	Message Message
+	Err     error
+}
+
+// ClientDecodeError implements an error interface.
+func (e *ClientDecodeError) Error() string {
+	return fmt.Sprintf("wspubsubclient: can't decode message: channel=%s, err=%s", e.Message.Channel, e.Err)
+}
+
+// NewClientDecodeError initializes a new ClientDecodeError.
+func NewClientDecodeError(message Message, err error) *ClientDecodeError {
+	return &ClientDecodeError{Message: message, Err: err}
+}
+
+// IsClientDecodeError checks if error type is ClientDecodeError.
+func IsClientDecodeError(err error) (*ClientDecodeError, bool) {
+	v, ok := errors.Cause(err).(*ClientDecodeError)
+
+	return v, ok
+}
@@ -0,0 +1,26 @@
+package wspubsubclient_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub/wspubsubclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDecodeError(t *testing.T) {
+	rawErr := errors.New("TEST")
+	message := wspubsubclient.Message{Channel: "X"}
+	err := wspubsubclient.NewClientDecodeError(message, rawErr)
+	require.Equal(t, message, err.Message)
+	require.Equal(t, rawErr, err.Err)
+	require.NotEmpty(t, err.Error())
+
+	e, ok := wspubsubclient.IsClientDecodeError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsubclient.IsClientDecodeError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -0,0 +1,25 @@
+package wspubsubclient
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ClientNotConnectedError returned when sending a message while the client is reconnecting.
+type ClientNotConnectedError struct{}
+
+// ClientNotConnectedError implements an error interface.
+func (e *ClientNotConnectedError) Error() string {
+	return "wspubsubclient: client is not connected"
+}
+
+// NewClientNotConnectedError initializes a new ClientNotConnectedError.
+func NewClientNotConnectedError() *ClientNotConnectedError {
+	return &ClientNotConnectedError{}
+}
+
+// IsClientNotConnectedError checks if error type is ClientNotConnectedError.
+func IsClientNotConnectedError(err error) (*ClientNotConnectedError, bool) {
+	v, ok := errors.Cause(err).(*ClientNotConnectedError)
+
+	return v, ok
+}
@@ -0,0 +1,52 @@
+package wspubsubclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ClientOptions represents configuration of the client.
+type ClientOptions struct {
+	// Dials a WebSocket connection (nil means websocket.DefaultDialer)
+	Dialer *websocket.Dialer
+
+	// Headers sent with a connection upgrade request (e.g. credentials)
+	Header http.Header
+
+	// How often pings will be sent by the client
+	PingInterval time.Duration
+
+	// Max time between messages (including pongs) received by the client.
+	// Exceeding it will cause a reconnection.
+	ReadTimeout time.Duration
+
+	// Write timeout of a message
+	WriteTimeout time.Duration
+
+	// Delays between reconnection attempts
+	Backoff Backoff
+
+	// Encodes subscription requests and decodes received messages
+	Protocol Protocol
+}
+
+// NewClientOptions initializes a new ClientOptions.
+// nolint: gomnd
+func NewClientOptions() ClientOptions {
+	options := ClientOptions{
+		PingInterval: 10 * time.Second,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		Backoff: Backoff{
+			Min:    100 * time.Millisecond,
+			Max:    30 * time.Second,
+			Factor: 2,
+			Jitter: 0.5,
+		},
+		Protocol: JSONProtocol{},
+	}
+
+	return options
+}
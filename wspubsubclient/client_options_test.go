@@ -0,0 +1,20 @@
+package wspubsubclient_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub/wspubsubclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientOptions(t *testing.T) {
+	options := wspubsubclient.NewClientOptions()
+	require.Nil(t, options.Dialer)
+	require.NotZero(t, options.PingInterval)
+	require.NotZero(t, options.ReadTimeout)
+	require.NotZero(t, options.WriteTimeout)
+	require.NotZero(t, options.Backoff.Min)
+	require.NotZero(t, options.Backoff.Max)
+	require.NotZero(t, options.Backoff.Factor)
+	require.Equal(t, wspubsubclient.JSONProtocol{}, options.Protocol)
+}
@@ -0,0 +1,25 @@
+package wspubsubclient
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ClientRepeatConnectError returned when trying to connect an already connected client.
+type ClientRepeatConnectError struct{}
+
+// ClientRepeatConnectError implements an error interface.
+func (e *ClientRepeatConnectError) Error() string {
+	return "wspubsubclient: client is already connected"
+}
+
+// NewClientRepeatConnectError initializes a new ClientRepeatConnectError.
+func NewClientRepeatConnectError() *ClientRepeatConnectError {
+	return &ClientRepeatConnectError{}
+}
+
+// IsClientRepeatConnectError checks if error type is ClientRepeatConnectError.
+func IsClientRepeatConnectError(err error) (*ClientRepeatConnectError, bool) {
+	v, ok := errors.Cause(err).(*ClientRepeatConnectError)
+
+	return v, ok
+}
@@ -0,0 +1,23 @@
+package wspubsubclient_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub/wspubsubclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRepeatConnectError(t *testing.T) {
+	rawErr := errors.New("TEST")
+	err := wspubsubclient.NewClientRepeatConnectError()
+	require.NotEmpty(t, err.Error())
+
+	e, ok := wspubsubclient.IsClientRepeatConnectError(err)
+	require.NotNil(t, e)
+	require.True(t, ok)
+
+	e, ok = wspubsubclient.IsClientRepeatConnectError(rawErr)
+	require.Nil(t, e)
+	require.False(t, ok)
+}
@@ -0,0 +1,351 @@
+package wspubsubclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubclient"
+	"github.com/stretchr/testify/require"
+)
+
+type testCommand struct {
+	Command  string            `json:"command"`
+	Channels []string          `json:"channels"`
+	Offsets  map[string]string `json:"offsets"`
+}
+
+type testPayload struct {
+	Channel string `json:"channel"`
+	Offset  int    `json:"offset"`
+	Data    string `json:"data"`
+}
+
+type testServer struct {
+	hub       *wspubsub.Hub
+	server    *httptest.Server
+	mu        sync.Mutex
+	clientIDs []wspubsub.UUID
+	commands  chan testCommand
+}
+
+func (s *testServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *testServer) ClientID(t *testing.T) wspubsub.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	require.NotEmpty(t, s.clientIDs)
+
+	return s.clientIDs[len(s.clientIDs)-1]
+}
+
+func (s *testServer) Command(t *testing.T) testCommand {
+	select {
+	case command := <-s.commands:
+		return command
+	case <-time.After(5 * time.Second):
+		t.Fatal("Command wasn't received")
+	}
+
+	return testCommand{}
+}
+
+func (s *testServer) Publish(t *testing.T, payload testPayload) {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	_, err = s.hub.Publish(wspubsub.NewTextMessage(data), payload.Channel)
+	require.NoError(t, err)
+}
+
+func (s *testServer) Close() {
+	_ = s.hub.Close()
+	s.server.Close()
+}
+
+func newTestServer(t *testing.T) *testServer {
+	logger := wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	upgrader := wspubsub.NewGorillaConnectionUpgrader(wspubsub.NewGorillaConnectionUpgraderOptions(), nil)
+	clientStore := wspubsub.NewClientStore(wspubsub.NewClientStoreOptions(), nil)
+	clientFactory := wspubsub.NewClientFactory(wspubsub.NewClientOptions(), wspubsub.SatoriUUIDGenerator{}, upgrader, nil)
+	hub := wspubsub.NewHub(wspubsub.NewHubOptions(), clientStore, clientFactory, logger)
+
+	s := &testServer{hub: hub, commands: make(chan testCommand, 16)}
+
+	hub.OnConnect(func(clientID wspubsub.UUID) {
+		s.mu.Lock()
+		s.clientIDs = append(s.clientIDs, clientID)
+		s.mu.Unlock()
+	})
+
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		command := testCommand{}
+		err := json.Unmarshal(message.Payload, &command)
+		if err != nil {
+			t.Error(err)
+		}
+
+		switch command.Command {
+		case "SUBSCRIBE":
+			err = hub.Subscribe(clientID, command.Channels...)
+		case "UNSUBSCRIBE":
+			err = hub.Unsubscribe(clientID, command.Channels...)
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		s.commands <- command
+	})
+
+	s.server = httptest.NewServer(hub)
+
+	return s
+}
+
+func newTestClientOptions() wspubsubclient.ClientOptions {
+	options := wspubsubclient.NewClientOptions()
+	options.PingInterval = 50 * time.Millisecond
+	options.Backoff.Min = 10 * time.Millisecond
+	options.Backoff.Max = 50 * time.Millisecond
+
+	return options
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	client := wspubsubclient.NewClient(newTestClientOptions(), server.URL())
+
+	payloads := make(chan testPayload, 16)
+	wspubsubclient.HandleJSON(client, func(message wspubsubclient.Message, payload testPayload) {
+		if payload.Channel != message.Channel {
+			t.Errorf("Unexpected channel: %s", message.Channel)
+		}
+
+		payloads <- payload
+	})
+
+	connects := make(chan struct{}, 16)
+	client.OnConnect(func() {
+		connects <- struct{}{}
+	})
+
+	disconnects := make(chan error, 16)
+	client.OnDisconnect(func(err error) {
+		disconnects <- err
+	})
+
+	err := client.Subscribe("X")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = client.Connect(ctx)
+	require.NoError(t, err)
+	<-connects
+
+	err = client.Connect(ctx)
+	_, ok := wspubsubclient.IsClientRepeatConnectError(err)
+	require.True(t, ok)
+
+	command := server.Command(t)
+	require.Equal(t, testCommand{Command: "SUBSCRIBE", Channels: []string{"X"}}, command)
+
+	server.Publish(t, testPayload{Channel: "X", Offset: 1, Data: "first"})
+	require.Equal(t, "first", (<-payloads).Data)
+
+	offset, ok := client.Offset("X")
+	require.True(t, ok)
+	require.Equal(t, "1", offset)
+
+	err = server.hub.Disconnect(server.ClientID(t))
+	require.NoError(t, err)
+	require.Error(t, <-disconnects)
+
+	command = server.Command(t)
+	require.Equal(t, testCommand{Command: "SUBSCRIBE", Channels: []string{"X"}, Offsets: map[string]string{"X": "1"}}, command)
+	<-connects
+
+	server.Publish(t, testPayload{Channel: "X", Offset: 2, Data: "second"})
+	require.Equal(t, "second", (<-payloads).Data)
+
+	err = client.Close()
+	require.NoError(t, err)
+
+	err = client.Connect(ctx)
+	_, ok = wspubsubclient.IsClientClosedError(err)
+	require.True(t, ok)
+
+	err = client.Send(wspubsub.NewTextMessageFromString("{}"))
+	_, ok = wspubsubclient.IsClientClosedError(err)
+	require.True(t, ok)
+}
+
+func TestClient_SubscribeAndSend(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	client := wspubsubclient.NewClient(newTestClientOptions(), server.URL())
+	defer client.Close()
+
+	errs := make(chan error, 16)
+	client.OnError(func(err error) {
+		errs <- err
+	})
+
+	wspubsubclient.HandleJSON(client, func(message wspubsubclient.Message, payload testPayload) {
+		t.Error("Unexpected call of: message_handler")
+	})
+
+	err := client.Send(wspubsub.NewTextMessageFromString("{}"))
+	_, ok := wspubsubclient.IsClientNotConnectedError(err)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = client.Connect(ctx)
+	require.NoError(t, err)
+
+	err = client.Subscribe("Y", "X")
+	require.NoError(t, err)
+	require.Equal(t, testCommand{Command: "SUBSCRIBE", Channels: []string{"Y", "X"}}, server.Command(t))
+	require.Equal(t, []string{"X", "Y"}, client.Channels())
+
+	err = client.Unsubscribe("Y")
+	require.NoError(t, err)
+	require.Equal(t, testCommand{Command: "UNSUBSCRIBE", Channels: []string{"Y"}}, server.Command(t))
+
+	err = client.Send(wspubsub.NewTextMessageFromString(`{"command":"PING"}`))
+	require.NoError(t, err)
+	require.Equal(t, testCommand{Command: "PING"}, server.Command(t))
+
+	_, err = server.hub.Publish(wspubsub.NewTextMessageFromString("not json"), "X")
+	require.NoError(t, err)
+
+	err = <-errs
+	decodeErr, ok := wspubsubclient.IsClientDecodeError(err)
+	require.True(t, ok)
+	require.Equal(t, "not json", string(decodeErr.Message.Payload))
+
+	err = client.Unsubscribe()
+	require.NoError(t, err)
+	require.Equal(t, testCommand{Command: "UNSUBSCRIBE", Channels: []string{"X"}}, server.Command(t))
+	require.Empty(t, client.Channels())
+}
+
+func TestClient_ConnectRetry(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	url := "ws://" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	client := wspubsubclient.NewClient(newTestClientOptions(), url)
+
+	numErrors := 0
+	client.OnError(func(err error) {
+		numErrors++
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = client.Connect(ctx)
+	require.Error(t, err)
+	require.Greater(t, numErrors, 1)
+
+	err = client.Close()
+	require.NoError(t, err)
+}
+
+func TestClient_CloseFromHandler(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	waitClosed := func(t *testing.T, closed chan error) {
+		select {
+		case err := <-closed:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Client wasn't closed by the handler")
+		}
+	}
+
+	t.Run("Message handler", func(t *testing.T) {
+		client := wspubsubclient.NewClient(newTestClientOptions(), server.URL())
+
+		closed := make(chan error, 1)
+		client.OnMessage(func(message wspubsubclient.Message) {
+			closed <- client.Close()
+		})
+
+		err := client.Subscribe("X")
+		require.NoError(t, err)
+
+		err = client.Connect(ctx)
+		require.NoError(t, err)
+		require.Equal(t, testCommand{Command: "SUBSCRIBE", Channels: []string{"X"}}, server.Command(t))
+
+		server.Publish(t, testPayload{Channel: "X", Offset: 1, Data: "first"})
+		waitClosed(t, closed)
+	})
+
+	t.Run("Disconnect handler", func(t *testing.T) {
+		client := wspubsubclient.NewClient(newTestClientOptions(), server.URL())
+
+		closed := make(chan error, 1)
+		client.OnDisconnect(func(err error) {
+			closed <- client.Close()
+		})
+
+		connects := make(chan struct{}, 1)
+		client.OnConnect(func() {
+			connects <- struct{}{}
+		})
+
+		err := client.Connect(ctx)
+		require.NoError(t, err)
+		<-connects
+
+		err = server.hub.Disconnect(server.ClientID(t))
+		require.NoError(t, err)
+		waitClosed(t, closed)
+
+		err = client.Connect(ctx)
+		_, ok := wspubsubclient.IsClientClosedError(err)
+		require.True(t, ok)
+	})
+
+	t.Run("Connect handler", func(t *testing.T) {
+		client := wspubsubclient.NewClient(newTestClientOptions(), server.URL())
+
+		closed := make(chan error, 1)
+		client.OnConnect(func() {
+			closed <- client.Close()
+		})
+
+		err := client.Connect(ctx)
+		require.NoError(t, err)
+		waitClosed(t, closed)
+	})
+}
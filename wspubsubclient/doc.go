@@ -0,0 +1,6 @@
+// Package wspubsubclient provides a Go client of a wspubsub hub.
+// The client reconnects with an exponential backoff after a connection is lost,
+// resubscribes to its channels and asks the hub to resume them from the last seen offsets.
+// The messages used to subscribe and to carry offsets are defined by a Protocol,
+// JSONProtocol is used by default.
+package wspubsubclient
@@ -0,0 +1,24 @@
+package wspubsubclient
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// HandleJSON registers a message handler of the client which decodes JSON payloads into values of type T.
+// Messages which can't be decoded are reported to the error handler as ClientDecodeError.
+func HandleJSON[T any](client *Client, handler func(message Message, v T)) {
+	client.OnMessage(func(message Message) {
+		var v T
+
+		err := json.Unmarshal(message.Payload, &v)
+		if err != nil {
+			client.reportError(errors.WithStack(NewClientDecodeError(message, err)))
+
+			return
+		}
+
+		handler(message, v)
+	})
+}
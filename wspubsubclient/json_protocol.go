@@ -0,0 +1,75 @@
+package wspubsubclient
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+)
+
+var _ Protocol = JSONProtocol{}
+
+// JSONProtocol is an implementation of Protocol using JSON commands like:
+// {"command":"SUBSCRIBE","channels":["X"],"offsets":{"X":"42"}}.
+// A channel and an offset are read from top-level "channel" and "offset" fields
+// of received JSON objects, e.g. {"channel":"X","offset":43,"data":...}.
+type JSONProtocol struct{}
+
+type jsonCommand struct {
+	Command  string            `json:"command"`
+	Channels []string          `json:"channels"`
+	Offsets  map[string]string `json:"offsets,omitempty"`
+}
+
+type jsonEnvelope struct {
+	Channel string          `json:"channel"`
+	Offset  json.RawMessage `json:"offset"`
+}
+
+// SubscribeMessage creates a SUBSCRIBE command.
+func (p JSONProtocol) SubscribeMessage(channels []string, offsets map[string]string) (wspubsub.Message, error) {
+	return p.command(jsonCommand{Command: "SUBSCRIBE", Channels: channels, Offsets: offsets})
+}
+
+// UnsubscribeMessage creates an UNSUBSCRIBE command.
+func (p JSONProtocol) UnsubscribeMessage(channels []string) (wspubsub.Message, error) {
+	return p.command(jsonCommand{Command: "UNSUBSCRIBE", Channels: channels})
+}
+
+// Decode reads a channel and an offset of a JSON object.
+// Both string and numeric offsets are supported.
+func (p JSONProtocol) Decode(message wspubsub.Message) Message {
+	decoded := Message{Message: message}
+
+	payload := bytes.TrimSpace(message.Payload)
+	if len(payload) == 0 || payload[0] != '{' {
+		return decoded
+	}
+
+	envelope := jsonEnvelope{}
+	err := json.Unmarshal(payload, &envelope)
+	if err != nil {
+		return decoded
+	}
+
+	decoded.Channel = envelope.Channel
+
+	var offset string
+	if json.Unmarshal(envelope.Offset, &offset) == nil {
+		decoded.Offset = offset
+	} else if len(envelope.Offset) > 0 && envelope.Offset[0] != 'n' {
+		decoded.Offset = string(envelope.Offset)
+	}
+
+	return decoded
+}
+
+func (p JSONProtocol) command(command jsonCommand) (wspubsub.Message, error) {
+	payload, err := json.Marshal(command)
+	if err != nil {
+		return wspubsub.Message{}, errors.WithStack(err)
+	}
+
+	return wspubsub.NewTextMessage(payload), nil
+}
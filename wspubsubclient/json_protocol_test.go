@@ -0,0 +1,56 @@
+package wspubsubclient_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONProtocol_SubscribeMessage(t *testing.T) {
+	protocol := wspubsubclient.JSONProtocol{}
+
+	message, err := protocol.SubscribeMessage([]string{"X", "Y"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, wspubsub.MessageTypeText, message.Type)
+	require.JSONEq(t, `{"command":"SUBSCRIBE","channels":["X","Y"]}`, string(message.Payload))
+
+	message, err = protocol.SubscribeMessage([]string{"X"}, map[string]string{"X": "42"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"command":"SUBSCRIBE","channels":["X"],"offsets":{"X":"42"}}`, string(message.Payload))
+}
+
+func TestJSONProtocol_UnsubscribeMessage(t *testing.T) {
+	protocol := wspubsubclient.JSONProtocol{}
+
+	message, err := protocol.UnsubscribeMessage([]string{"X"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"command":"UNSUBSCRIBE","channels":["X"]}`, string(message.Payload))
+}
+
+func TestJSONProtocol_Decode(t *testing.T) {
+	protocol := wspubsubclient.JSONProtocol{}
+
+	testCases := []struct {
+		payload string
+		channel string
+		offset  string
+	}{
+		{payload: `{"channel":"X","offset":43,"data":1}`, channel: "X", offset: "43"},
+		{payload: ` {"channel":"X","offset":"a1"}`, channel: "X", offset: "a1"},
+		{payload: `{"channel":"X","offset":null}`, channel: "X"},
+		{payload: `{"channel":"X"}`, channel: "X"},
+		{payload: `[1, 2]`},
+		{payload: `{broken`},
+		{payload: ``},
+	}
+
+	for _, testCase := range testCases {
+		message := wspubsub.NewTextMessageFromString(testCase.payload)
+		decoded := protocol.Decode(message)
+		require.Equal(t, message, decoded.Message, testCase.payload)
+		require.Equal(t, testCase.channel, decoded.Channel, testCase.payload)
+		require.Equal(t, testCase.offset, decoded.Offset, testCase.payload)
+	}
+}
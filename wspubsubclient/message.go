@@ -0,0 +1,17 @@
+package wspubsubclient
+
+import (
+	"github.com/kpeu3i/wspubsub"
+)
+
+// Message represents a message received from the hub.
+type Message struct {
+	wspubsub.Message
+
+	// Channel the message was published to (empty if the protocol doesn't carry it)
+	Channel string
+
+	// Position of the message in the channel (empty if the protocol doesn't carry it).
+	// The last seen offset of a channel is sent on resubscription.
+	Offset string
+}
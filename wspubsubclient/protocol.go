@@ -0,0 +1,19 @@
+package wspubsubclient
+
+import (
+	"github.com/kpeu3i/wspubsub"
+)
+
+// Protocol is an interface responsible for encoding subscription requests
+// and decoding received messages.
+type Protocol interface {
+	// SubscribeMessage creates a message subscribing to the channels.
+	// Offsets contain the last seen offset of channels which should be resumed.
+	SubscribeMessage(channels []string, offsets map[string]string) (wspubsub.Message, error)
+
+	// UnsubscribeMessage creates a message unsubscribing from the channels
+	UnsubscribeMessage(channels []string) (wspubsub.Message, error)
+
+	// Decode extracts a channel and an offset of the received message
+	Decode(message wspubsub.Message) Message
+}
@@ -0,0 +1,101 @@
+package wspubsubtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+)
+
+var _ wspubsub.Clock = (*ManualClock)(nil)
+
+// ManualClock is an implementation of wspubsub.Clock whose time moves only on Advance.
+// Timers are fired synchronously by Advance, so scheduled work is done once it returns.
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	when  time.Time
+	f     func()
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// AfterFunc calls f once the clock is advanced by the duration.
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) wspubsub.ClockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &manualTimer{clock: c, when: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+
+	return timer
+}
+
+// Advance moves the time forward firing due timers in order of their time.
+// Timers created by fired functions are also fired if they become due.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	until := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		next := -1
+		for i, timer := range c.timers {
+			if !timer.when.After(until) && (next == -1 || timer.when.Before(c.timers[next].when)) {
+				next = i
+			}
+		}
+
+		if next == -1 {
+			// Fired functions could advance the clock further
+			if until.After(c.now) {
+				c.now = until
+			}
+			c.mu.Unlock()
+
+			return
+		}
+
+		timer := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if timer.when.After(c.now) {
+			c.now = timer.when
+		}
+		c.mu.Unlock()
+
+		timer.f()
+	}
+}
+
+// Stop removes the timer from the clock.
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewManualClock initializes a new ManualClock set to the time.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
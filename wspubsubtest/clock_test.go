@@ -0,0 +1,44 @@
+package wspubsubtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := wspubsubtest.NewManualClock(start)
+	require.Equal(t, start, clock.Now())
+
+	var fired []time.Duration
+	record := func() {
+		fired = append(fired, clock.Now().Sub(start))
+	}
+
+	clock.AfterFunc(2*time.Second, record)
+	clock.AfterFunc(time.Second, func() {
+		record()
+
+		// Timers created while advancing fire within the same advance
+		clock.AfterFunc(500*time.Millisecond, record)
+	})
+
+	stopped := clock.AfterFunc(time.Second, record)
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+
+	clock.Advance(500 * time.Millisecond)
+	require.Empty(t, fired)
+
+	clock.Advance(5 * time.Second)
+	require.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second}, fired)
+	require.Equal(t, start.Add(5500*time.Millisecond), clock.Now())
+
+	past := clock.AfterFunc(-time.Second, record)
+	clock.Advance(0)
+	require.Len(t, fired, 4)
+	require.False(t, past.Stop())
+}
@@ -0,0 +1,88 @@
+package wspubsubtest
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+)
+
+var _ wspubsub.WebsocketConnection = (*Conn)(nil)
+
+// Conn is an in-memory implementation of wspubsub.WebsocketConnection.
+// Messages written to one side of a pair are read from the other side.
+type Conn struct {
+	inbound chan wspubsub.Message
+	peer    *Conn
+	closed  chan struct{}
+	once    *sync.Once
+}
+
+// Read returns the next message written by the peer.
+// Messages written before closing are still readable.
+func (c *Conn) Read() (wspubsub.Message, error) {
+	select {
+	case message := <-c.inbound:
+		return message, nil
+	case <-c.closed:
+		select {
+		case message := <-c.inbound:
+			return message, nil
+		default:
+		}
+
+		return wspubsub.Message{}, errors.WithStack(wspubsub.NewConnectionClosedError(io.EOF))
+	}
+}
+
+// Write passes a message to the peer.
+// It blocks while the buffer of the peer is full like a real socket does.
+func (c *Conn) Write(message wspubsub.Message) error {
+	select {
+	case <-c.closed:
+		return errors.WithStack(wspubsub.NewConnectionClosedError(io.ErrClosedPipe))
+	default:
+	}
+
+	select {
+	case c.peer.inbound <- message:
+		return nil
+	case <-c.closed:
+		return errors.WithStack(wspubsub.NewConnectionClosedError(io.ErrClosedPipe))
+	}
+}
+
+// Close closes both sides of the pair.
+func (c *Conn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+
+	return nil
+}
+
+// Closed returns a channel which is closed when the pair is closed.
+func (c *Conn) Closed() <-chan struct{} {
+	return c.closed
+}
+
+// RTT always returns zero since there is no network.
+func (c *Conn) RTT() time.Duration {
+	return 0
+}
+
+// NewConnPair initializes two connected sides of an in-memory connection.
+// Each side buffers up to bufferSize unread messages.
+func NewConnPair(bufferSize int) (*Conn, *Conn) {
+	closed := make(chan struct{})
+	once := &sync.Once{}
+
+	server := &Conn{inbound: make(chan wspubsub.Message, bufferSize), closed: closed, once: once}
+	client := &Conn{inbound: make(chan wspubsub.Message, bufferSize), closed: closed, once: once}
+	server.peer = client
+	client.peer = server
+
+	return server, client
+}
@@ -0,0 +1,47 @@
+package wspubsubtest_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnPair(t *testing.T) {
+	server, client := wspubsubtest.NewConnPair(2)
+
+	err := server.Write(wspubsub.NewTextMessageFromString("X"))
+	require.NoError(t, err)
+	err = client.Write(wspubsub.NewTextMessageFromString("Y"))
+	require.NoError(t, err)
+
+	message, err := client.Read()
+	require.NoError(t, err)
+	require.Equal(t, "X", string(message.Payload))
+
+	message, err = server.Read()
+	require.NoError(t, err)
+	require.Equal(t, "Y", string(message.Payload))
+	require.Zero(t, server.RTT())
+
+	err = server.Write(wspubsub.NewTextMessageFromString("Z"))
+	require.NoError(t, err)
+	require.NoError(t, server.Close())
+	require.NoError(t, client.Close())
+
+	<-client.Closed()
+
+	// Messages written before closing are still readable
+	message, err = client.Read()
+	require.NoError(t, err)
+	require.Equal(t, "Z", string(message.Payload))
+
+	_, err = client.Read()
+	_, ok := wspubsub.IsConnectionClosedError(err)
+	require.True(t, ok)
+
+	err = client.Write(wspubsub.NewTextMessageFromString("X"))
+	_, ok = wspubsub.IsConnectionClosedError(err)
+	require.True(t, ok)
+}
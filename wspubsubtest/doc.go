@@ -0,0 +1,10 @@
+// Package wspubsubtest provides utilities for testing code built on top of wspubsub.
+// Connections are emulated in memory, so tests don't need real sockets:
+//
+//	harness := wspubsubtest.NewHarness(t, wspubsubtest.NewHarnessOptions())
+//	harness.Hub().OnReceive(...)
+//
+//	clients := harness.ConnectN(3)
+//	clients[0].SendText(`{"command":"SUBSCRIBE","channels":["X"]}`)
+//	clients[0].ExpectText(`{"subscribed":"X"}`)
+package wspubsubtest
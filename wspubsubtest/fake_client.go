@@ -0,0 +1,254 @@
+package wspubsubtest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+)
+
+// FakeClient is a client connected to the hub through an in-memory connection.
+// Messages written by the hub are buffered in the background (pings are skipped),
+// expectations consume them in order and fail the test if nothing arrives in time.
+type FakeClient struct {
+	t        testing.TB
+	id       wspubsub.UUID
+	conn     *Conn
+	timeout  time.Duration
+	mu       sync.Mutex
+	received []wspubsub.Message
+	cursor   int
+	notify   chan struct{}
+	done     chan struct{}
+}
+
+// ID returns the ID of the client in the hub.
+func (c *FakeClient) ID() wspubsub.UUID {
+	return c.id
+}
+
+// Send writes a message to the hub as if the client sent it.
+func (c *FakeClient) Send(message wspubsub.Message) {
+	c.t.Helper()
+
+	err := c.conn.Write(message)
+	if err != nil {
+		c.t.Fatalf("wspubsubtest: client %s can't send message: %s", c.id, err)
+	}
+}
+
+// SendText writes a text message to the hub.
+func (c *FakeClient) SendText(payload string) {
+	c.t.Helper()
+	c.Send(wspubsub.NewTextMessageFromString(payload))
+}
+
+// SendJSON writes a text message with the JSON encoding of v to the hub.
+func (c *FakeClient) SendJSON(v interface{}) {
+	c.t.Helper()
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		c.t.Fatalf("wspubsubtest: client %s can't encode message: %s", c.id, err)
+	}
+
+	c.Send(wspubsub.NewTextMessage(payload))
+}
+
+// Receive returns the next message written by the hub.
+func (c *FakeClient) Receive() wspubsub.Message {
+	c.t.Helper()
+
+	message, ok := c.next(c.timeout)
+	if !ok {
+		c.t.Fatalf("wspubsubtest: client %s didn't receive a message within %s", c.id, c.timeout)
+	}
+
+	return message
+}
+
+// Expect checks that the next messages written by the hub are equal to the messages.
+func (c *FakeClient) Expect(messages ...wspubsub.Message) {
+	c.t.Helper()
+
+	for _, expected := range messages {
+		actual := c.Receive()
+		if actual.Type != expected.Type || !bytes.Equal(actual.Payload, expected.Payload) {
+			c.t.Fatalf(
+				"wspubsubtest: client %s received unexpected message:\nexpected: type=%d, payload=%q\nactual:   type=%d, payload=%q",
+				c.id, expected.Type, expected.Payload, actual.Type, actual.Payload,
+			)
+		}
+	}
+}
+
+// ExpectText checks that the next messages written by the hub are text messages with the payloads.
+func (c *FakeClient) ExpectText(payloads ...string) {
+	c.t.Helper()
+
+	for _, payload := range payloads {
+		c.Expect(wspubsub.NewTextMessageFromString(payload))
+	}
+}
+
+// ExpectJSON checks that the next messages written by the hub are JSON documents
+// equal to the payloads ignoring formatting and order of keys.
+func (c *FakeClient) ExpectJSON(payloads ...string) {
+	c.t.Helper()
+
+	for _, payload := range payloads {
+		var expected interface{}
+		err := json.Unmarshal([]byte(payload), &expected)
+		if err != nil {
+			c.t.Fatalf("wspubsubtest: expected payload is not a valid JSON: %s", err)
+		}
+
+		actual := c.Receive()
+
+		var v interface{}
+		err = json.Unmarshal(actual.Payload, &v)
+		if err != nil || !reflect.DeepEqual(expected, v) {
+			c.t.Fatalf(
+				"wspubsubtest: client %s received unexpected message:\nexpected: %s\nactual:   %s",
+				c.id, payload, actual.Payload,
+			)
+		}
+	}
+}
+
+// ExpectNothing checks that the hub doesn't write any message within the duration.
+func (c *FakeClient) ExpectNothing(d time.Duration) {
+	c.t.Helper()
+
+	message, ok := c.next(d)
+	if ok {
+		c.t.Fatalf("wspubsubtest: client %s received unexpected message: type=%d, payload=%q", c.id, message.Type, message.Payload)
+	}
+}
+
+// ExpectClosed checks that the hub closes the connection.
+// Unread messages are skipped.
+func (c *FakeClient) ExpectClosed() {
+	c.t.Helper()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+	case <-timer.C:
+		c.t.Fatalf("wspubsubtest: client %s wasn't disconnected within %s", c.id, c.timeout)
+	}
+}
+
+// ExpectClosedWithCode checks that the hub closes the connection sending a close message with the code.
+func (c *FakeClient) ExpectClosedWithCode(code wspubsub.CloseCode) {
+	c.t.Helper()
+
+	c.ExpectClosed()
+
+	for _, message := range c.Received() {
+		if message.Type != wspubsub.MessageTypeClose || len(message.Payload) < 2 {
+			continue
+		}
+
+		actual := wspubsub.CloseCode(binary.BigEndian.Uint16(message.Payload))
+		if actual != code {
+			c.t.Fatalf("wspubsubtest: client %s was disconnected with unexpected code: expected=%d, actual=%d", c.id, code, actual)
+		}
+
+		return
+	}
+
+	c.t.Fatalf("wspubsubtest: client %s was disconnected without a close message", c.id)
+}
+
+// Received returns all messages written by the hub so far (including consumed ones).
+func (c *FakeClient) Received() []wspubsub.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	received := make([]wspubsub.Message, len(c.received))
+	copy(received, c.received)
+
+	return received
+}
+
+// Close closes the connection as if the client disconnected.
+func (c *FakeClient) Close() {
+	_ = c.conn.Close()
+}
+
+func (c *FakeClient) next(timeout time.Duration) (wspubsub.Message, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	isDone := false
+	for {
+		c.mu.Lock()
+		if c.cursor < len(c.received) {
+			message := c.received[c.cursor]
+			c.cursor++
+			c.mu.Unlock()
+
+			return message, true
+		}
+		c.mu.Unlock()
+
+		if isDone {
+			return wspubsub.Message{}, false
+		}
+
+		select {
+		case <-c.notify:
+		case <-c.done:
+			isDone = true
+		case <-timer.C:
+			return wspubsub.Message{}, false
+		}
+	}
+}
+
+func (c *FakeClient) runReader() {
+	defer close(c.done)
+
+	for {
+		message, err := c.conn.Read()
+		if err != nil {
+			return
+		}
+
+		if message.Type == wspubsub.MessageTypePing {
+			continue
+		}
+
+		c.mu.Lock()
+		c.received = append(c.received, message)
+		c.mu.Unlock()
+
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func newFakeClient(t testing.TB, id wspubsub.UUID, conn *Conn, timeout time.Duration) *FakeClient {
+	client := &FakeClient{
+		t:       t,
+		id:      id,
+		conn:    conn,
+		timeout: timeout,
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	go client.runReader()
+
+	return client
+}
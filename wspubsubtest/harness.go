@@ -0,0 +1,106 @@
+package wspubsubtest
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+)
+
+// Harness connects in-memory clients to a real hub.
+// The hub is closed when the test finishes.
+type Harness struct {
+	t             testing.TB
+	options       HarnessOptions
+	hub           *wspubsub.Hub
+	upgrader      *Upgrader
+	uuidGenerator *SequentialUUIDGenerator
+	mu            sync.Mutex
+}
+
+// Hub returns the hub under test.
+// Handlers should be registered before connecting clients.
+func (h *Harness) Hub() *wspubsub.Hub {
+	return h.hub
+}
+
+// Connect connects a new client and fails the test if the connection is rejected.
+func (h *Harness) Connect() *FakeClient {
+	h.t.Helper()
+
+	client, err := h.ConnectRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		h.t.Fatalf("wspubsubtest: can't connect client: %s", err)
+	}
+
+	return client
+}
+
+// ConnectN connects n new clients.
+func (h *Harness) ConnectN(n int) []*FakeClient {
+	h.t.Helper()
+
+	clients := make([]*FakeClient, 0, n)
+	for i := 0; i < n; i++ {
+		clients = append(clients, h.Connect())
+	}
+
+	return clients
+}
+
+// ConnectRequest connects a new client using the request (e.g. with credentials).
+// It returns an error with the status code if the hub rejects the connection.
+func (h *Harness) ConnectRequest(request *http.Request) (*FakeClient, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	response := httptest.NewRecorder()
+	h.hub.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		return nil, errors.Errorf("wspubsubtest: connection is rejected: status=%d", response.Code)
+	}
+
+	conn, err := h.upgrader.Accept(h.options.Timeout)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return newFakeClient(h.t, h.uuidGenerator.Last(), conn, h.options.Timeout), nil
+}
+
+// Close closes the hub and disconnects all clients.
+func (h *Harness) Close() {
+	_ = h.hub.Close()
+}
+
+// NewHarness initializes a new Harness with a hub configured by options.
+func NewHarness(t testing.TB, options HarnessOptions) *Harness {
+	logger := options.Logger
+	if logger == nil {
+		logger = wspubsub.NewSlogLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	upgrader := NewUpgrader(options.BufferSize)
+	uuidGenerator := &SequentialUUIDGenerator{}
+
+	clientStore := wspubsub.NewClientStore(options.ClientStoreOptions, logger)
+	clientFactory := wspubsub.NewClientFactory(options.ClientOptions, uuidGenerator, upgrader, logger)
+	hub := wspubsub.NewHub(options.HubOptions, clientStore, clientFactory, logger)
+
+	harness := &Harness{
+		t:             t,
+		options:       options,
+		hub:           hub,
+		upgrader:      upgrader,
+		uuidGenerator: uuidGenerator,
+	}
+
+	t.Cleanup(harness.Close)
+
+	return harness
+}
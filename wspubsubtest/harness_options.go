@@ -0,0 +1,42 @@
+package wspubsubtest
+
+import (
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+)
+
+// HarnessOptions represents configuration of the Harness.
+type HarnessOptions struct {
+	// Configuration of the hub under test
+	HubOptions wspubsub.HubOptions
+
+	// Configuration of clients created by the hub
+	ClientOptions wspubsub.ClientOptions
+
+	// Configuration of the client store
+	ClientStoreOptions wspubsub.ClientStoreOptions
+
+	// Logger of the hub (nil discards log messages)
+	Logger wspubsub.Logger
+
+	// Number of unread messages buffered by each side of a connection
+	BufferSize int
+
+	// How long expectations wait for a message
+	Timeout time.Duration
+}
+
+// NewHarnessOptions initializes a new HarnessOptions.
+// nolint: gomnd
+func NewHarnessOptions() HarnessOptions {
+	options := HarnessOptions{
+		HubOptions:         wspubsub.NewHubOptions(),
+		ClientOptions:      wspubsub.NewClientOptions(),
+		ClientStoreOptions: wspubsub.NewClientStoreOptions(),
+		BufferSize:         1000,
+		Timeout:            time.Second,
+	}
+
+	return options
+}
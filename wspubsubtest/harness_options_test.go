@@ -0,0 +1,19 @@
+package wspubsubtest_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHarnessOptions(t *testing.T) {
+	options := wspubsubtest.NewHarnessOptions()
+	require.Equal(t, wspubsub.NewHubOptions().ShutdownTimeout, options.HubOptions.ShutdownTimeout)
+	require.Equal(t, wspubsub.NewClientOptions().SendBufferSize, options.ClientOptions.SendBufferSize)
+	require.Equal(t, wspubsub.NewClientStoreOptions(), options.ClientStoreOptions)
+	require.Nil(t, options.Logger)
+	require.NotZero(t, options.BufferSize)
+	require.NotZero(t, options.Timeout)
+}
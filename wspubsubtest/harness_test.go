@@ -0,0 +1,79 @@
+package wspubsubtest_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+type command struct {
+	Command  string   `json:"command"`
+	Channels []string `json:"channels"`
+}
+
+func TestHarness(t *testing.T) {
+	options := wspubsubtest.NewHarnessOptions()
+	options.HubOptions.ConnectionLimits.Total = 3
+
+	harness := wspubsubtest.NewHarness(t, options)
+
+	hub := harness.Hub()
+	hub.OnReceive(func(clientID wspubsub.UUID, message wspubsub.Message) {
+		c := command{}
+		err := json.Unmarshal(message.Payload, &c)
+		if err != nil {
+			t.Error(err)
+
+			return
+		}
+
+		err = hub.Subscribe(clientID, c.Channels...)
+		if err != nil {
+			t.Error(err)
+
+			return
+		}
+
+		_ = hub.Send(clientID, wspubsub.NewTextMessageFromString(`{"subscribed": true}`))
+	})
+
+	clients := harness.ConnectN(3)
+	require.Equal(t, wspubsubtest.SequentialUUID(1), clients[0].ID())
+	require.Equal(t, wspubsubtest.SequentialUUID(3), clients[2].ID())
+	require.Equal(t, 3, hub.Count())
+
+	_, err := harness.ConnectRequest(httptest.NewRequest("GET", "/", nil))
+	require.Error(t, err)
+
+	for _, client := range clients[:2] {
+		client.SendJSON(command{Command: "SUBSCRIBE", Channels: []string{"X"}})
+		client.ExpectJSON(`{"subscribed":true}`)
+	}
+
+	numClients, err := hub.Publish(wspubsub.NewTextMessageFromString("hello"), "X")
+	require.NoError(t, err)
+	require.Equal(t, 2, numClients)
+
+	clients[0].ExpectText("hello")
+	clients[1].Expect(wspubsub.NewTextMessageFromString("hello"))
+	clients[2].ExpectNothing(10 * time.Millisecond)
+	require.Len(t, clients[0].Received(), 2)
+
+	err = hub.DisconnectWithCode(clients[0].ID(), wspubsub.CloseCodeGoingAway, "bye")
+	require.NoError(t, err)
+	clients[0].ExpectClosedWithCode(wspubsub.CloseCodeGoingAway)
+
+	clients[1].Close()
+	clients[1].ExpectClosed()
+	require.Eventually(t, func() bool {
+		return hub.Count() == 1
+	}, time.Second, time.Millisecond)
+
+	client := harness.Connect()
+	require.Equal(t, wspubsubtest.SequentialUUID(4), client.ID())
+}
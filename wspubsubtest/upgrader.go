@@ -0,0 +1,51 @@
+package wspubsubtest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/pkg/errors"
+)
+
+var _ wspubsub.WebsocketConnectionUpgrader = (*Upgrader)(nil)
+
+// Upgrader is an implementation of wspubsub.WebsocketConnectionUpgrader creating in-memory connections.
+// The server side of a pair is returned to the hub, the client side is available through Accept.
+type Upgrader struct {
+	bufferSize int
+	conns      chan *Conn
+}
+
+// Upgrade creates a new connection pair.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (wspubsub.WebsocketConnection, error) {
+	server, client := NewConnPair(u.bufferSize)
+
+	select {
+	case u.conns <- client:
+	default:
+		return nil, errors.New("wspubsubtest: too many connections are not accepted")
+	}
+
+	return server, nil
+}
+
+// Accept returns the client side of the next upgraded connection.
+func (u *Upgrader) Accept(timeout time.Duration) (*Conn, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case conn := <-u.conns:
+		return conn, nil
+	case <-timer.C:
+		return nil, errors.New("wspubsubtest: no connection was upgraded")
+	}
+}
+
+// NewUpgrader initializes a new Upgrader.
+// Each side of a connection buffers up to bufferSize unread messages.
+func NewUpgrader(bufferSize int) *Upgrader {
+	// nolint: gomnd
+	return &Upgrader{bufferSize: bufferSize, conns: make(chan *Conn, 1024)}
+}
@@ -0,0 +1,31 @@
+package wspubsubtest_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgrader(t *testing.T) {
+	upgrader := wspubsubtest.NewUpgrader(1)
+
+	_, err := upgrader.Accept(time.Millisecond)
+	require.Error(t, err)
+
+	server, err := upgrader.Upgrade(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+
+	client, err := upgrader.Accept(time.Millisecond)
+	require.NoError(t, err)
+
+	err = client.Write(wspubsub.NewTextMessageFromString("X"))
+	require.NoError(t, err)
+
+	message, err := server.Read()
+	require.NoError(t, err)
+	require.Equal(t, "X", string(message.Payload))
+}
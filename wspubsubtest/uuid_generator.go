@@ -0,0 +1,47 @@
+package wspubsubtest
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/kpeu3i/wspubsub"
+)
+
+var _ wspubsub.UUIDGenerator = (*SequentialUUIDGenerator)(nil)
+
+// SequentialUUIDGenerator is an implementation of wspubsub.UUIDGenerator
+// generating predictable IDs: 00000000-0000-0000-0000-000000000001, 00000000-0000-0000-0000-000000000002, etc.
+type SequentialUUIDGenerator struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+// GenerateV4 returns the next ID.
+func (g *SequentialUUIDGenerator) GenerateV4() wspubsub.UUID {
+	g.mu.Lock()
+	g.last++
+	id := SequentialUUID(g.last)
+	g.mu.Unlock()
+
+	return id
+}
+
+// Last returns the last generated ID (zero if nothing was generated).
+func (g *SequentialUUIDGenerator) Last() wspubsub.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.last == 0 {
+		return wspubsub.UUID{}
+	}
+
+	return SequentialUUID(g.last)
+}
+
+// SequentialUUID returns the n-th ID generated by SequentialUUIDGenerator.
+func SequentialUUID(n uint64) wspubsub.UUID {
+	var id wspubsub.UUID
+	binary.BigEndian.PutUint64(id[8:], n)
+
+	return id
+}
@@ -0,0 +1,22 @@
+package wspubsubtest_test
+
+import (
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/kpeu3i/wspubsub/wspubsubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequentialUUIDGenerator(t *testing.T) {
+	generator := &wspubsubtest.SequentialUUIDGenerator{}
+	require.Equal(t, wspubsub.UUID{}, generator.Last())
+
+	id := generator.GenerateV4()
+	require.Equal(t, "00000000-0000-0000-0000-000000000001", id.String())
+	require.Equal(t, id, generator.Last())
+
+	id = generator.GenerateV4()
+	require.Equal(t, wspubsubtest.SequentialUUID(2), id)
+	require.Equal(t, id, generator.Last())
+}
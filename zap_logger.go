@@ -0,0 +1,42 @@
+package wspubsub
+
+import (
+	"go.uber.org/zap"
+)
+
+var _ Logger = (*ZapLogger)(nil)
+
+// ZapLogger is an implementation of Logger based on go.uber.org/zap.
+type ZapLogger struct {
+	logger *zap.SugaredLogger
+}
+
+// Debug logs a message with fields at level Debug.
+func (l ZapLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.Debugw(msg, keysAndValues...)
+}
+
+// Info logs a message with fields at level Info.
+func (l ZapLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.Infow(msg, keysAndValues...)
+}
+
+// Warn logs a message with fields at level Warn.
+func (l ZapLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.logger.Warnw(msg, keysAndValues...)
+}
+
+// Error logs a message with fields at level Error.
+func (l ZapLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.Errorw(msg, keysAndValues...)
+}
+
+// With returns a logger which adds the fields to every message.
+func (l ZapLogger) With(keysAndValues ...interface{}) Logger {
+	return &ZapLogger{logger: l.logger.With(keysAndValues...)}
+}
+
+// NewZapLogger initializes a new ZapLogger.
+func NewZapLogger(logger *zap.Logger) *ZapLogger {
+	return &ZapLogger{logger: logger.Sugar()}
+}
@@ -0,0 +1,33 @@
+package wspubsub_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestZapLogger(t *testing.T) {
+	output := &bytes.Buffer{}
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(output),
+		zapcore.DebugLevel,
+	)
+
+	logger := wspubsub.NewZapLogger(zap.New(core)).With(wspubsub.LogFieldRemoteAddr, "127.0.0.1:1234")
+	logger.Warn("Client subscribed", wspubsub.LogFieldClientID, clientID, wspubsub.LogFieldChannel, "X")
+
+	entry := map[string]interface{}{}
+	err := json.Unmarshal(output.Bytes(), &entry)
+	require.NoError(t, err)
+	require.Equal(t, "Client subscribed", entry["msg"])
+	require.Equal(t, "warn", entry["level"])
+	require.Equal(t, clientID.String(), entry["client_id"])
+	require.Equal(t, "X", entry["channel"])
+	require.Equal(t, "127.0.0.1:1234", entry["remote_addr"])
+}
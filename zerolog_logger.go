@@ -0,0 +1,42 @@
+package wspubsub
+
+import (
+	"github.com/rs/zerolog"
+)
+
+var _ Logger = (*ZerologLogger)(nil)
+
+// ZerologLogger is an implementation of Logger based on github.com/rs/zerolog.
+type ZerologLogger struct {
+	logger zerolog.Logger
+}
+
+// Debug logs a message with fields at level Debug.
+func (l ZerologLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.Debug().Fields(keysAndValues).Msg(msg)
+}
+
+// Info logs a message with fields at level Info.
+func (l ZerologLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.Info().Fields(keysAndValues).Msg(msg)
+}
+
+// Warn logs a message with fields at level Warn.
+func (l ZerologLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.logger.Warn().Fields(keysAndValues).Msg(msg)
+}
+
+// Error logs a message with fields at level Error.
+func (l ZerologLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.Error().Fields(keysAndValues).Msg(msg)
+}
+
+// With returns a logger which adds the fields to every message.
+func (l ZerologLogger) With(keysAndValues ...interface{}) Logger {
+	return &ZerologLogger{logger: l.logger.With().Fields(keysAndValues).Logger()}
+}
+
+// NewZerologLogger initializes a new ZerologLogger.
+func NewZerologLogger(logger zerolog.Logger) *ZerologLogger {
+	return &ZerologLogger{logger: logger}
+}
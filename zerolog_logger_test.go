@@ -0,0 +1,27 @@
+package wspubsub_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kpeu3i/wspubsub"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZerologLogger(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	logger := wspubsub.NewZerologLogger(zerolog.New(output)).With(wspubsub.LogFieldRemoteAddr, "127.0.0.1:1234")
+	logger.Warn("Client subscribed", wspubsub.LogFieldClientID, clientID, wspubsub.LogFieldChannel, "X")
+
+	entry := map[string]interface{}{}
+	err := json.Unmarshal(output.Bytes(), &entry)
+	require.NoError(t, err)
+	require.Equal(t, "Client subscribed", entry["message"])
+	require.Equal(t, "warn", entry["level"])
+	require.Equal(t, clientID.String(), entry["client_id"])
+	require.Equal(t, "X", entry["channel"])
+	require.Equal(t, "127.0.0.1:1234", entry["remote_addr"])
+}